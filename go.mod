@@ -1,16 +1,40 @@
 module Users/nikokauz/git/ESC-Streaming-Architectures-Thesis-Materializer
 
-go 1.19
+go 1.21
 
 require github.com/lib/pq v1.10.7 // direct
 
 require github.com/joho/godotenv v1.4.0 // direct
 
+require github.com/jackc/pgx/v5 v5.4.3 // direct
+
+require github.com/parquet-go/parquet-go v0.23.0 // direct
+
 require (
-	github.com/mattn/go-runewidth v0.0.14 // direct
+	github.com/mattn/go-runewidth v0.0.15 // direct
 	github.com/mitchellh/colorstring v0.0.0-20190213212951-d06e56a500db // direct
-	github.com/rivo/uniseg v0.4.3 // direct
+	github.com/rivo/uniseg v0.4.7 // direct
 	github.com/schollz/progressbar/v3 v3.13.0 // direct
-	golang.org/x/sys v0.4.0 // direct
-	golang.org/x/term v0.4.0 // direct
+	golang.org/x/sys v0.21.0 // direct
+	golang.org/x/term v0.20.0 // direct
+	golang.org/x/text v0.15.0 // direct
+)
+
+require (
+	google.golang.org/grpc v1.65.0 // direct
+	google.golang.org/protobuf v1.34.2 // direct
+)
+
+require (
+	github.com/andybalholm/brotli v1.1.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
+	github.com/olekukonko/tablewriter v0.0.5 // indirect
+	github.com/pierrec/lz4/v4 v4.1.21 // indirect
+	github.com/segmentio/encoding v0.4.0 // indirect
+	golang.org/x/crypto v0.23.0 // indirect
+	golang.org/x/net v0.25.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240528184218-531527333157 // indirect
 )
@@ -0,0 +1,157 @@
+package main
+
+/*
+@author 1Zero64
+Aggregation of the transformed measurements into hourly buckets per sensor for long-term storage
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for math functions
+	"math"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Object structure for the key of an hourly bucket
+type BucketKey struct {
+	// Id of the sensor
+	sensor_id int64
+	// Start of the hour of the bucket
+	bucket time.Time
+}
+
+// Object structure for the aggregated measurements of a sensor in an hour
+type HourlyBucket struct {
+	// Number of aggregated measurements
+	measurements int64
+	// Minimum, maximum and sum of the temperatures
+	min_temperature, max_temperature, sum_temperature float64
+	// Minimum, maximum and sum of the humidities
+	min_humidity, max_humidity, sum_humidity float64
+	// Number of computable heat indices with their maximum and sum
+	heat_indices                   int64
+	max_heat_index, sum_heat_index float64
+	// Index of the worst danger level in dangerLevels
+	danger int
+}
+
+/*
+Function to create an empty hourly aggregation
+@return Map of the hourly buckets
+*/
+func newHourlyAggregation() map[BucketKey]*HourlyBucket {
+	return make(map[BucketKey]*HourlyBucket)
+}
+
+/*
+Function to add a transformed measurement to the bucket of its sensor and hour
+@param buckets Hourly buckets of the run
+@param transformedMeasurement Transformed measurement to aggregate
+*/
+func aggregateMeasurement(buckets map[BucketKey]*HourlyBucket, transformedMeasurement TransformedMeasurement) {
+
+	// Find or create the bucket of the sensor and hour of creation
+	key := BucketKey{sensor_id: transformedMeasurement.sensor_id, bucket: transformedMeasurement.created_on.Truncate(time.Hour)}
+	bucket, found := buckets[key]
+	if !found {
+		bucket = &HourlyBucket{
+			min_temperature: math.Inf(1),
+			max_temperature: math.Inf(-1),
+			min_humidity:    math.Inf(1),
+			max_humidity:    math.Inf(-1),
+			max_heat_index:  math.Inf(-1),
+		}
+		buckets[key] = bucket
+	}
+
+	// Accumulate count, minimums, maximums and sums of the readings
+	temperature := float64(transformedMeasurement.temperature)
+	humidity := float64(transformedMeasurement.humidity)
+	bucket.measurements++
+	bucket.min_temperature = math.Min(bucket.min_temperature, temperature)
+	bucket.max_temperature = math.Max(bucket.max_temperature, temperature)
+	bucket.sum_temperature += temperature
+	bucket.min_humidity = math.Min(bucket.min_humidity, humidity)
+	bucket.max_humidity = math.Max(bucket.max_humidity, humidity)
+	bucket.sum_humidity += humidity
+
+	// Accumulate the heat index, if it's computable
+	if heatIndex := float64(transformedMeasurement.heat_index); !math.IsNaN(heatIndex) && !math.IsInf(heatIndex, 0) {
+		bucket.heat_indices++
+		bucket.max_heat_index = math.Max(bucket.max_heat_index, heatIndex)
+		bucket.sum_heat_index += heatIndex
+	}
+
+	// Keep the worst danger level of the bucket
+	for i, level := range dangerLevels {
+		if level == transformedMeasurement.danger && i > bucket.danger {
+			bucket.danger = i
+		}
+	}
+}
+
+/*
+Function to write the hourly buckets into the aggregated view within a single transaction
+@param buckets Hourly buckets of the run
+@param db *sql.DB Database connection to Postgres database
+*/
+func writeHourlyAggregation(buckets map[BucketKey]*HourlyBucket, db *sql.DB) {
+
+	// Begin transaction and check on error with handler
+	tx, err := db.Begin()
+	checkError(err)
+
+	// Roll back, if the transaction wasn't committed when the function returns
+	defer tx.Rollback()
+
+	// Prepare insert statement for all buckets
+	stmt, err := tx.Prepare("INSERT INTO " + quoteTableName(aggregateTable) + " (sensor_id, bucket, measurements, min_temperature, max_temperature, avg_temperature, min_humidity, max_humidity, avg_humidity, danger, max_heat_index, avg_heat_index) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)")
+	checkError(err)
+
+	// Insert a row per bucket with the averages of the readings and of the computable heat indices (NULL without any)
+	for key, bucket := range buckets {
+		count := float64(bucket.measurements)
+		_, err = stmt.Exec(key.sensor_id, key.bucket, bucket.measurements,
+			nullableFloat(reading(bucket.min_temperature)), nullableFloat(reading(bucket.max_temperature)), nullableFloat64(bucket.sum_temperature/count),
+			nullableFloat(reading(bucket.min_humidity)), nullableFloat(reading(bucket.max_humidity)), nullableFloat64(bucket.sum_humidity/count),
+			dangerLevels[bucket.danger],
+			nullableFloat(reading(bucket.max_heat_index)), nullableFloat64(bucket.sum_heat_index/float64(bucket.heat_indices)))
+		checkError(err)
+	}
+
+	// Close statement and commit transaction
+	checkError(stmt.Close())
+	checkError(tx.Commit())
+
+	fmt.Printf("Aggregated into %d hourly buckets in %s\n", len(buckets), aggregateTable)
+}
+
+/*
+Function to convert an average into a value for the database, mapping NaN and infinite values to NULL
+@param value Value to convert
+@return Value or nil for NaN and infinite values
+*/
+func nullableFloat64(value float64) interface{} {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+	return value
+}
+
+/*
+Function to delete all buckets of the aggregated view before a rebuild
+@param db *sql.DB Database connection to Postgres database
+*/
+func cleanAggregatedView(db *sql.DB) {
+
+	// Refuse to clean a protected environment
+	requireUnprotected("clean the aggregated view")
+	_, err := db.Exec("DELETE FROM " + quoteTableName(aggregateTable))
+	// Check on error with handler
+	checkError(err)
+}
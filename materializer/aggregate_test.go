@@ -0,0 +1,106 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the aggregation into hourly buckets
+*/
+
+// Importing packages
+import (
+	// Package for math functions
+	"math"
+	// Package for automated tests
+	"testing"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+/*
+Test the minimums, maximums, averages, counts and the worst danger level of the buckets, with readings on both sides of an
+hour boundary
+@param t Test state
+*/
+func TestAggregateMeasurementHourlyBuckets(t *testing.T) {
+	hour := time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC)
+	measurement := func(sensor int64, createdOn time.Time, temperature reading, humidity reading, danger string) TransformedMeasurement {
+		return TransformedMeasurement{Measurement: Measurement{sensor_id: sensor, created_on: createdOn, temperature: temperature, humidity: humidity}, danger: danger}
+	}
+
+	buckets := newHourlyAggregation()
+	for _, transformed := range []TransformedMeasurement{
+		measurement(7, hour, 4, 30, Low),
+		measurement(7, hour.Add(30*time.Minute), 8, 50, High),
+		measurement(7, hour.Add(time.Hour-time.Millisecond), 6, 40, Medium),
+		// The first reading of the next hour starts a new bucket
+		measurement(7, hour.Add(time.Hour), 12, 70, Critical),
+		// Readings of another sensor in the same hour have their own bucket
+		measurement(8, hour.Add(10*time.Minute), 1, 10, No),
+	} {
+		aggregateMeasurement(buckets, transformed)
+	}
+
+	if len(buckets) != 3 {
+		t.Fatalf("%d buckets, want 3", len(buckets))
+	}
+	cases := []struct {
+		name         string
+		key          BucketKey
+		measurements int64
+		// Minimum, maximum and average of the temperatures and the humidities
+		temperature [3]float64
+		humidity    [3]float64
+		danger      string
+	}{
+		{"sensor 7 at 10:00", BucketKey{7, hour}, 3, [3]float64{4, 8, 6}, [3]float64{30, 50, 40}, High},
+		{"sensor 7 at 11:00", BucketKey{7, hour.Add(time.Hour)}, 1, [3]float64{12, 12, 12}, [3]float64{70, 70, 70}, Critical},
+		{"sensor 8 at 10:00", BucketKey{8, hour}, 1, [3]float64{1, 1, 1}, [3]float64{10, 10, 10}, No},
+	}
+	for _, testCase := range cases {
+		bucket, found := buckets[testCase.key]
+		if !found {
+			t.Errorf("%s: bucket missing", testCase.name)
+			continue
+		}
+		count := float64(bucket.measurements)
+		temperature := [3]float64{bucket.min_temperature, bucket.max_temperature, bucket.sum_temperature / count}
+		humidity := [3]float64{bucket.min_humidity, bucket.max_humidity, bucket.sum_humidity / count}
+		if bucket.measurements != testCase.measurements || temperature != testCase.temperature || humidity != testCase.humidity {
+			t.Errorf("%s: %d measurements, temperature %v, humidity %v, want %d, %v, %v", testCase.name,
+				bucket.measurements, temperature, humidity, testCase.measurements, testCase.temperature, testCase.humidity)
+		}
+		if got := dangerLevels[bucket.danger]; got != testCase.danger {
+			t.Errorf("%s: danger %s, want %s", testCase.name, got, testCase.danger)
+		}
+	}
+}
+
+/*
+Test the maximum and average heat index of the buckets, which leave out not computable heat indices
+@param t Test state
+*/
+func TestAggregateMeasurementHeatIndex(t *testing.T) {
+	hour := time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC)
+	measurement := func(sensor int64, heatIndex reading) TransformedMeasurement {
+		return TransformedMeasurement{Measurement: Measurement{sensor_id: sensor, created_on: hour}, heat_index: heatIndex, danger: No}
+	}
+
+	buckets := newHourlyAggregation()
+	for _, transformed := range []TransformedMeasurement{
+		measurement(7, 20),
+		measurement(7, reading(math.NaN())),
+		measurement(7, 26),
+		// A bucket without a computable heat index
+		measurement(8, reading(math.NaN())),
+	} {
+		aggregateMeasurement(buckets, transformed)
+	}
+
+	bucket := buckets[BucketKey{7, hour}]
+	if bucket.heat_indices != 2 || bucket.max_heat_index != 26 || bucket.sum_heat_index/float64(bucket.heat_indices) != 23 {
+		t.Errorf("sensor 7: %d heat indices, maximum %v, sum %v, want 2, 26 and an average of 23", bucket.heat_indices, bucket.max_heat_index, bucket.sum_heat_index)
+	}
+	bucket = buckets[BucketKey{8, hour}]
+	if average := bucket.sum_heat_index / float64(bucket.heat_indices); nullableFloat(reading(bucket.max_heat_index)) != nil || nullableFloat64(average) != nil {
+		t.Errorf("sensor 8: maximum %v and average %v, want both NULL", bucket.max_heat_index, average)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the append-only mode, which keeps the materialized view and only inserts new measurements
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package for string manipulation
+	"strings"
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Test that the insert statement ignores conflicting rows only in append-only mode
+@param t Test state
+*/
+func TestInsertStatementAppendOnly(t *testing.T) {
+	saved := appendOnly
+	defer func() { appendOnly = saved }()
+
+	for _, enabled := range []bool{false, true} {
+		appendOnly = enabled
+		statement := insertStatement("materialized_view")
+		if !strings.HasPrefix(statement, "INSERT INTO materialized_view (id, created_on, danger,") {
+			t.Errorf("append-only %t: statement %s doesn't insert into the materialized view", enabled, statement)
+		}
+		if got := strings.HasSuffix(statement, "VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) ON CONFLICT DO NOTHING"); got != enabled {
+			t.Errorf("append-only %t: statement %s, want ON CONFLICT DO NOTHING only in append-only mode", enabled, statement)
+		}
+	}
+}
+
+/*
+Test that a re-run in append-only mode keeps the existing rows and inserts only the new measurements
+@param t Test state
+*/
+func TestAppendOnlyRerunKeepsExistingRows(t *testing.T) {
+	db := openTestDatabase(t)
+	useDatabaseRun(t)
+	createTestEventStore(t, db, pushdownFixture)
+	captureStdout(t, func() { materialize(context.Background(), db, "test", nil) })
+
+	// Mark a materialized row to see whether it's rewritten, and add a new measurement to the event store
+	for _, statement := range []string{
+		"UPDATE materialized_view SET danger = 'kept' WHERE id = 1",
+		"INSERT INTO event_store VALUES (10, '2024-01-05 10:00:09', 'kafka', 50, '2024-01-05 10:00:09.5', 7, 6)",
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	appendOnly = true
+	captureStdout(t, func() { materialize(context.Background(), db, "test", nil) })
+
+	var rows, kept, added int
+	err := db.QueryRow(`SELECT COUNT(*), COUNT(*) FILTER (WHERE id = 1 AND danger = 'kept'), COUNT(*) FILTER (WHERE id = 10)
+		FROM materialized_view`).Scan(&rows, &kept, &added)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 10 || kept != 1 || added != 1 {
+		t.Errorf("%d rows, %d kept and %d added, want 10 rows with the existing row kept and the new one added", rows, kept, added)
+	}
+}
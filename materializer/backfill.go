@@ -0,0 +1,65 @@
+package main
+
+/*
+@author 1Zero64
+Detection of late-arriving measurements, whose created_on is much older than their processed_on, as sign of replays or backfills
+*/
+
+// Importing packages
+import (
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Object structure for the backfilled measurements of a run
+type Backfill struct {
+	// Number of measurements processed later than BACKFILL_THRESHOLD after their creation
+	measurements int
+	// Oldest created_on of the backfilled measurements
+	oldest time.Time
+	// Newest created_on of the backfilled measurements
+	newest time.Time
+}
+
+/*
+Function to count a measurement as backfilled, if its gap between created_on and processed_on exceeds BACKFILL_THRESHOLD
+@param measurement Measurement to check
+*/
+func (backfill *Backfill) add(measurement Measurement) {
+
+	// Ignore all measurements, if the detector is disabled, and the ones processed in time
+	if backfillThreshold <= 0 || measurement.processed_on.Sub(measurement.created_on) <= backfillThreshold {
+		return
+	}
+
+	// Count the measurement and widen the creation range
+	backfill.measurements++
+	if backfill.oldest.IsZero() || measurement.created_on.Before(backfill.oldest) {
+		backfill.oldest = measurement.created_on
+	}
+	if measurement.created_on.After(backfill.newest) {
+		backfill.newest = measurement.created_on
+	}
+}
+
+/*
+Function to print the backfilled measurements and the spread of their creation times
+@param measurements Number of transformed measurements of the run
+*/
+func (backfill *Backfill) print(measurements int) {
+	if backfillThreshold <= 0 {
+		return
+	}
+
+	// Calculate the rate among all measurements
+	var rate float64
+	if measurements > 0 {
+		rate = float64(backfill.measurements) / float64(measurements)
+	}
+	localePrintf("Backfilled measurements (processed > %s after creation): %d (%.2f%%)\n", backfillThreshold, backfill.measurements, rate*100)
+
+	// Print the range of creation times, which tells a replay of a single period from scattered late events
+	if backfill.measurements > 0 {
+		localePrintf("Backfilled created_on from %s to %s (spread %s)\n", backfill.oldest.Format(time.RFC3339), backfill.newest.Format(time.RFC3339), backfill.newest.Sub(backfill.oldest))
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+/*
+@author 1Zero64
+Concurrent writes in the background, bounded by WRITE_CONCURRENCY
+*/
+
+// Importing packages
+import (
+	// Package for synchronization of goroutines
+	"sync"
+)
+
+// Object structure for the writes running in the background
+type BackgroundWrites struct {
+	// Semaphore with a slot per concurrent write
+	semaphore chan struct{}
+	// Wait group of the in-flight writes
+	running sync.WaitGroup
+	// First failure of a write
+	failure GoroutineFailure
+}
+
+/*
+Function to create the background writes
+@param concurrency Maximum number of writes in flight at the same time
+@return Pointer to the background writes
+*/
+func newBackgroundWrites(concurrency int) *BackgroundWrites {
+	return &BackgroundWrites{semaphore: make(chan struct{}, concurrency)}
+}
+
+/*
+Function to start a write in the background, blocking while all slots of the semaphore are in use
+@param write Function writing a measurement
+*/
+func (writes *BackgroundWrites) start(write func()) {
+
+	// Acquire a slot of the semaphore
+	writes.semaphore <- struct{}{}
+	writes.running.Add(1)
+	go func() {
+		// Release the slot and capture a failure, when the write is finished
+		defer func() {
+			<-writes.semaphore
+			writes.running.Done()
+		}()
+		defer writes.failure.capture()
+		write()
+	}()
+}
+
+/*
+Function to check whether a write failed, so no further writes are started
+@return True after the first failed write
+*/
+func (writes *BackgroundWrites) failed() bool {
+	return writes.failure.failed.Load()
+}
+
+/*
+Function to wait for the in-flight writes
+*/
+func (writes *BackgroundWrites) wait() {
+	writes.running.Wait()
+}
@@ -0,0 +1,47 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the concurrent writes in the background
+*/
+
+// Importing packages
+import (
+	// Package for atomic operations
+	"sync/atomic"
+	// Package for automated tests
+	"testing"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+/*
+Test that the semaphore of the background writes bounds the writes in flight
+@param t Test state
+*/
+func TestBackgroundWritesBoundInFlight(t *testing.T) {
+	for _, concurrency := range []int{1, 3, 8} {
+		var inFlight, maximum atomic.Int32
+		writes := newBackgroundWrites(concurrency)
+		for i := 0; i < 50; i++ {
+			writes.start(func() {
+				current := inFlight.Add(1)
+				for {
+					seen := maximum.Load()
+					if current <= seen || maximum.CompareAndSwap(seen, current) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				inFlight.Add(-1)
+			})
+		}
+		writes.wait()
+		if maximum.Load() > int32(concurrency) {
+			t.Errorf("WRITE_CONCURRENCY %d: %d writes in flight", concurrency, maximum.Load())
+		}
+		if concurrency > 1 && maximum.Load() < 2 {
+			t.Errorf("WRITE_CONCURRENCY %d: writes didn't run concurrently", concurrency)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+/*
+@author 1Zero64
+Non-interactive batch mode for a single materialize run, e.g. as Kubernetes Job, with JSON log lines and distinct exit codes
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package with the interfaces of the database drivers
+	"database/sql/driver"
+	// Package for encoding JSON
+	"encoding/json"
+	// Package for inspecting errors
+	"errors"
+	// Package for formatted printing
+	"fmt"
+	// Package for network errors
+	"net"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for string manipulation
+	"strings"
+	// Package for system calls like the termination signal
+	"syscall"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Enumerations for the exit codes of the batch mode
+const (
+	BatchExitSuccess    = 0
+	BatchExitConnection = 2
+	BatchExitData       = 3
+	BatchExitPartial    = 4
+	BatchExitSkipped    = 5
+	BatchExitLocked     = 6
+)
+
+// Object structure for the final JSON line of a batch run
+type BatchResult struct {
+	// Event of the log line, always result
+	Event string `json:"event"`
+	// Time point on when the run finished
+	Time time.Time `json:"time"`
+	// Outcome of the run: success, partial, connection_error, data_error, skip_threshold_exceeded or locked
+	Outcome string `json:"outcome"`
+	// Exit code of the process
+	ExitCode int `json:"exit_code"`
+	// Error of a failed run (omitted on success)
+	Error string `json:"error,omitempty"`
+	// Id of the last processed measurement of a partial run, to catch up with -since-last-run (omitted otherwise)
+	LastId int64 `json:"last_id,omitempty"`
+	// Report of the run (omitted, if the run didn't start or was skipped)
+	Report *RunReport `json:"report,omitempty"`
+}
+
+/*
+Function to print a JSON log line of the batch mode
+@param event Name of the event
+@param message Human-readable message of the event
+*/
+func batchLog(event string, message string) {
+	line, err := json.Marshal(map[string]interface{}{"event": event, "time": time.Now(), "message": message})
+	checkError(err)
+	fmt.Println(string(line))
+}
+
+/*
+Function to check whether an error is caused by the database connection instead of the data
+@param err Error of the run
+@return True for network errors, broken connections, rejected credentials and the SQLSTATE classes of connection failures and shutdowns
+*/
+func isConnectionError(err error) bool {
+
+	// Network and broken connection errors of the drivers
+	var networkError net.Error
+	if errors.As(err, &networkError) || errors.Is(err, driver.ErrBadConn) || isAuthenticationError(err) {
+		return true
+	}
+
+	// Connection exceptions (08) and operator interventions like a server shutdown (57P)
+	var serverError interface{ SQLState() string }
+	if errors.As(err, &serverError) {
+		return strings.HasPrefix(serverError.SQLState(), "08") || strings.HasPrefix(serverError.SQLState(), "57P")
+	}
+
+	// Errors of waiting for the database
+	return strings.Contains(err.Error(), "database not available")
+}
+
+/*
+Function to run a single non-interactive materialize run selected by the flags and settings. Progress is printed as periodic
+log lines, the result as final JSON line. SIGTERM or an interrupt stops the run before the next measurement, rows written
+so far stay committed as checkpoint to catch up from with -since-last-run
+@param db *sql.DB Database connection to Postgres database
+@return Exit code: 0 for success, 2 for connection, 3 for data errors, 4 for a partial run, 5 above SKIP_THRESHOLD and 6 for a lock held by another instance
+*/
+func runBatch(db *sql.DB) (exitCode int) {
+
+	// Stop the run on SIGTERM or an interrupt, so it finishes within the termination grace period
+	ctx, stop := notifyInterruptContext(syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	// Print the result as final JSON line, also for a failed run
+	result := BatchResult{Event: "result", Outcome: "success"}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err, ok := recovered.(error)
+			if !ok {
+				err = fmt.Errorf("%v", recovered)
+			}
+			result.Error = err.Error()
+			result.Outcome, result.ExitCode = "data_error", BatchExitData
+			var lockError *LockHeldError
+			if errors.As(err, &lockError) {
+				result.Outcome, result.ExitCode = "locked", BatchExitLocked
+			} else if isConnectionError(err) {
+				result.Outcome, result.ExitCode = "connection_error", BatchExitConnection
+			}
+		}
+		result.Time = time.Now()
+		line, err := json.Marshal(result)
+		checkError(err)
+		fmt.Println(string(line))
+		exitCode = result.ExitCode
+	}()
+
+	// Connect and bootstrap the schema
+	batchLog("start", "waiting for the database")
+	waitForDatabase(db)
+	batchLog("connected", connectionSummary())
+	if sourceMode == ModeDb || outputMode == ModeDb {
+		createSchema(db)
+	}
+
+	// Run the materialize process
+	batchLog("materialize", "starting materialize run")
+	summary := materializeView(ctx, db)
+	if summary == nil {
+		batchLog("skipped", "event store unchanged since the last rebuild")
+		return
+	}
+	report := newRunReport(summary)
+	result.Report = &report
+
+	// Report a run stopped by a signal or MAX_RUNTIME as partial
+	if summary.stopped != nil {
+		result.Outcome, result.ExitCode = "partial", BatchExitPartial
+		result.Error = summary.stopped.Error()
+		result.LastId = summary.lastId
+	} else if summary.skipThresholdExceeded() {
+		// Fail a complete run, which silently dropped too many measurements
+		result.Outcome, result.ExitCode = "skip_threshold_exceeded", BatchExitSkipped
+		skipped, input := summary.skipTotals()
+		result.Error = fmt.Sprintf("%d of %d measurements skipped, exceeding SKIP_THRESHOLD %s", skipped, input, skipThresholdValue)
+	}
+	return
+}
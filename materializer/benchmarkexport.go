@@ -0,0 +1,199 @@
+package main
+
+/*
+@author 1Zero64
+Export of microbenchmark results as JSON file
+*/
+
+// Importing packages
+import (
+	// Package for encoding JSON
+	"encoding/json"
+	// Package for inspecting errors
+	"errors"
+	// Package for formatted printing
+	"fmt"
+	// Package for file system interfaces
+	"io/fs"
+	// Package for math functions
+	"math"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Minimum number of iterations before a microbenchmark can be considered converged
+const minConvergenceIterations = 3
+
+// Object structure for the exported results of a microbenchmark
+type BenchmarkExport struct {
+	// Unique identifier of the benchmark run
+	RunId string `json:"run_id"`
+	// Time point on when the measured iterations started
+	Timestamp time.Time `json:"timestamp"`
+	// Number of measurements processed in each iteration
+	Measurements int `json:"measurements"`
+	// Number of requested (or maximum) iterations
+	RequestedIterations int `json:"requested_iterations"`
+	// Number of actually executed iterations
+	ExecutedIterations int `json:"executed_iterations"`
+	// Number of iterations loaded from the results file of an interrupted benchmark
+	ResumedIterations int `json:"resumed_iterations,omitempty"`
+	// Number of unrecorded warmup iterations
+	WarmupIterations int `json:"warmup_iterations"`
+	// Reason, why the benchmark stopped
+	StopReason string `json:"stop_reason"`
+	// Flag whether the benchmark was interrupted and the statistics cover only the completed iterations
+	Partial bool `json:"partial"`
+	// Relative standard error threshold of the convergence mode (0 if disabled)
+	ConvergenceThreshold float64 `json:"convergence_threshold"`
+	// Relative standard error of the mean duration (omitted for less than two iterations)
+	RelativeStandardError *float64 `json:"relative_standard_error,omitempty"`
+	// Fastest iteration in seconds
+	Min float64 `json:"min"`
+	// Slowest iteration in seconds
+	Max float64 `json:"max"`
+	// Average duration in seconds
+	Mean float64 `json:"mean"`
+	// Median duration in seconds
+	Median float64 `json:"median"`
+	// Standard deviation in seconds
+	StandardDeviation float64 `json:"stddev"`
+	// Variance in seconds
+	Variance float64 `json:"variance"`
+	// Median absolute deviation scaled by 1.4826 in seconds
+	MedianAbsoluteDeviation float64 `json:"mad"`
+	// Mean without the fastest and slowest 10% in seconds (omitted for less than five iterations)
+	TrimmedMean *float64 `json:"trimmed_mean,omitempty"`
+	// Difference between the third and the first quartile in seconds
+	InterquartileRange float64 `json:"iqr"`
+	// Durations of all iterations in seconds in execution order
+	Durations []float64 `json:"durations"`
+	// Flag whether the clean phase is included in the durations, which changes comparability with older results
+	CleanIncluded bool `json:"clean_included"`
+	// Flag whether the measurements were read once before the iterations, so the read phase isn't part of the durations
+	CachedRead bool `json:"cached_read"`
+	// Unit of the latencies written to the view (ms, us or s)
+	LatencyUnit string `json:"latency_unit"`
+	// Transport of the database connection (unix socket or tcp), which materially affects the latencies
+	Connection string `json:"connection"`
+	// Version and build information of the binary, so results of different builds can be told apart
+	Build BuildInfo `json:"build"`
+	// Effective synchronous_commit setting of the benchmark connections
+	SynchronousCommit string `json:"synchronous_commit,omitempty"`
+	// Flag whether unsafe benchmark settings (synchronous_commit=off) were used, so the results exclude the fsync latency
+	UnsafeSettings bool `json:"unsafe_settings"`
+	// Garbage collection target percentage of the run
+	GCPercent int `json:"gc_percent"`
+	// Garbage collection statistics of all iterations (omitted, if not collected)
+	GCStatistics []GCStatistics `json:"gc_statistics,omitempty"`
+	// Differences of the pg_stat counters of all iterations (omitted, if not collected)
+	PgStatistics []PgStats `json:"pg_statistics,omitempty"`
+	// Throughput samples of all iterations (omitted without throughput logging)
+	ThroughputSamples [][]ThroughputSample `json:"throughput_samples,omitempty"`
+	// Durations of the clean phase of all iterations in seconds
+	CleanDurations []float64 `json:"clean_durations"`
+	// Durations of the read phase of all iterations in seconds
+	ReadDurations []float64 `json:"read_durations"`
+	// Durations of the transform and write phase of all iterations in seconds
+	WriteDurations []float64 `json:"write_durations"`
+	// Plans of the read and insert captured after the iterations (omitted without BENCHMARK_EXPLAIN)
+	QueryPlans []QueryPlan `json:"query_plans,omitempty"`
+}
+
+/*
+Function to calculate the relative standard error of the mean of durations
+@param durations Iteration durations, NaN and infinite ones are ignored
+@return Standard error of the mean divided by the mean (infinity for less than two finite values)
+*/
+func relativeStandardError(durations []float64) float64 {
+
+	// The sample standard deviation needs at least two finite values
+	durations, _ = finiteValues(durations)
+	n := float64(len(durations))
+	if len(durations) < 2 {
+		return math.Inf(1)
+	}
+
+	// Calculate mean and sample standard deviation
+	mean, _ := meanAndStandardDeviation(durations)
+	var squares float64
+	for _, duration := range durations {
+		squares += math.Pow(duration-mean, 2)
+	}
+	sampleStandardDeviation := math.Sqrt(squares / (n - 1))
+
+	// Divide standard error of the mean by the mean
+	return sampleStandardDeviation / math.Sqrt(n) / mean
+}
+
+/*
+Function to write the microbenchmark results into a JSON file
+@param path Path of the JSON file
+@param export Results to write
+*/
+func writeBenchmarkExport(path string, export BenchmarkExport) {
+
+	// Create export file and check on error with handler
+	file, err := os.Create(path)
+	checkError(err)
+
+	// Close file later, when surrounding function returns
+	defer file.Close()
+
+	// Write indented JSON document
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	checkError(encoder.Encode(export))
+}
+
+/*
+Function to read the results of a previous microbenchmark from a JSON file to resume it
+@param path Path of the JSON file
+@return Pointer to the previous results or nil, if the file doesn't exist yet
+*/
+func readBenchmarkExport(path string) *BenchmarkExport {
+
+	// Open results file and start without previous results, if it doesn't exist
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	checkError(err)
+
+	// Close file later, when surrounding function returns
+	defer file.Close()
+
+	// Decode JSON document
+	var export BenchmarkExport
+	if err := json.NewDecoder(file).Decode(&export); err != nil {
+		checkError(fmt.Errorf("invalid benchmark results file %s: %w", path, err))
+	}
+	return &export
+}
+
+/*
+Function to get a pointer to a finite float for JSON fields, which can't hold NaN or infinite values
+@param value Float value
+@return Pointer to the value or nil for NaN and infinite values
+*/
+func finiteOrNil(value float64) *float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+	return &value
+}
+
+/*
+Function to convert the trimmed mean into an optional JSON value
+@param value Trimmed mean
+@param valid Flag whether there were enough iterations to trim
+@return Pointer to the value or nil, if not valid
+*/
+func trimmedOrNil(value float64, valid bool) *float64 {
+	if !valid {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,498 @@
+package main
+
+/*
+@author 1Zero64
+Change data capture source, consuming the inserts into the event store from a logical replication slot with the wal2json plugin
+*/
+
+// Importing packages
+import (
+	// Package for in-memory byte buffers
+	"bytes"
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for encoding JSON
+	"encoding/json"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for receiving operating system signals
+	"os/signal"
+	// Package for converting strings to numbers
+	"strconv"
+	// Package for string manipulation
+	"strings"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Go time layouts of the timestamps in the wal2json output, with and without time zone
+var cdcTimeLayouts = []string{"2006-01-02 15:04:05.999999999-07", "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05.999999999"}
+
+// Object structure for an insert into the event store decoded from the wal2json output (format version 2)
+type CdcChange struct {
+	// Action of the change, I for an insert
+	Action string `json:"action"`
+	// Commit timestamp of the transaction of the change
+	Timestamp string `json:"timestamp"`
+	// Inserted columns with name and value
+	Columns []struct {
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value"`
+	} `json:"columns"`
+}
+
+// Object structure for a row of the logical decoding output
+type CdcRow struct {
+	// Position of the row in the WAL, the end of the commit record for a commit
+	lsn string
+	// Change in the wal2json output
+	data string
+}
+
+// Object structure for a change, which can't be decoded, and is written into the dead letter table instead of the view
+type CdcRejection struct {
+	// Columns of the change decoded so far
+	measurement Measurement
+	// Reason for rejecting the change
+	reason string
+}
+
+// Object structure for the decoded changes of a peeked batch
+type CdcBatch struct {
+	// Measurements of the inserts of the transactions committed after the applied position
+	measurements []Measurement
+	// Changes of these transactions, which can't be decoded
+	rejections []CdcRejection
+	// End position of the last commit of the batch, to record and confirm (empty for a drained slot)
+	lsn string
+	// Commit time of the oldest transaction not yet applied (zero, if caught up)
+	oldestPending time.Time
+}
+
+// Object structure for the replication lag of the slot
+type CdcLag struct {
+	// WAL bytes between the current position of the primary and the confirmed position of the slot
+	bytes int64
+	// Time since the commit of the oldest change not yet applied (0, if caught up)
+	seconds float64
+}
+
+/*
+Function to parse a WAL position in the text form of pg_lsn (e.g. 16/B374D848) into a comparable number
+@param lsn WAL position as text
+@return WAL position as number
+*/
+func parseLsn(lsn string) uint64 {
+
+	// Combine the upper and lower 32 bits given in hexadecimal
+	parts := strings.SplitN(lsn, "/", 2)
+	if len(parts) != 2 {
+		checkError(fmt.Errorf("invalid LSN %q", lsn))
+	}
+	upper, err := strconv.ParseUint(parts[0], 16, 32)
+	checkError(err)
+	lower, err := strconv.ParseUint(parts[1], 16, 32)
+	checkError(err)
+	return upper<<32 | lower
+}
+
+/*
+Function to parse a timestamp of the wal2json output
+@param value Timestamp in the text form of Postgres
+@return Parsed time point
+*/
+func parseCdcTime(value string) (time.Time, error) {
+	for _, layout := range cdcTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+}
+
+/*
+Function to decode an insert into the event store into a measurement
+@param change Decoded change of the wal2json output
+@return Measurement with the inserted columns
+*/
+func (change CdcChange) measurement() (Measurement, error) {
+
+	// Decode the columns by name, NULL readings stay nil
+	var measurement Measurement
+	var temperature, humidity *reading
+	var createdOn, processedOn string
+	for _, column := range change.Columns {
+		var err error
+		switch column.Name {
+		case "id":
+			err = json.Unmarshal(column.Value, &measurement.id)
+		case "sensor_id":
+			err = json.Unmarshal(column.Value, &measurement.sensor_id)
+		case "temperature":
+			err = json.Unmarshal(column.Value, &temperature)
+		case "humidity":
+			err = json.Unmarshal(column.Value, &humidity)
+		case "event_stream":
+			err = json.Unmarshal(column.Value, &measurement.event_stream)
+		case "created_on":
+			err = json.Unmarshal(column.Value, &createdOn)
+		case "processed_on":
+			err = json.Unmarshal(column.Value, &processedOn)
+		}
+		if err != nil {
+			return measurement, fmt.Errorf("invalid column %s: %w", column.Name, err)
+		}
+	}
+
+	// Parse the timestamps
+	var err error
+	if measurement.created_on, err = parseCdcTime(createdOn); err != nil {
+		return measurement, err
+	}
+	if measurement.processed_on, err = parseCdcTime(processedOn); err != nil {
+		return measurement, err
+	}
+	measurement.setReadings(temperature, humidity)
+	return measurement, nil
+}
+
+/*
+Function to decode the rows of a peeked batch. Logical decoding returns whole transactions in commit order, so the changes are
+taken per transaction, if its commit lies after the applied position. The changes of a transaction can have smaller positions
+than the commit of an earlier one, so only the commit position is compared. The batch ends at the end of its last commit,
+which is recorded and confirmed to the slot, so the transaction isn't peeked again
+@param rows Rows of the wal2json output with begin (B), insert (I) and commit (C) actions
+@param applied Position of the last commit applied to the materialized view
+@return Decoded changes of the transactions committed after the applied position
+*/
+func decodeCdcBatch(rows []CdcRow, applied uint64) CdcBatch {
+	var batch CdcBatch
+	var measurements []Measurement
+	var rejections []CdcRejection
+	for _, row := range rows {
+		// Reject a change, which isn't valid JSON, instead of failing on it again after every restart
+		var change CdcChange
+		if err := json.Unmarshal([]byte(row.data), &change); err != nil {
+			rejections = append(rejections, CdcRejection{reason: fmt.Sprintf("undecodable change at LSN %s: %v", row.lsn, err)})
+			continue
+		}
+		switch change.Action {
+		case "B":
+			// Start collecting the changes of a new transaction
+			measurements, rejections = nil, nil
+		case "I":
+			// Decode the insert, rejecting invalid columns
+			measurement, err := change.measurement()
+			if err != nil {
+				rejections = append(rejections, CdcRejection{measurement: measurement, reason: fmt.Sprintf("undecodable change at LSN %s: %v", row.lsn, err)})
+				continue
+			}
+			if streamFilter != "" && measurement.event_stream != streamFilter {
+				continue
+			}
+			measurements = append(measurements, measurement)
+		case "C":
+			// Take the changes of a transaction committed after the applied position, earlier ones were committed before a restart
+			batch.lsn = row.lsn
+			if parseLsn(row.lsn) > applied {
+				batch.measurements = append(batch.measurements, measurements...)
+				batch.rejections = append(batch.rejections, rejections...)
+				if batch.oldestPending.IsZero() {
+					batch.oldestPending, _ = parseCdcTime(change.Timestamp)
+				}
+			}
+			measurements, rejections = nil, nil
+		}
+	}
+	return batch
+}
+
+/*
+Function to create the logical replication slot of the change data capture or attach to the existing one, and the table of the applied positions
+@param db *sql.DB Database connection to Postgres database
+@return Position of the last change applied to the materialized view
+*/
+func prepareCdcSlot(db *sql.DB) uint64 {
+
+	// Create the slot, if missing. It only captures changes from now on, older events need a full materialize run
+	var plugin string
+	err := db.QueryRow("SELECT plugin FROM pg_replication_slots WHERE slot_name = $1", cdcSlot).Scan(&plugin)
+	if err == sql.ErrNoRows {
+		_, err = db.Exec("SELECT pg_create_logical_replication_slot($1, 'wal2json')", cdcSlot)
+		checkError(err)
+		fmt.Printf("Created logical replication slot %s, events inserted before aren't captured, materialize them with a full run\n", cdcSlot)
+	} else {
+		checkError(err)
+		if plugin != "wal2json" {
+			checkError(fmt.Errorf("logical replication slot %s uses the plugin %s, expected wal2json", cdcSlot, plugin))
+		}
+		fmt.Printf("Attached to logical replication slot %s\n", cdcSlot)
+	}
+
+	// Create the table of the positions applied per slot, which is committed together with the view rows
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS materializer_cdc_offsets (
+		slot_name VARCHAR(255) PRIMARY KEY,
+		lsn PG_LSN NOT NULL,
+		updated_on TIMESTAMP DEFAULT NOW()
+	)`)
+	checkError(err)
+
+	// Create the dead letter table for changes, which can't be decoded
+	createDeadLetterTable(db)
+
+	// Read the applied position, starting from the beginning of the slot without one
+	applied := "0/0"
+	err = db.QueryRow("SELECT lsn::text FROM materializer_cdc_offsets WHERE slot_name = $1", cdcSlot).Scan(&applied)
+	if err != sql.ErrNoRows {
+		checkError(err)
+	}
+	return parseLsn(applied)
+}
+
+/*
+Function to measure the replication lag of the slot
+@param db *sql.DB Database connection to Postgres database
+@param oldestPending Commit time of the oldest change not yet applied (zero, if caught up)
+@return Replication lag in bytes and seconds
+*/
+func measureCdcLag(db *sql.DB, oldestPending time.Time) CdcLag {
+
+	// Compare the current WAL position of the primary with the confirmed one of the slot
+	var lag CdcLag
+	err := db.QueryRow("SELECT COALESCE(pg_wal_lsn_diff(pg_current_wal_lsn(), confirmed_flush_lsn), 0)::bigint FROM pg_replication_slots WHERE slot_name = $1", cdcSlot).Scan(&lag.bytes)
+	checkError(err)
+	if !oldestPending.IsZero() {
+		lag.seconds = sinceNow(oldestPending).Seconds()
+	}
+	return lag
+}
+
+/*
+Function to write the change data capture metrics into the .prom file
+@param path Path of the .prom file
+@param applied Number of changes applied since the start
+@param lag Replication lag of the slot (nil for a follower, which doesn't measure it)
+@param election Leadership state of the replica (nil without LEADER_ELECTION)
+*/
+func writeCdcPrometheusFile(path string, applied int, lag *CdcLag, election *LeaderElection) {
+
+	// Build metrics in exposition format
+	var buffer bytes.Buffer
+	fmt.Fprintln(&buffer, "# HELP materializer_cdc_applied_changes Number of event store inserts applied to the materialized view since the start.")
+	fmt.Fprintln(&buffer, "# TYPE materializer_cdc_applied_changes counter")
+	fmt.Fprintf(&buffer, "materializer_cdc_applied_changes{slot=%s} %d\n", prometheusLabel(cdcSlot), applied)
+	if lag != nil {
+		fmt.Fprintln(&buffer, "# HELP materializer_cdc_lag_bytes WAL bytes the replication slot is behind the primary.")
+		fmt.Fprintln(&buffer, "# TYPE materializer_cdc_lag_bytes gauge")
+		fmt.Fprintf(&buffer, "materializer_cdc_lag_bytes{slot=%s} %d\n", prometheusLabel(cdcSlot), lag.bytes)
+		fmt.Fprintln(&buffer, "# HELP materializer_cdc_lag_seconds Seconds since the commit of the oldest change not yet applied.")
+		fmt.Fprintln(&buffer, "# TYPE materializer_cdc_lag_seconds gauge")
+		fmt.Fprintf(&buffer, "materializer_cdc_lag_seconds{slot=%s} %f\n", prometheusLabel(cdcSlot), lag.seconds)
+	}
+
+	// Add the leadership of the replica, if elected
+	if election != nil {
+		election.writeMetrics(&buffer)
+	}
+
+	// Replace the .prom file with the metrics
+	replacePrometheusFile(path, &buffer)
+}
+
+/*
+Function to apply a batch of changes to the materialized view and record the position of the last one in the same transaction,
+so a restart neither loses nor repeats changes. The NULL, non-finite and unknown sensor policies are applied like in a materialize run,
+their dead letters are written in the same transaction. The slot is advanced
+afterwards to release the WAL. Both run on the session holding the lock of the view, so they fail, once the session is gone
+@param db *sql.DB Database connection to Postgres database
+@param lock Lock of the view with the session to write on
+@param batch Decoded changes of the batch
+@return Number of written measurements
+*/
+func applyCdcBatch(db *sql.DB, lock *MaterializeLock, batch CdcBatch) int {
+
+	// Create the monthly partitions before the transaction, if the view is partitioned
+	if partitionedView {
+		for _, measurement := range batch.measurements {
+			ensurePartition(measurement.created_on, db)
+		}
+	}
+
+	// Load valid sensor ids from the sensor registry, if validation is enabled and they are outdated
+	registry := currentSensorRegistry(db)
+
+	// Write the transformed measurements and the applied position atomically
+	written, unknownSensors := 0, 0
+	tx, err := lock.conn.BeginTx(context.Background(), nil)
+	checkError(err)
+
+	// Roll back on a failed write, rolling back after the commit does nothing
+	defer tx.Rollback()
+
+	// Write the dead letters in the same transaction, so a replayed batch doesn't duplicate them
+	for _, rejection := range batch.rejections {
+		writeDeadLetter(rejection.measurement, rejection.reason, tx)
+	}
+	for _, measurement := range batch.measurements {
+		// Apply the NULL and non-finite policies of the materialize run
+		if measurement.skippedByNullPolicy() {
+			continue
+		}
+		if measurement.deadLetteredAsNonFinite() {
+			writeDeadLetter(measurement, "non-finite reading", tx)
+			continue
+		}
+		// Apply the unknown sensor policy of the materialize run
+		unknownSensor := registry != nil && !registry[measurement.sensor_id]
+		if unknownSensor && unknownSensorPolicy != UnknownSensorFlag {
+			if unknownSensorPolicy == UnknownSensorDeadLetter {
+				writeDeadLetter(measurement, "unknown sensor", tx)
+			}
+			unknownSensors++
+			continue
+		}
+		transformedMeasurement := transformMeasurement(measurement)
+		transformedMeasurement.unknown_sensor = unknownSensor
+		_, err = tx.Exec(insertStatement("materialized_view"), insertArguments(transformedMeasurement)...)
+		if err != nil {
+			tx.Rollback()
+			handleRowError(measurement, err)
+		}
+		written++
+	}
+	_, err = tx.Exec(`INSERT INTO materializer_cdc_offsets (slot_name, lsn, updated_on) VALUES ($1, $2::pg_lsn, NOW())
+		ON CONFLICT (slot_name) DO UPDATE SET lsn = EXCLUDED.lsn, updated_on = EXCLUDED.updated_on`, cdcSlot, batch.lsn)
+	if err != nil {
+		tx.Rollback()
+		checkError(err)
+	}
+	checkError(tx.Commit())
+
+	// Confirm the position to the slot only after the commit, a crash in between is caught by the recorded position
+	_, err = lock.conn.ExecContext(context.Background(), "SELECT pg_replication_slot_advance($1, $2::pg_lsn)", cdcSlot, batch.lsn)
+	checkError(err)
+	if unknownSensors > 0 {
+		fmt.Printf("Rejected %d changes of unknown sensors (UNKNOWN_SENSOR_POLICY %s)\n", unknownSensors, unknownSensorPolicy)
+	}
+	return written
+}
+
+/*
+Function to consume the inserts into the event store from the logical replication slot until interrupted,
+transforming and writing them into the materialized view in batches. With LEADER_ELECTION only the replica holding the lock
+of the view consumes them, the others follow and take over, when its session ends
+@param db *sql.DB Database connection to Postgres database
+*/
+func consumeChanges(db *sql.DB) {
+
+	// Stop after the in-flight batch on an interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Number of changes applied since the start
+	total := 0
+
+	if leaderElection {
+		// Alternate between following and leading, until interrupted
+		election := &LeaderElection{}
+		for ctx.Err() == nil {
+			lock := election.follow(ctx, db, total)
+			if lock == nil {
+				break
+			}
+			total += consumeBatches(ctx, db, lock, election, total)
+			if election.leader {
+				lock.release()
+			}
+		}
+	} else {
+		// Lock the view against concurrent instances for the whole consumption
+		lock := acquireMaterializeLock(db, "materialized_view")
+		defer lock.release()
+		total = consumeBatches(ctx, db, lock, nil, 0)
+	}
+
+	// Print the number of applied changes
+	fmt.Printf("Stopped consuming changes, %d applied\n", total)
+}
+
+/*
+Function to consume batches of changes while holding the lock of the view, until interrupted or the leadership is lost
+@param ctx Context of the consumer, consuming stops after the in-flight batch when it's done
+@param db *sql.DB Database connection to Postgres database
+@param lock Lock of the view
+@param election Leadership state of the replica, verified before every write (nil without LEADER_ELECTION)
+@param previous Number of changes applied by this replica before, for the metrics
+@return Number of applied changes
+*/
+func consumeBatches(ctx context.Context, db *sql.DB, lock *MaterializeLock, election *LeaderElection, previous int) int {
+
+	// Attach to the slot and resume after the last applied change, also after a previous leader
+	applied := prepareCdcSlot(db)
+	fmt.Printf("Consuming changes of event_store from slot %s (interrupt to stop)...\n", cdcSlot)
+
+	// Number of changes applied while holding the lock
+	total := 0
+
+	for ctx.Err() == nil {
+		// Peek at the next transactions without consuming them, the slot is only advanced after the commit.
+		// Peeking stops only at the end of a transaction, so a transaction with more inserts than the batch size is returned whole
+		rows, err := db.Query(`SELECT lsn::text, data FROM pg_logical_slot_peek_changes($1, NULL, $2,
+			'format-version', '2', 'include-timestamp', '1', 'include-transaction', 'true', 'actions', 'insert', 'add-tables', '*.event_store')`,
+			cdcSlot, cdcBatchSize)
+		checkError(err)
+		var peeked []CdcRow
+		for rows.Next() {
+			var row CdcRow
+			checkError(rows.Scan(&row.lsn, &row.data))
+			peeked = append(peeked, row)
+		}
+		checkError(rows.Err())
+		rows.Close()
+
+		// Decode the transactions committed after the applied position
+		batch := decodeCdcBatch(peeked, applied)
+		lastLsn := batch.lsn
+
+		// Measure the lag before applying, so it shows how far behind the batch was
+		lag := measureCdcLag(db, batch.oldestPending)
+
+		// Verify the leadership before writing, a lost one stops the writes
+		if election != nil && lastLsn != "" && election.verify(lock) != nil {
+			return total
+		}
+
+		// Apply the batch and record its position, also for batches without matching inserts to advance the slot
+		if lastLsn != "" && parseLsn(lastLsn) > applied {
+			written := applyCdcBatch(db, lock, batch)
+			applied = parseLsn(lastLsn)
+			total += written
+			fmt.Printf("Applied %d changes up to LSN %s (lag %d bytes, %.3f seconds)\n", written, lastLsn, lag.bytes, lag.seconds)
+			if len(batch.rejections) > 0 {
+				fmt.Printf("Wrote %d undecodable changes into the dead letter table\n", len(batch.rejections))
+			}
+		} else if lastLsn != "" {
+			// Only changes applied before a restart, confirm them to the slot
+			_, err = lock.conn.ExecContext(context.Background(), "SELECT pg_replication_slot_advance($1, $2::pg_lsn)", cdcSlot, lastLsn)
+			checkError(err)
+		}
+
+		// Write the metrics of the consumer, if a file is configured
+		if promFile != "" {
+			writeCdcPrometheusFile(promFile, previous+total, &lag, election)
+		}
+
+		// Wait for new changes, if the slot is drained
+		if lastLsn == "" {
+			select {
+			case <-ctx.Done():
+			case <-time.After(cdcPollInterval):
+			}
+		}
+	}
+	return total
+}
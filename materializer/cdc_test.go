@@ -0,0 +1,142 @@
+package main
+
+/*
+@author 1Zero64
+Tests of decoding the changes of the change data capture source
+*/
+
+// Importing packages
+import (
+	// Package for formatted printing
+	"fmt"
+	// Package for string manipulation
+	"strings"
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Function to build the wal2json row of an insert into the event store
+@param lsn Position of the insert
+@param id Id of the inserted measurement
+@return Row of the logical decoding output
+*/
+func cdcInsert(lsn string, id int64) CdcRow {
+	return CdcRow{lsn: lsn, data: fmt.Sprintf(`{"action":"I","columns":[{"name":"id","value":%d},{"name":"sensor_id","value":7},`+
+		`{"name":"temperature","value":21.5},{"name":"humidity","value":40},{"name":"event_stream","value":"room-1"},`+
+		`{"name":"created_on","value":"2024-01-05 10:00:00"},{"name":"processed_on","value":"2024-01-05 10:00:01"}]}`, id)}
+}
+
+/*
+Function to build the wal2json rows of the begin and commit of a transaction
+@param begin Position of the begin
+@param commit End position of the commit
+@return Begin and commit rows of the logical decoding output
+*/
+func cdcTransaction(begin string, commit string) (CdcRow, CdcRow) {
+	return CdcRow{lsn: begin, data: `{"action":"B"}`}, CdcRow{lsn: commit, data: `{"action":"C","timestamp":"2024-01-05 10:00:02+00"}`}
+}
+
+/*
+Function to collect the ids of decoded measurements
+@param measurements Decoded measurements
+@return Ids in the order of the measurements
+*/
+func measurementIds(measurements []Measurement) []int64 {
+	ids := make([]int64, 0, len(measurements))
+	for _, measurement := range measurements {
+		ids = append(ids, measurement.id)
+	}
+	return ids
+}
+
+/*
+Test that the changes of a transaction are taken by its commit position, also when they lie before the commit of an applied transaction
+@param t Test state
+*/
+func TestDecodeCdcBatchComparesCommitPositions(t *testing.T) {
+	streamFilter = ""
+
+	// Two interleaved transactions: the insert of the second lies before the commit of the first
+	begin1, commit1 := cdcTransaction("0/90", "0/200")
+	begin2, commit2 := cdcTransaction("0/140", "0/250")
+	rows := []CdcRow{begin1, cdcInsert("0/100", 1), commit1, begin2, cdcInsert("0/150", 2), commit2}
+
+	// From the start both transactions are taken
+	batch := decodeCdcBatch(rows, 0)
+	if got := fmt.Sprint(measurementIds(batch.measurements)); got != "[1 2]" {
+		t.Errorf("ids = %s, want [1 2]", got)
+	}
+	if batch.lsn != "0/250" || batch.oldestPending.IsZero() {
+		t.Errorf("lsn, oldestPending = %s, %v, want the end of the last commit and its time", batch.lsn, batch.oldestPending)
+	}
+
+	// After a restart with the first transaction applied, only the second one is taken
+	batch = decodeCdcBatch(rows, parseLsn("0/200"))
+	if got := fmt.Sprint(measurementIds(batch.measurements)); got != "[2]" {
+		t.Errorf("ids after the first commit = %s, want [2]", got)
+	}
+}
+
+/*
+Test that an applied transaction larger than the batch size ends the batch at its commit, so the slot is advanced past it
+@param t Test state
+*/
+func TestDecodeCdcBatchAdvancesPastAppliedTransaction(t *testing.T) {
+	streamFilter = ""
+
+	// A bulk load peeked again after a restart between the commit of its rows and the advance of the slot
+	begin, commit := cdcTransaction("0/10", "0/9000")
+	rows := []CdcRow{begin}
+	for id := int64(1); id <= 2000; id++ {
+		rows = append(rows, cdcInsert(fmt.Sprintf("0/%X", 10+id), id))
+	}
+	rows = append(rows, commit)
+	batch := decodeCdcBatch(rows, parseLsn("0/9000"))
+	if len(batch.measurements) != 0 || !batch.oldestPending.IsZero() {
+		t.Errorf("%d measurements of an applied transaction taken", len(batch.measurements))
+	}
+	if batch.lsn != "0/9000" {
+		t.Errorf("lsn = %s, want the end of the applied commit to advance the slot to", batch.lsn)
+	}
+}
+
+/*
+Test that changes, which can't be decoded, are rejected instead of failing the batch
+@param t Test state
+*/
+func TestDecodeCdcBatchRejectsUndecodableChanges(t *testing.T) {
+	streamFilter = ""
+	begin, commit := cdcTransaction("0/10", "0/60")
+	rows := []CdcRow{
+		begin,
+		cdcInsert("0/20", 1),
+		{lsn: "0/30", data: `{"action":"I","columns":[{"name":"id","value":2},{"name":"created_on","value":"yesterday"}]}`},
+		{lsn: "0/40", data: `not json`},
+		cdcInsert("0/50", 3),
+		commit,
+	}
+	batch := decodeCdcBatch(rows, 0)
+	if got := fmt.Sprint(measurementIds(batch.measurements)); got != "[1 3]" {
+		t.Errorf("ids = %s, want [1 3]", got)
+	}
+	if len(batch.rejections) != 2 {
+		t.Fatalf("%d rejections, want 2", len(batch.rejections))
+	}
+	if batch.rejections[0].measurement.id != 2 || !strings.Contains(batch.rejections[0].reason, "0/30") {
+		t.Errorf("first rejection = %+v, want id 2 at 0/30", batch.rejections[0])
+	}
+	if batch.lsn != "0/60" {
+		t.Errorf("lsn = %s, want 0/60", batch.lsn)
+	}
+}
+
+/*
+Test that a drained slot returns an empty batch
+@param t Test state
+*/
+func TestDecodeCdcBatchEmpty(t *testing.T) {
+	if batch := decodeCdcBatch(nil, 0); batch.lsn != "" || len(batch.measurements) != 0 {
+		t.Errorf("batch = %+v, want an empty one", batch)
+	}
+}
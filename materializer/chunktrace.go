@@ -0,0 +1,114 @@
+package main
+
+/*
+@author 1Zero64
+Timing trace of the read, transform and write time per chunk of measurements, to reveal degrading performance during long runs
+*/
+
+// Importing packages
+import (
+	// Package for formatted printing
+	"fmt"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Enumerations for the phases of a measurement in the chunk trace
+const (
+	ChunkRead = iota
+	ChunkTransform
+	ChunkWrite
+)
+
+// Object structure for the timings of a chunk of measurements in seconds
+type ChunkTiming struct {
+	// Index of the chunk, starting with 1
+	Index int `json:"index"`
+	// Number of measurements in the chunk, including skipped ones
+	Rows int `json:"rows"`
+	// Time taking the measurements of the read ones or waiting for the pipeline reader
+	Read float64 `json:"read"`
+	// Time checking the policies and transforming the measurements
+	Transform float64 `json:"transform"`
+	// Time writing the measurements (handing them over to the background writes with WRITE_CONCURRENCY)
+	Write float64 `json:"write"`
+}
+
+// Object structure for the chunk trace of a run
+type ChunkTracer struct {
+	// Timings of the current chunk
+	current ChunkTiming
+	// Timings of the completed chunks
+	chunks []ChunkTiming
+	// Time point of the last lap
+	mark time.Time
+}
+
+/*
+Function to start the chunk trace of a run
+@return Pointer to the trace or nil, if VERBOSE_CHUNKS is disabled
+*/
+func newChunkTracer() *ChunkTracer {
+	if verboseChunks <= 0 {
+		return nil
+	}
+	return &ChunkTracer{current: ChunkTiming{Index: 1}, mark: time.Now()}
+}
+
+/*
+Function to add the time since the last lap to a phase of the current chunk. Does nothing without a trace
+@param phase ChunkRead, ChunkTransform or ChunkWrite
+*/
+func (tracer *ChunkTracer) lap(phase int) {
+	if tracer == nil {
+		return
+	}
+	now := time.Now()
+	elapsed := now.Sub(tracer.mark).Seconds()
+	tracer.mark = now
+	switch phase {
+	case ChunkRead:
+		tracer.current.Read += elapsed
+	case ChunkTransform:
+		tracer.current.Transform += elapsed
+	case ChunkWrite:
+		tracer.current.Write += elapsed
+	}
+}
+
+/*
+Function to count a measurement of the current chunk and complete the chunk, when it's full. Does nothing without a trace
+*/
+func (tracer *ChunkTracer) row() {
+	if tracer == nil {
+		return
+	}
+	tracer.current.Rows++
+	if tracer.current.Rows >= verboseChunks {
+		tracer.complete()
+	}
+}
+
+/*
+Function to print and keep the timings of the current chunk and start the next one
+*/
+func (tracer *ChunkTracer) complete() {
+	chunk := tracer.current
+	fmt.Printf("Chunk %d: %d rows, read %f s, transform %f s, write %f s\n", chunk.Index, chunk.Rows, chunk.Read, chunk.Transform, chunk.Write)
+	tracer.chunks = append(tracer.chunks, chunk)
+	tracer.current = ChunkTiming{Index: chunk.Index + 1}
+}
+
+/*
+Function to complete a partially filled last chunk and get the timings of all chunks
+@return Timings of the chunks (nil without a trace)
+*/
+func (tracer *ChunkTracer) finish() []ChunkTiming {
+	if tracer == nil {
+		return nil
+	}
+	if tracer.current.Rows > 0 {
+		tracer.complete()
+	}
+	return tracer.chunks
+}
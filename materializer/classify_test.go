@@ -0,0 +1,98 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the danger classification at the thresholds with the float comparison tolerance
+*/
+
+// Importing packages
+import (
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Test the readings, which flip into the next tier at a decimal threshold without the tolerance in the single precision build
+@param t Test state
+*/
+func TestClassifyToleranceAtThresholds(t *testing.T) {
+	savedTolerance, savedScoring := tolerance, scoringMode
+	defer func() { tolerance, scoringMode = savedTolerance, savedScoring }()
+	scoringMode = ""
+	boundary := Thresholds{temperature: [4]float64{3, 5, 7.1, 10.1}, humidity: [4]float64{20.1, 40.7, 50, 60}}
+
+	cases := []struct {
+		name        string
+		temperature reading
+		humidity    reading
+		// Danger level with the default tolerance
+		want string
+		// Danger level without a tolerance in the single precision build
+		wantFloat32WithoutTolerance string
+	}{
+		// 10.1 is stored as 10.100000381 and exceeds the Critical threshold of 10.1 without the tolerance
+		{"temperature 10.1 on 10.1", 10.1, 0, High, Critical},
+		// 40.7 is stored as 40.700000763 and exceeds the Medium threshold of 40.7 without the tolerance
+		{"humidity 40.7 on 40.7", 0, 40.7, Low, Medium},
+		// 7.1 is stored as 7.099999905 and stays below the High threshold of 7.1
+		{"temperature 7.1 on 7.1", 7.1, 0, Medium, Medium},
+		// Whole numbers are exact and never exceed an equal threshold
+		{"humidity 60 on 60", 0, 60, High, High},
+		{"temperature 5 on 5", 5, 0, Low, Low},
+		// Readings clearly above a threshold exceed it with the tolerance as well
+		{"temperature 10.2", 10.2, 0, Critical, Critical},
+		{"humidity 40.8", 0, 40.8, Medium, Medium},
+	}
+	for _, testCase := range cases {
+		tolerance = 1e-4
+		if got := classify(boundary, testCase.temperature, testCase.humidity); got != testCase.want {
+			t.Errorf("%s with tolerance: %s, want %s", testCase.name, got, testCase.want)
+		}
+
+		// Without the tolerance the stored value decides, which is only inexact in the single precision build
+		tolerance = 0
+		want := testCase.want
+		if readingBits == 32 {
+			want = testCase.wantFloat32WithoutTolerance
+		}
+		if got := classify(boundary, testCase.temperature, testCase.humidity); got != want {
+			t.Errorf("%s without tolerance: %s, want %s", testCase.name, got, want)
+		}
+	}
+}
+
+/*
+Test that a reading borderline on both temperature and humidity escalates with the weighted risk score above the level of
+either value alone, while the threshold classification keeps the level of the worse value
+@param t Test state
+*/
+func TestClassifyWeightedEscalatesBorderlineReadings(t *testing.T) {
+	savedTolerance, savedScoring, savedWeights, savedScores := tolerance, scoringMode, scoringWeights, scoreThresholds
+	defer func() {
+		tolerance, scoringMode, scoringWeights, scoreThresholds = savedTolerance, savedScoring, savedWeights, savedScores
+	}()
+	tolerance, scoringWeights, scoreThresholds = 0, [2]float64{1, 1}, defaultScoreThresholds
+	defaults := Thresholds{temperature: [4]float64{3, 5, 7, 10}, humidity: [4]float64{20, 40, 50, 60}}
+
+	// Both readings just below their Medium threshold normalize to 1.95 each
+	cases := []struct {
+		name         string
+		temperature  reading
+		humidity     reading
+		wantWeighted string
+	}{
+		{"temperature alone", 4.9, 0, Low},
+		{"humidity alone", 0, 39, Low},
+		{"both borderline", 4.9, 39, High},
+	}
+	for _, testCase := range cases {
+		scoringMode = ScoringThreshold
+		if got := classify(defaults, testCase.temperature, testCase.humidity); got != Low {
+			t.Errorf("%s with thresholds: %s, want %s", testCase.name, got, Low)
+		}
+		scoringMode = ScoringWeighted
+		if got := classify(defaults, testCase.temperature, testCase.humidity); got != testCase.wantWeighted {
+			t.Errorf("%s weighted: %s, want %s", testCase.name, got, testCase.wantWeighted)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+/*
+@author 1Zero64
+Source of the current wall-clock time, replaceable to calculate freshness, lags, skew and retention deterministically
+*/
+
+// Importing packages
+import (
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Function returning the current wall-clock time (time.Now by default). Durations of the phases are measured with time.Now directly
+var nowFunc = time.Now
+
+/*
+Function to get the wall-clock time elapsed since a time point by the replaceable clock
+@param t Time point in the past
+@return Duration since the time point
+*/
+func sinceNow(t time.Time) time.Duration {
+	return nowFunc().Sub(t)
+}
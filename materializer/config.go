@@ -0,0 +1,947 @@
+package main
+
+/*
+@author 1Zero64
+Configuration of the materializer by .env variables and command line flags
+*/
+
+// Importing packages
+import (
+	// Package for command line flags
+	"flag"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for debugging facilities of the Go runtime
+	"runtime/debug"
+	// Package for converting strings to numbers
+	"strconv"
+	// Package for string manipulation
+	"strings"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Enumerations for the policy on measurements of sensors missing in the sensor registry
+const (
+	UnknownSensorSkip       = "skip"
+	UnknownSensorDeadLetter = "dead-letter"
+	UnknownSensorFlag       = "flag"
+)
+
+// Enumerations for the policy on measurements created in the future beyond the skew tolerance
+const (
+	FutureSkewFlag  = "flag"
+	FutureSkewClamp = "clamp"
+	FutureSkewSkip  = "skip"
+)
+
+// Enumerations for the policy on measurements with a NULL temperature or humidity
+const (
+	NullPolicyError = "error"
+	NullPolicyZero  = "zero"
+	NullPolicySkip  = "skip"
+)
+
+// Enumerations for the policy on measurements with a NaN or infinite temperature or humidity
+const (
+	NonFiniteDeadLetter = "dead-letter"
+	NonFiniteClamp      = "clamp"
+)
+
+// Enumerations for the source and output modes
+const (
+	ModeDb  = "db"
+	ModeCsv = "csv"
+	ModeCdc = "cdc"
+)
+
+// Source of the measurements (event store in database, CSV file or changes of the event store from a logical replication slot)
+var sourceMode string
+
+// Name of the logical replication slot of the change data capture source
+var cdcSlot string
+
+// Maximum number of changes applied per transaction by the change data capture source
+var cdcBatchSize int
+
+// Time between two polls of a drained logical replication slot
+var cdcPollInterval time.Duration
+
+// Path of the CSV file to read measurements from
+var sourceCsvPath string
+
+// Output of the transformed measurements (materialized view in database or CSV file)
+var outputMode string
+
+// Path of the CSV file to write transformed measurements into
+var outputCsvPath string
+
+// Layout of timestamps in CSV files (Go reference time layout)
+var csvTimeLayout string
+
+// Event stream to restrict the read measurements to (empty for all event streams)
+var streamFilter string
+
+// Path of the Prometheus textfile to write run metrics into (empty to disable)
+var promFile string
+
+// Flag whether only measurements processed since the last run are appended to the view
+var sinceLastRun bool
+
+// ORDER BY clause of the event store read (id by default)
+var readOrder string
+
+// Policy to collapse measurements sharing (sensor_id, created_on): latest, first or empty to disable
+var dedupPolicy string
+
+// Flag whether the database transforms and writes the measurements with a single INSERT ... SELECT instead of Go
+var pushdownMode bool
+
+// Flag whether only measurements missing from the view are materialized (anti-join against the view)
+var fillGaps bool
+
+// Path of the results file to resume an interrupted microbenchmark from (empty to disable)
+var resumePath string
+
+// Flag whether the microbenchmark reads the measurements once and times only clean, transform and write per iteration
+var cachedRead bool
+
+// Path of the Parquet file to export the transformed measurements into (empty to run the interactive menu)
+var exportParquet string
+
+// Flag whether only the effective SQL dialect is printed
+var showDialect bool
+
+// Flag whether the first error of a single measurement aborts the run with a non-zero exit code
+var strict bool
+
+// Name of the table with the registered sensors to validate sensor ids against (empty to disable)
+var sensorTable string
+
+// Maximum age of the loaded sensor registry before it is reloaded (0 to reload it for every run and CDC batch)
+var sensorRegistryRefresh time.Duration
+
+// Policy on how to handle measurements of unknown sensors
+var unknownSensorPolicy string
+
+// Tolerated clock skew of sensors, before a created_on in the future is counted (0 to disable the check)
+var futureSkew time.Duration
+
+// Policy on how to handle measurements created in the future beyond the skew tolerance
+var futureSkewPolicy string
+
+// Flag whether the pg_stat counters are snapshotted before and after each materialize run
+var pgStats bool
+
+// Language tag of the locale of the numbers in the printed summaries (empty for the plain formatting)
+var outputLocale string
+
+// Flag whether change data capture replicas elect a leader on the advisory lock of the view, which alone consumes the changes
+var leaderElection bool
+
+// Interval of the followers to retry taking over the leadership and limit of the leadership check of the leader
+var leaderPollInterval time.Duration
+
+// Time to wait for the advisory lock of the target table held by another instance (0 to fail immediately)
+var materializeLockTimeout time.Duration
+
+// Flag whether the sizes of the tables involved in a run are reported before and after it
+var tableSizes bool
+
+// Minimum gap between created_on and processed_on of a backfilled measurement (0 to disable the detector)
+var backfillThreshold time.Duration
+
+// Policy on how to handle measurements with a NULL temperature or humidity
+var nullPolicy string
+
+// Policy on how to handle measurements with a NaN or infinite temperature or humidity
+var nonFinitePolicy string
+
+// Margin, by which readings have to pass a threshold to change the danger level of a sensor (0 to disable the hysteresis)
+var hysteresisMargin float64
+
+// Path of the JSON report written after every materialize run (empty to disable)
+var runReportPath string
+
+// Mode of writing the run reports (a file per run or appended to a JSON array)
+var runReportMode string
+
+// Number of retries of a write failing with a deadlock (0 to fail immediately)
+var deadlockRetries int
+
+// Backoff before the first retry of a deadlocked write, growing linearly with the attempts
+var deadlockBackoff time.Duration
+
+// Flag whether the float latency column is still written next to latency_us for dashboards not migrated yet
+var latencyFloatColumn bool
+
+// Flag whether latency_us of existing rows is backfilled instead of running the interactive menu
+var migrateLatencyUs bool
+
+// Ingest lag since the newest processed_on, above which the stream consumer is reported as lagging (0 to disable)
+var ingestLagThreshold time.Duration
+
+// Classification model of the danger levels (threshold or weighted)
+var scoringMode string
+
+// Weights of the normalized temperature and humidity in the weighted risk score
+var scoringWeights [2]float64
+
+// Risk scores, that have to be exceeded for the levels Low, Medium, High and Critical
+var scoreThresholds [4]float64
+
+// Tolerance for comparing float readings against danger thresholds
+var tolerance float64
+
+// Maximum runtime of a single materialize run (0 for no limit)
+var maxRuntime time.Duration
+
+// Latency SLA in milliseconds to count breaching measurements against (0 to disable)
+var latencySla float64
+
+// Path of the file to write the ids of the measurements breaching the latency SLA to (empty to disable)
+var latencySlaFile string
+
+// Upper boundaries of the latency histogram buckets in milliseconds (nil to disable the histogram)
+var latencyBuckets []float64
+
+// Number of unrecorded warmup iterations before a microbenchmark
+var benchmarkWarmup int
+
+// Flag whether the clean phase is included in the timed region of microbenchmark iterations
+var benchmarkIncludeClean bool
+
+// Flag whether garbage collection statistics are collected per microbenchmark iteration
+var benchmarkGCStats bool
+
+// Garbage collection target percentage (GOGC) of the run
+var gcPercent int
+
+// Flag whether the query plans of the read and insert are captured once per microbenchmark
+var benchmarkExplain bool
+
+// Maximum size of a captured query plan in bytes (0 for no limit)
+var benchmarkExplainLimit int
+
+// Flag whether the pg_stat counters are snapshotted before and after each microbenchmark iteration
+var benchmarkPgStats bool
+
+// Number of first and last iterations shown in the per-iteration table of a microbenchmark (0 to show all)
+var benchmarkTableLimit int
+
+// Flag whether the transformed measurements are additionally written into a table per danger level
+var splitByDanger bool
+
+// Path of the JSON file with the jobs of the scheduler (empty to run the interactive menu)
+var scheduleFile string
+
+// Validated jobs of the schedule file
+var scheduledJobs []ScheduledJob
+
+// Threshold of skipped measurements, absolute or as percentage with %, above which a batch run fails (empty to disable)
+var skipThresholdValue string
+
+// Parsed threshold of skipped measurements
+var skipThreshold float64
+
+// Flag whether the threshold of skipped measurements is a percentage of the input
+var skipThresholdPercent bool
+
+// Id of the last exported measurement of an interrupted CSV or Parquet export to continue after (0 to export from the start)
+var resumeFromId int64
+
+// Flag whether the CSV output and the rows of peek are ordered by danger severity (Critical first) instead of by id or time
+var sortSeverity bool
+
+// Flag whether a single non-interactive materialize run is executed instead of the interactive menu
+var batchMode bool
+
+// Flag whether the interactive menu returns after a failed function instead of terminating the program
+var menuRecover bool
+
+// Relative standard error of the mean, below which a microbenchmark stops (0 to run all iterations)
+var convergenceThreshold float64
+
+// Maximum time of the measured iterations of a converging microbenchmark (0 for no limit)
+var benchmarkMaxTime time.Duration
+
+// Path of the JSON file to export microbenchmark results into (empty to disable)
+var benchmarkExport string
+
+// Age of materialized view rows (by created_on), after which they are purged (0 to disable)
+var retention time.Duration
+
+// Number of rows deleted per statement while purging
+var purgeBatchSize int
+
+// Flag whether rows are purged automatically after each materialize run
+var autoPurge bool
+
+// Flag whether the retention purge also deletes the hourly buckets of the aggregated view
+var purgeAggregate bool
+
+// Flag whether the materialized view is partitioned monthly on created_on
+var partitionedView bool
+
+// Flag whether the materialized view is append-only and never cleaned before a run
+var appendOnly bool
+
+// Fraction of measurements to sample (1 to process all measurements)
+var sampleRate float64
+
+// Seed of the random number generator for reproducible samples
+var sampleSeed int64
+
+// Flag whether SAMPLE_SEED is set explicitly, which makes the database-side samples repeatable
+var sampleSeedSet bool
+
+// Fraction of the event store to materialize into the sample table (0 to disable)
+var sampleFraction float64
+
+// Number of rows to materialize into the sample table (0 to disable)
+var sampleRows int
+
+// Method of the database-side sample (bernoulli, system or random)
+var sampleMethod string
+
+// Maximum number of concurrent write statements
+var writeConcurrency int
+
+// Name of the database driver (lib/pq or pgx)
+var dbDriver string
+
+// Maximum number of events the read replica may be behind the primary before reading
+var replicaMaxLag int64
+
+// Maximum time to wait for a lagging read replica to catch up before reading anyway
+var replicaLagWait time.Duration
+
+// Flag whether the measurements are streamed from a dedicated read connection to the writes while they are read
+var pipelineMode bool
+
+// Number of measurements buffered between the pipeline reader and the writes
+var pipelineBuffer int
+
+// Number of measurements per chunk of the timing trace (0 to disable the trace)
+var verboseChunks int
+
+// Maximum number of measurements read into memory, above which they are streamed like in the pipeline mode (0 for no limit)
+var maxInMemory int64
+
+// Worker counts of the write concurrency sweep
+var sweepWorkers []int
+
+// Progress output mode (auto detects a terminal, on forces the progress bar, off forces log lines)
+var progressMode string
+
+// Interval between two progress log lines without a progress bar
+var progressInterval time.Duration
+
+// Flag whether the benchmarks write with synchronous_commit=off, an unsafe setting excluding the fsync latency
+var benchmarkSynchronousCommitOff bool
+
+// Handling of the in-flight benchmark iteration on an interrupt (finish or abandon)
+var benchmarkInterruptPolicy string
+
+// Flag whether a full rebuild is skipped, if the source data didn't change since the last one
+var skipIfUnchanged bool
+
+// Port of the embedded web dashboard (empty to disable)
+var httpPort string
+
+// Listen address of the gRPC service of the serve-grpc subcommand
+var grpcAddress string
+
+// Interval between two throughput log lines during a run (0 to disable)
+var throughputInterval time.Duration
+
+// Flag whether the healthcheck checks the event store and materialized view exist
+var healthcheckTables bool
+
+// Maximum time to wait for the database to become available on startup (0 for a single attempt)
+var startupTimeout time.Duration
+
+// Maximum number of open database connections of the connection pool (0 for unlimited)
+var dbMaxOpenConnections int
+
+// Flag whether full rebuilds are written into a staging table, that is swapped with the view afterwards
+var stagingRebuild bool
+
+// Flag whether the measurements are aggregated into hourly buckets per sensor instead of being written one by one
+var aggregateMode bool
+
+// Name of the table of the hourly buckets
+var aggregateTable string
+
+// Flag whether the planner statistics of the materialized view are refreshed after a full rebuild
+var postAnalyze bool
+
+// Flag whether the materialized view is vacuumed together with the statistics refresh
+var postVacuum bool
+
+/*
+Function to load the configuration from .env variables and command line flags
+*/
+func loadConfig() {
+
+	// Read source and output settings
+	sourceMode = getEnv("SOURCE", ModeDb)
+	sourceCsvPath = os.Getenv("SOURCE_CSV_PATH")
+	outputMode = getEnv("OUTPUT", ModeDb)
+	outputCsvPath = os.Getenv("OUTPUT_CSV_PATH")
+	csvTimeLayout = getEnv("CSV_TIME_LAYOUT", time.RFC3339Nano)
+
+	// Check for supported modes with their paths
+	if sourceMode != ModeDb && sourceMode != ModeCsv && sourceMode != ModeCdc {
+		checkError(fmt.Errorf("invalid SOURCE %q, expected %q, %q or %q", sourceMode, ModeDb, ModeCsv, ModeCdc))
+	}
+	if outputMode != ModeDb && outputMode != ModeCsv {
+		checkError(fmt.Errorf("invalid OUTPUT %q, expected %q or %q", outputMode, ModeDb, ModeCsv))
+	}
+	if sourceMode == ModeCsv && sourceCsvPath == "" {
+		checkError(fmt.Errorf("SOURCE_CSV_PATH is required for SOURCE %q", ModeCsv))
+	}
+	if outputMode == ModeCsv && outputCsvPath == "" {
+		checkError(fmt.Errorf("OUTPUT_CSV_PATH is required for OUTPUT %q", ModeCsv))
+	}
+
+	// Read change data capture settings, which writes the changes incrementally into the materialized view
+	cdcSlot = getEnv("CDC_SLOT", "materializer_cdc")
+	cdcBatchSize = getIntEnv("CDC_BATCH_SIZE", 1000)
+	cdcPollInterval = getDurationEnv("CDC_POLL_INTERVAL", time.Second)
+	if cdcBatchSize < 1 || cdcPollInterval <= 0 {
+		checkError(fmt.Errorf("invalid CDC_BATCH_SIZE %d or CDC_POLL_INTERVAL %s, expected values > 0", cdcBatchSize, cdcPollInterval))
+	}
+	if sourceMode == ModeCdc && outputMode != ModeDb {
+		checkError(fmt.Errorf("SOURCE %q requires OUTPUT %q", ModeCdc, ModeDb))
+	}
+
+	// Read and validate the jobs of the scheduler, so misconfigured cron expressions are rejected at startup
+	scheduleFile = os.Getenv("SCHEDULE_FILE")
+	if scheduleFile != "" {
+		scheduledJobs = readSchedule(scheduleFile)
+	}
+
+	// Read threshold of skipped measurements
+	skipThresholdValue = os.Getenv("SKIP_THRESHOLD")
+	skipThreshold, skipThresholdPercent = parseSkipThreshold(skipThresholdValue)
+
+	// Read recovery of the interactive menu after failed functions
+	menuRecover = getBoolEnv("MENU_RECOVER", false)
+
+	// Read protection of the environment against destructive operations
+	protectedEnvironment = getBoolEnv("PROTECTED", false)
+
+	// Read event stream filter
+	streamFilter = os.Getenv("EVENT_STREAM")
+
+	// Read sensor registry settings
+	sensorTable = os.Getenv("SENSOR_TABLE")
+	sensorRegistryRefresh = getDurationEnv("SENSOR_REGISTRY_REFRESH", 0)
+	unknownSensorPolicy = getEnv("UNKNOWN_SENSOR_POLICY", UnknownSensorSkip)
+
+	// Check for a supported unknown sensor policy
+	switch unknownSensorPolicy {
+	case UnknownSensorSkip, UnknownSensorDeadLetter, UnknownSensorFlag:
+	default:
+		checkError(fmt.Errorf("invalid UNKNOWN_SENSOR_POLICY %q, expected %q, %q or %q", unknownSensorPolicy, UnknownSensorSkip, UnknownSensorDeadLetter, UnknownSensorFlag))
+	}
+
+	// Read future-dated created_on settings
+	futureSkew = getDurationEnv("FUTURE_SKEW", 0)
+	futureSkewPolicy = getEnv("FUTURE_SKEW_POLICY", FutureSkewFlag)
+
+	// Read whether the pg_stat counters are snapshotted
+	pgStats = getBoolEnv("PG_STATS", false)
+
+	// Read the time to wait for the advisory lock of another instance
+	materializeLockTimeout = getDurationEnv("MATERIALIZE_LOCK_TIMEOUT", 0)
+	if materializeLockTimeout < 0 {
+		checkError(fmt.Errorf("invalid MATERIALIZE_LOCK_TIMEOUT %s, expected a duration >= 0", materializeLockTimeout))
+	}
+
+	// Read the leader election of change data capture replicas
+	leaderElection = getBoolEnv("LEADER_ELECTION", false)
+	leaderPollInterval = getDurationEnv("LEADER_POLL_INTERVAL", 5*time.Second)
+	if leaderElection && (sourceMode != ModeCdc || leaderPollInterval <= 0) {
+		checkError(fmt.Errorf("LEADER_ELECTION needs SOURCE=cdc and a LEADER_POLL_INTERVAL > 0"))
+	}
+
+	// Read whether the table sizes are reported
+	tableSizes = getBoolEnv("TABLE_SIZES", false)
+
+	// Read the gap of backfilled measurements
+	backfillThreshold = getDurationEnv("BACKFILL_THRESHOLD", 0)
+	if backfillThreshold < 0 {
+		checkError(fmt.Errorf("invalid BACKFILL_THRESHOLD %s, expected a duration >= 0", backfillThreshold))
+	}
+
+	// Check for a supported future skew policy
+	switch futureSkewPolicy {
+	case FutureSkewFlag, FutureSkewClamp, FutureSkewSkip:
+	default:
+		checkError(fmt.Errorf("invalid FUTURE_SKEW_POLICY %q, expected %q, %q or %q", futureSkewPolicy, FutureSkewFlag, FutureSkewClamp, FutureSkewSkip))
+	}
+
+	// Read and check the policy on NULL readings
+	nullPolicy = getEnv("NULL_POLICY", NullPolicyError)
+	switch nullPolicy {
+	case NullPolicyError, NullPolicyZero, NullPolicySkip:
+	default:
+		checkError(fmt.Errorf("invalid NULL_POLICY %q, expected %q, %q or %q", nullPolicy, NullPolicyError, NullPolicyZero, NullPolicySkip))
+	}
+
+	// Read danger thresholds, which are the fallback of the danger_thresholds table
+	configuredThresholds = Thresholds{
+		temperature: getThresholdsEnv("THRESHOLDS_TEMPERATURE", defaultThresholds.temperature),
+		humidity:    getThresholdsEnv("THRESHOLDS_HUMIDITY", defaultThresholds.humidity),
+	}
+	thresholds = configuredThresholds
+
+	// Read threshold profiles per event stream
+	loadThresholdProfiles()
+
+	// Read scoring model and check it is supported
+	scoringMode = getEnv("SCORING_MODE", ScoringThreshold)
+	if scoringMode != ScoringThreshold && scoringMode != ScoringWeighted {
+		checkError(fmt.Errorf("invalid SCORING_MODE %q, expected %q or %q", scoringMode, ScoringThreshold, ScoringWeighted))
+	}
+	scoringWeights = parseScoringWeights(getEnv("SCORING_WEIGHTS", "1,1"))
+	scoreThresholds = getThresholdsEnv("SCORE_THRESHOLDS", defaultScoreThresholds)
+
+	// Read tolerance for threshold comparisons and check it's not negative
+	tolerance = getFloatEnv("TOLERANCE", 1e-4)
+	if tolerance < 0 {
+		checkError(fmt.Errorf("invalid TOLERANCE %v, expected a value >= 0", tolerance))
+	}
+
+	// Read and check the policy on NaN and infinite readings
+	nonFinitePolicy = getEnv("NON_FINITE_POLICY", NonFiniteDeadLetter)
+	if nonFinitePolicy != NonFiniteDeadLetter && nonFinitePolicy != NonFiniteClamp {
+		checkError(fmt.Errorf("invalid NON_FINITE_POLICY %q, expected %q or %q", nonFinitePolicy, NonFiniteDeadLetter, NonFiniteClamp))
+	}
+
+	// Read retry settings of deadlocked writes
+	deadlockRetries = getIntEnv("DEADLOCK_RETRIES", 3)
+	if deadlockRetries < 0 {
+		checkError(fmt.Errorf("invalid DEADLOCK_RETRIES %d, expected a value >= 0", deadlockRetries))
+	}
+	deadlockBackoff = getDurationEnv("DEADLOCK_BACKOFF", 50*time.Millisecond)
+
+	// Read run report settings
+	runReportPath = getEnv("RUN_REPORT", "")
+	runReportMode = getEnv("RUN_REPORT_MODE", RunReportPerRun)
+	if runReportMode != RunReportPerRun && runReportMode != RunReportAppend {
+		checkError(fmt.Errorf("invalid RUN_REPORT_MODE %q, expected %q or %q", runReportMode, RunReportPerRun, RunReportAppend))
+	}
+
+	// Read ingest lag threshold
+	ingestLagThreshold = getDurationEnv("INGEST_LAG_THRESHOLD", 0)
+
+	// Read maximum runtime of a run
+	maxRuntime = getDurationEnv("MAX_RUNTIME", 0)
+
+	// Read unit of the latency
+	var err error
+	latencyUnit, err = parseLatencyUnit(getEnv("LATENCY_UNIT", "ms"))
+	if err != nil {
+		checkError(fmt.Errorf("invalid LATENCY_UNIT: %w", err))
+	}
+
+	// Read the locale of the numbers in the printed summaries
+	outputLocale = getEnv("OUTPUT_LOCALE", "")
+	localePrinter, err = newLocalePrinter(outputLocale)
+	if err != nil {
+		checkError(fmt.Errorf("invalid OUTPUT_LOCALE %q: %w", outputLocale, err))
+	}
+
+	// Read whether the float latency column is maintained next to latency_us
+	latencyFloatColumn = getBoolEnv("LATENCY_FLOAT_COLUMN", true)
+
+	// Read latency SLA and convert it into the latency unit
+	latencySla = getFloatEnv("LATENCY_SLA_MS", 0)
+	if latencySla < 0 {
+		checkError(fmt.Errorf("invalid LATENCY_SLA_MS %v, expected a value >= 0", latencySla))
+	}
+	latencySla = latencyUnit.fromMilliseconds(latencySla)
+	latencySlaFile = getEnv("LATENCY_SLA_FILE", "")
+
+	// Read latency histogram buckets, custom boundaries enable the histogram as well
+	if value := getEnv("LATENCY_BUCKETS", ""); value != "" {
+		var err error
+		latencyBuckets, err = parseBuckets(value)
+		if err != nil {
+			checkError(fmt.Errorf("invalid LATENCY_BUCKETS %q: %w", value, err))
+		}
+	} else if getBoolEnv("LATENCY_HISTOGRAM", false) {
+		latencyBuckets = defaultLatencyBuckets
+	}
+
+	// Read microbenchmark settings
+	benchmarkWarmup = getIntEnv("BENCHMARK_WARMUP", 0)
+	convergenceThreshold = getFloatEnv("CONVERGENCE_THRESHOLD", 0)
+	benchmarkMaxTime = getDurationEnv("BENCHMARK_MAX_TIME", 0)
+	benchmarkExport = os.Getenv("BENCHMARK_EXPORT")
+	benchmarkIncludeClean = getBoolEnv("BENCHMARK_INCLUDE_CLEAN", false)
+	benchmarkGCStats = getBoolEnv("BENCHMARK_GC_STATS", false)
+	benchmarkTableLimit = getIntEnv("BENCHMARK_TABLE_LIMIT", 0)
+	if benchmarkTableLimit < 0 {
+		checkError(fmt.Errorf("invalid BENCHMARK_TABLE_LIMIT %d, expected a value >= 0", benchmarkTableLimit))
+	}
+	benchmarkPgStats = getBoolEnv("BENCHMARK_PG_STATS", false)
+	benchmarkExplain = getBoolEnv("BENCHMARK_EXPLAIN", false)
+	benchmarkExplainLimit = getIntEnv("BENCHMARK_EXPLAIN_LIMIT", 1048576)
+	if benchmarkExplainLimit < 0 {
+		checkError(fmt.Errorf("invalid BENCHMARK_EXPLAIN_LIMIT %d, expected a value >= 0", benchmarkExplainLimit))
+	}
+
+	// Set the garbage collection target percentage, if configured, and remember the effective one for the run metadata
+	if value := os.Getenv("GC_PERCENT"); value != "" {
+		debug.SetGCPercent(getIntEnv("GC_PERCENT", 100))
+	}
+	gcPercent = debug.SetGCPercent(100)
+	debug.SetGCPercent(gcPercent)
+	if benchmarkWarmup < 0 || convergenceThreshold < 0 {
+		checkError(fmt.Errorf("invalid BENCHMARK_WARMUP %d or CONVERGENCE_THRESHOLD %v, expected values >= 0", benchmarkWarmup, convergenceThreshold))
+	}
+
+	// Read retention purge settings
+	retention = getDurationEnv("RETENTION", 0)
+	purgeBatchSize = getIntEnv("PURGE_BATCH_SIZE", 50000)
+	autoPurge = getBoolEnv("AUTO_PURGE", false)
+	purgeAggregate = getBoolEnv("PURGE_AGGREGATE", false)
+	if purgeBatchSize <= 0 {
+		checkError(fmt.Errorf("invalid PURGE_BATCH_SIZE %d, expected a value > 0", purgeBatchSize))
+	}
+
+	// Read partitioning option of the schema bootstrap
+	partitionedView = getBoolEnv("PARTITIONED_VIEW", false)
+
+	// Read append-only option
+	appendOnly = getBoolEnv("APPEND_ONLY", false)
+
+	// Read sampling options and check the rate is a fraction
+	sampleRate = getFloatEnv("SAMPLE_RATE", 1)
+	sampleSeed = int64(getIntEnv("SAMPLE_SEED", 1))
+	sampleSeedSet = os.Getenv("SAMPLE_SEED") != ""
+	sampleMethod = getEnv("SAMPLE_METHOD", SampleBernoulli)
+	if sampleMethod != SampleBernoulli && sampleMethod != SampleSystem && sampleMethod != SampleRandom {
+		checkError(fmt.Errorf("invalid SAMPLE_METHOD %q, expected bernoulli, system or random", sampleMethod))
+	}
+	if sampleRate <= 0 || sampleRate > 1 {
+		checkError(fmt.Errorf("invalid SAMPLE_RATE %v, expected a value > 0 and <= 1", sampleRate))
+	}
+
+	// Read write concurrency and check at least one write can run
+	writeConcurrency = getIntEnv("WRITE_CONCURRENCY", 1)
+	if writeConcurrency < 1 {
+		checkError(fmt.Errorf("invalid WRITE_CONCURRENCY %d, expected a value >= 1", writeConcurrency))
+	}
+
+	// Read worker counts of the write concurrency sweep
+	sweepWorkers = parseWorkerCounts(getEnv("SWEEP_WORKERS", "1,2,4,8,16"))
+
+	// Read database driver and check it is supported
+	dbDriver = getEnv("DB_DRIVER", DriverPq)
+	if dbDriver != DriverPq && dbDriver != DriverPgx {
+		checkError(fmt.Errorf("invalid DB_DRIVER %q, expected %q or %q", dbDriver, DriverPq, DriverPgx))
+	}
+
+	// Read replica lag settings
+	replicaMaxLag = int64(getIntEnv("REPLICA_MAX_LAG", 0))
+	replicaLagWait = getDurationEnv("REPLICA_LAG_WAIT", 0)
+
+	// Read pipeline mode settings
+	pipelineMode = getBoolEnv("PIPELINE", false)
+	pipelineBuffer = getIntEnv("PIPELINE_BUFFER", 1000)
+	maxInMemory = int64(getIntEnv("MAX_IN_MEMORY", 0))
+	verboseChunks = getIntEnv("VERBOSE_CHUNKS", 0)
+	if verboseChunks < 0 {
+		checkError(fmt.Errorf("invalid VERBOSE_CHUNKS %d, expected a value >= 0", verboseChunks))
+	}
+	if maxInMemory < 0 {
+		checkError(fmt.Errorf("invalid MAX_IN_MEMORY %d, expected a value >= 0", maxInMemory))
+	}
+	if pipelineBuffer < 1 {
+		checkError(fmt.Errorf("invalid PIPELINE_BUFFER %d, expected a value >= 1", pipelineBuffer))
+	}
+
+	// Read progress output options and check for a supported mode
+	progressMode = getEnv("PROGRESS", ProgressAuto)
+	if progressMode != ProgressAuto && progressMode != ProgressOn && progressMode != ProgressOff {
+		checkError(fmt.Errorf("invalid PROGRESS %q, expected %q, %q or %q", progressMode, ProgressAuto, ProgressOn, ProgressOff))
+	}
+	progressInterval = getDurationEnv("PROGRESS_INTERVAL", 10*time.Second)
+
+	// Read unsafe benchmark setting for asynchronous commits
+	benchmarkSynchronousCommitOff = getBoolEnv("BENCHMARK_SYNCHRONOUS_COMMIT_OFF", false)
+
+	// Read and validate the order of the event store read
+	readOrder, err = parseReadOrder(getEnv("READ_ORDER", "id"))
+	if err != nil {
+		checkError(fmt.Errorf("invalid READ_ORDER %q: %w", os.Getenv("READ_ORDER"), err))
+	}
+
+	// Read hysteresis margin, which needs the measurements of every sensor in created_on order and the threshold classification
+	hysteresisMargin = getFloatEnv("HYSTERESIS_MARGIN", 0)
+	if hysteresisMargin < 0 {
+		checkError(fmt.Errorf("invalid HYSTERESIS_MARGIN %v, expected a value >= 0", hysteresisMargin))
+	}
+	if hysteresisMargin > 0 && (sourceMode != ModeDb || readOrder != "created_on ASC, id ASC" || scoringMode == ScoringWeighted) {
+		checkError(fmt.Errorf("HYSTERESIS_MARGIN requires SOURCE=db, READ_ORDER=created_on and SCORING_MODE=threshold"))
+	}
+
+	// Read deduplication policy
+	dedupPolicy = getEnv("DEDUP", "")
+	if dedupPolicy != "" && dedupPolicy != DedupLatest && dedupPolicy != DedupFirst {
+		checkError(fmt.Errorf("invalid DEDUP %q, expected latest or first", dedupPolicy))
+	}
+
+	// Read handling of the in-flight benchmark iteration on an interrupt
+	benchmarkInterruptPolicy = getEnv("BENCHMARK_INTERRUPT", InterruptFinish)
+	if benchmarkInterruptPolicy != InterruptFinish && benchmarkInterruptPolicy != InterruptAbandon {
+		checkError(fmt.Errorf("invalid BENCHMARK_INTERRUPT %q, expected finish or abandon", benchmarkInterruptPolicy))
+	}
+
+	// Read option to skip rebuilds of unchanged source data
+	skipIfUnchanged = getBoolEnv("SKIP_IF_UNCHANGED", false)
+
+	// Read port of the web dashboard
+	httpPort = getEnv("HTTP_PORT", "")
+
+	// Read listen address of the gRPC service
+	grpcAddress = getEnv("GRPC_ADDRESS", ":50051")
+
+	// Read throughput logging interval
+	throughputInterval = getDurationEnv("THROUGHPUT_INTERVAL", 30*time.Second)
+
+	// Read healthcheck option
+	healthcheckTables = getBoolEnv("HEALTHCHECK_TABLES", true)
+
+	// Read timeout of waiting for the database on startup
+	startupTimeout = getDurationEnv("STARTUP_TIMEOUT", 0)
+
+	// Read maximum number of open database connections
+	dbMaxOpenConnections = getIntEnv("DB_MAX_OPEN_CONNS", 0)
+
+	// Read staging rebuild option, which isn't supported for partitioned views
+	stagingRebuild = getBoolEnv("STAGING_REBUILD", false)
+
+	// Read aggregation options
+	aggregateMode = getBoolEnv("AGGREGATE", false)
+	aggregateTable = getEnv("AGGREGATE_TABLE", "materialized_view_hourly")
+
+	// Read routing of the measurements into a table per danger level
+	splitByDanger = getBoolEnv("SPLIT_BY_DANGER", false)
+
+	// Read post-run maintenance options
+	postAnalyze = getBoolEnv("POST_ANALYZE", true)
+	postVacuum = getBoolEnv("POST_VACUUM", false)
+
+	// Define flags with their default values and usage descriptions
+	flag.StringVar(&promFile, "prom-file", "", "Path of a .prom file for the node_exporter textfile collector to write run metrics into")
+	flag.BoolVar(&sinceLastRun, "since-last-run", false, "Append only measurements processed after the newest processed_on in the materialized view")
+	flag.Float64Var(&sampleFraction, "sample", 0, "Materialize a random fraction of the event store (e.g. 0.01) into materialized_view_sample and exit")
+	flag.IntVar(&sampleRows, "sample-rows", 0, "Materialize about the given number of random rows of the event store into materialized_view_sample and exit")
+	flag.BoolVar(&pushdownMode, "pushdown", false, "Transform and write the measurements in the database with a single INSERT ... SELECT instead of in Go")
+	flag.BoolVar(&fillGaps, "fill-gaps", false, "Append only measurements of the event store missing from the materialized view, without cleaning it")
+	flag.StringVar(&resumePath, "resume", "", "Path of a microbenchmark results file to continue an interrupted benchmark from and to save every iteration into")
+	flag.StringVar(&exportParquet, "export-parquet", "", "Path of a Parquet file to export the transformed measurements into, streaming them from the event store, and exit")
+	flag.BoolVar(&cachedRead, "cached-read", false, "Read the measurements once before the microbenchmark and exclude the read phase from the iterations")
+	flag.BoolVar(&migrateLatencyUs, "migrate-latency-us", false, "Backfill latency_us of existing rows of the materialized view from their timestamps and exit")
+	flag.BoolVar(&showDialect, "dialect", false, "Print the selected database driver, SQL dialect, placeholder style and a sample insert statement and exit")
+	flag.String("env", "", "Environment to load .env.<name> for with fallback to .env (overrides APP_ENV)")
+	flag.BoolVar(&force, "force", false, "Run destructive operations like clean and purge against a PROTECTED environment")
+	flag.BoolVar(&batchMode, "batch", false, "Run a single non-interactive materialize run with log line progress, a final JSON result line and distinct exit codes, e.g. as Kubernetes Job")
+	flag.Int64Var(&resumeFromId, "resume-from", 0, "Continue an interrupted OUTPUT=csv export by appending the measurements after the given id, or write them into a new -export-parquet file")
+	flag.BoolVar(&sortSeverity, "sort-severity", false, "Order the CSV output and the rows of peek by danger severity, Critical first, instead of by id or time")
+	flag.BoolVar(&strict, "strict", false, "Abort the run with a non-zero exit code on the first error or tolerated problem of a single measurement")
+
+	// Parse given command line arguments
+	flag.Parse()
+
+	// Print the progress of a batch run as periodic log lines instead of a progress bar
+	if batchMode {
+		progressMode = ProgressOff
+	}
+
+	// Incremental runs append to the view, so it must neither be cleaned nor fail on existing rows
+	if sinceLastRun || fillGaps {
+		appendOnly = true
+	}
+
+	// The danger level tables are cleaned per run like the view, so they need a full rebuild writing rows into the database
+	if splitByDanger && (appendOnly || aggregateMode || outputMode == ModeCsv || pushdownMode) {
+		checkError(fmt.Errorf("SPLIT_BY_DANGER can't be combined with APPEND_ONLY, -since-last-run, -fill-gaps, AGGREGATE, OUTPUT=csv or -pushdown"))
+	}
+
+	// A resumed export continues after an id, so the rows must be read in id order without collapsing duplicates across the boundary
+	if resumeFromId < 0 || (resumeFromId > 0 && ((outputMode != ModeCsv && exportParquet == "") || sourceMode != ModeDb || readOrder != "id ASC" || dedupPolicy != "" || sortSeverity)) {
+		checkError(fmt.Errorf("-resume-from needs a positive id, OUTPUT=csv or -export-parquet, SOURCE=db and READ_ORDER=id and can't be combined with DEDUP or -sort-severity"))
+	}
+
+	// Gaps are found by joining the event store against the view, so both must be in the database
+	if fillGaps && (sourceMode == ModeCsv || outputMode == ModeCsv) {
+		checkError(fmt.Errorf("-fill-gaps can't be combined with SOURCE=csv or OUTPUT=csv"))
+	}
+
+	// The sample fraction has to be a fraction and can't be combined with a number of rows
+	if sampleFraction < 0 || sampleFraction > 1 || sampleRows < 0 || (sampleFraction > 0 && sampleRows > 0) {
+		checkError(fmt.Errorf("invalid sample, expected either -sample with a fraction > 0 and <= 1 or -sample-rows with a positive number"))
+	}
+
+	// The pushdown implements only the transformation of the threshold classification in SQL
+	if pushdownMode && (sourceMode != ModeDb || outputMode == ModeCsv || aggregateMode || scoringMode == ScoringWeighted || sampleRate < 1 || sensorTable != "" || futureSkew > 0 || len(streamProfiles) > 0 || nullPolicy != NullPolicyError || nonFinitePolicy == NonFiniteClamp || hysteresisMargin > 0) {
+		checkError(fmt.Errorf("-pushdown can't be combined with SOURCE=csv or cdc, OUTPUT=csv, AGGREGATE, SCORING_MODE=weighted, SAMPLE_RATE, SENSOR_TABLE, FUTURE_SKEW, STREAM_PROFILES, NULL_POLICY, NON_FINITE_POLICY=clamp or HYSTERESIS_MARGIN"))
+	}
+
+	// The aggregated view is always rebuilt completely from the event store
+	if aggregateMode && (appendOnly || stagingRebuild || outputMode == ModeCsv) {
+		checkError(fmt.Errorf("AGGREGATE can't be combined with APPEND_ONLY, -since-last-run, -fill-gaps, STAGING_REBUILD or OUTPUT=csv"))
+	}
+
+	// The strict mode aborts on every tolerated measurement, so it contradicts the settings tolerating them
+	checkStrictMode()
+}
+
+/*
+Function to refuse the strict mode together with a SKIP_THRESHOLD or an explicitly configured policy, that skips, dead-letters
+or clamps measurements, as the strict mode aborts on them instead. The tolerant defaults of UNKNOWN_SENSOR_POLICY and
+NON_FINITE_POLICY are replaced by the abort
+*/
+func checkStrictMode() {
+	if !strict {
+		return
+	}
+	if skipThresholdValue != "" || nullPolicy != NullPolicyError || futureSkewPolicy != FutureSkewFlag || nonFinitePolicy == NonFiniteClamp ||
+		(os.Getenv("NON_FINITE_POLICY") != "" && nonFinitePolicy == NonFiniteDeadLetter) || (os.Getenv("UNKNOWN_SENSOR_POLICY") != "" && unknownSensorPolicy != UnknownSensorFlag) {
+		checkError(fmt.Errorf("-strict can't be combined with SKIP_THRESHOLD, NULL_POLICY zero or skip, FUTURE_SKEW_POLICY clamp or skip, NON_FINITE_POLICY or UNKNOWN_SENSOR_POLICY skip or dead-letter"))
+	}
+}
+
+/*
+Function to read a .env variable with a default value
+@param name Name of the variable
+@param defaultValue Value to use, if the variable is not set or empty
+@return Value of the variable or the default value
+*/
+func getEnv(name string, defaultValue string) string {
+
+	// Return default value for missing variables
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+/*
+Function to read a sensitive .env variable, which can also be given as file (e.g. a Docker or Kubernetes secret mount)
+with the variable name and the suffix _FILE. The file takes precedence over the variable, trailing newlines are trimmed
+@param name Name of the variable
+@param defaultValue Value to use, if neither the file nor the variable is set
+@return Value of the secret
+*/
+func getSecretEnv(name string, defaultValue string) string {
+
+	// Read the secret from the file, if given
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			checkError(fmt.Errorf("unreadable %s_FILE: %w", name, err))
+		}
+		return strings.TrimRight(string(content), "\r\n")
+	}
+
+	// Fall back to the variable
+	return getEnv(name, defaultValue)
+}
+
+/*
+Function to read a .env variable as float with a default value
+@param name Name of the variable
+@param defaultValue Value to use, if the variable is not set or empty
+@return Parsed value of the variable or the default value
+*/
+func getFloatEnv(name string, defaultValue float64) float64 {
+
+	// Return default value for missing variables
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	// Parse value and check on error with handler
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		checkError(fmt.Errorf("invalid %s %q: %w", name, value, err))
+	}
+	return parsed
+}
+
+/*
+Function to read a .env variable as integer with a default value
+@param name Name of the variable
+@param defaultValue Value to use, if the variable is not set or empty
+@return Parsed value or the default value
+*/
+func getIntEnv(name string, defaultValue int) int {
+
+	// Return default value for missing variables
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	// Parse value and check on error with handler
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		checkError(fmt.Errorf("invalid %s %q, expected an integer: %w", name, value, err))
+	}
+	return parsed
+}
+
+/*
+Function to read a .env variable as boolean with a default value
+@param name Name of the variable
+@param defaultValue Value to use, if the variable is not set or empty
+@return Parsed value or the default value
+*/
+func getBoolEnv(name string, defaultValue bool) bool {
+
+	// Return default value for missing variables
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	// Parse value and check on error with handler
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		checkError(fmt.Errorf("invalid %s %q, expected true or false: %w", name, value, err))
+	}
+	return parsed
+}
+
+/*
+Function to read a .env variable as duration (e.g. "720h") with a default value
+@param name Name of the variable
+@param defaultValue Value to use, if the variable is not set or empty
+@return Parsed value of the variable or the default value
+*/
+func getDurationEnv(name string, defaultValue time.Duration) time.Duration {
+
+	// Return default value for missing variables
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	// Parse value and check on error with handler
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		checkError(fmt.Errorf("invalid %s %q: %w", name, value, err))
+	}
+	return parsed
+}
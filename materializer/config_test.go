@@ -0,0 +1,86 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the parsing of integer, boolean and duration variables
+*/
+
+// Importing packages
+import (
+	// Package for string manipulation
+	"strings"
+	// Package for automated tests
+	"testing"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+/*
+Test default, valid and invalid values of integer variables
+@param t Test state
+*/
+func TestGetIntEnv(t *testing.T) {
+	t.Setenv("MATERIALIZER_TEST_INT", "")
+	if got := getIntEnv("MATERIALIZER_TEST_INT", 42); got != 42 {
+		t.Errorf("unset: %d, want the default 42", got)
+	}
+	t.Setenv("MATERIALIZER_TEST_INT", "-7")
+	if got := getIntEnv("MATERIALIZER_TEST_INT", 42); got != -7 {
+		t.Errorf("-7: %d", got)
+	}
+	for _, value := range []string{"abc", "1.5", "10k", "99999999999999999999"} {
+		t.Setenv("MATERIALIZER_TEST_INT", value)
+		message := expectFailure(t, func() { getIntEnv("MATERIALIZER_TEST_INT", 42) })
+		if !strings.Contains(message, "invalid MATERIALIZER_TEST_INT \""+value+"\", expected an integer") {
+			t.Errorf("%q: %s", value, message)
+		}
+	}
+}
+
+/*
+Test default, valid and invalid values of boolean variables
+@param t Test state
+*/
+func TestGetBoolEnv(t *testing.T) {
+	t.Setenv("MATERIALIZER_TEST_BOOL", "")
+	if got := getBoolEnv("MATERIALIZER_TEST_BOOL", true); !got {
+		t.Error("unset: false, want the default true")
+	}
+	for value, want := range map[string]bool{"true": true, "1": true, "TRUE": true, "false": false, "0": false, "f": false} {
+		t.Setenv("MATERIALIZER_TEST_BOOL", value)
+		if got := getBoolEnv("MATERIALIZER_TEST_BOOL", !want); got != want {
+			t.Errorf("%q: %t, want %t", value, got, want)
+		}
+	}
+	for _, value := range []string{"yes", "on", "2"} {
+		t.Setenv("MATERIALIZER_TEST_BOOL", value)
+		message := expectFailure(t, func() { getBoolEnv("MATERIALIZER_TEST_BOOL", false) })
+		if !strings.Contains(message, "expected true or false") {
+			t.Errorf("%q: %s", value, message)
+		}
+	}
+}
+
+/*
+Test default, valid and invalid values of duration variables
+@param t Test state
+*/
+func TestGetDurationEnv(t *testing.T) {
+	t.Setenv("MATERIALIZER_TEST_DURATION", "")
+	if got := getDurationEnv("MATERIALIZER_TEST_DURATION", time.Minute); got != time.Minute {
+		t.Errorf("unset: %s, want the default 1m", got)
+	}
+	for value, want := range map[string]time.Duration{"720h": 720 * time.Hour, "1m30s": 90 * time.Second, "250ms": 250 * time.Millisecond, "0": 0} {
+		t.Setenv("MATERIALIZER_TEST_DURATION", value)
+		if got := getDurationEnv("MATERIALIZER_TEST_DURATION", time.Minute); got != want {
+			t.Errorf("%q: %s, want %s", value, got, want)
+		}
+	}
+	for _, value := range []string{"30", "1 day", "abc"} {
+		t.Setenv("MATERIALIZER_TEST_DURATION", value)
+		message := expectFailure(t, func() { getDurationEnv("MATERIALIZER_TEST_DURATION", time.Minute) })
+		if !strings.Contains(message, "invalid MATERIALIZER_TEST_DURATION") {
+			t.Errorf("%q: %s", value, message)
+		}
+	}
+}
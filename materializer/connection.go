@@ -0,0 +1,217 @@
+package main
+
+/*
+@author 1Zero64
+Connection settings of the Postgres database over TCP or a unix domain socket
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for inspecting errors
+	"errors"
+	// Package for formatted printing
+	"fmt"
+	// Package for parsing URLs
+	"net/url"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for string manipulation
+	"strings"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Backoff before the second connection attempt, doubled after every further attempt
+const startupInitialBackoff = 500 * time.Millisecond
+
+// Maximum backoff between two connection attempts
+const startupMaxBackoff = 10 * time.Second
+
+/*
+Function to build the connection string of the Postgres database from .env variables.
+A DATABASE_URL takes precedence for the primary and for the reader without own DB_READ_ variables,
+a DB_HOST beginning with / is the directory of a unix domain socket
+@param prefix Prefix of the .env variables (DB_ for the primary, DB_READ_ for the read replica falling back to the primary ones)
+@return Connection string for the database driver
+*/
+func connectionString(prefix string) string {
+
+	// Use the complete connection URL of the primary, if given and not overridden for the reader
+	if databaseUrl := getSecretEnv("DATABASE_URL", ""); databaseUrl != "" && (prefix == "DB_" || !hasOwnConnectionSettings(prefix)) {
+		return databaseUrl
+	}
+
+	// Collect the quoted connection parameters, a socket connection doesn't need a port
+	parameters := []string{"host=" + quoteParameter(getEnv(prefix+"HOST", os.Getenv("DB_HOST")))}
+	if port := getEnv(prefix+"PORT", os.Getenv("DB_PORT")); port != "" {
+		parameters = append(parameters, "port="+quoteParameter(port))
+	}
+	parameters = append(parameters,
+		"user="+quoteParameter(getSecretEnv(prefix+"USER", getSecretEnv("DB_USER", ""))),
+		"password="+quoteParameter(getSecretEnv(prefix+"PASSWORD", getSecretEnv("DB_PASSWORD", ""))),
+		"dbname="+quoteParameter(getEnv(prefix+"DATABASE", os.Getenv("DB_DATABASE"))),
+		"sslmode=disable")
+
+	// Join the parameters in key/value form
+	return strings.Join(parameters, " ")
+}
+
+/*
+Function to check whether any connection variable with a prefix is set
+@param prefix Prefix of the .env variables, e.g. DB_READ_
+@return True, if a host, port, user, password or database is set with the prefix
+*/
+func hasOwnConnectionSettings(prefix string) bool {
+	for _, name := range []string{"HOST", "PORT", "USER", "PASSWORD", "DATABASE"} {
+		if getSecretEnv(prefix+name, "") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Function to add a server setting to a connection string, which the drivers send as startup parameter of every connection,
+so it applies to all connections of the pool instead of only the one a SET would run on
+@param connection Connection string in key/value or URL form
+@param name Name of the server setting
+@param value Value of the server setting
+@return Connection string with the setting
+*/
+func withSetting(connection string, name string, value string) string {
+
+	// Add the setting as query parameter of a connection URL
+	if strings.Contains(connection, "://") {
+		parsed, err := url.Parse(connection)
+		if err != nil {
+			checkError(fmt.Errorf("invalid DATABASE_URL: %w", err))
+		}
+		query := parsed.Query()
+		query.Set(name, value)
+		parsed.RawQuery = query.Encode()
+		return parsed.String()
+	}
+
+	// Append the setting in key/value form
+	return connection + " " + name + "=" + quoteParameter(value)
+}
+
+/*
+Function to quote a value of a key/value connection string, so spaces, quotes and backslashes in passwords, users and database names survive
+@param value Value of the connection parameter
+@return Value in single quotes with escaped single quotes and backslashes
+*/
+func quoteParameter(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+/*
+Function to check whether an error is an authentication failure reported by the server, which waiting won't fix
+@param err Error of a connection attempt
+@return True for the SQLSTATE classes of rejected credentials
+*/
+func isAuthenticationError(err error) bool {
+
+	// Both drivers report server errors with their SQLSTATE
+	var serverError interface{ SQLState() string }
+	if !errors.As(err, &serverError) {
+		return false
+	}
+	return serverError.SQLState() == "28P01" || serverError.SQLState() == "28000"
+}
+
+/*
+Function to get the host of the primary database from DATABASE_URL or DB_HOST
+@return Host name, address or socket directory
+*/
+func connectionHost() string {
+
+	// Take the host query parameter or the host of the URL, if given
+	if databaseUrl := getSecretEnv("DATABASE_URL", ""); databaseUrl != "" {
+		parsed, err := url.Parse(databaseUrl)
+		if err != nil {
+			checkError(fmt.Errorf("invalid DATABASE_URL: %w", err))
+		}
+		if host := parsed.Query().Get("host"); host != "" {
+			return host
+		}
+		return parsed.Host
+	}
+	return os.Getenv("DB_HOST")
+}
+
+/*
+Function to check whether the primary database is connected over a unix domain socket
+@return True, if the host is a socket directory
+*/
+func isUnixSocket() bool {
+	return strings.HasPrefix(connectionHost(), "/")
+}
+
+/*
+Function to get the transport of the primary database connection for benchmark metadata
+@return unix socket or tcp
+*/
+func connectionTransport() string {
+	if isUnixSocket() {
+		return "unix socket"
+	}
+	return "tcp"
+}
+
+/*
+Function to describe the primary database connection for the console
+@return Socket directory or host and port
+*/
+func connectionSummary() string {
+
+	// Show the socket directory of a socket connection
+	host := connectionHost()
+	if isUnixSocket() {
+		return "unix socket " + host
+	}
+
+	// Show host and port of a TCP connection (the URL host already contains the port)
+	if getSecretEnv("DATABASE_URL", "") != "" || os.Getenv("DB_PORT") == "" {
+		return host
+	}
+	return host + ":" + os.Getenv("DB_PORT")
+}
+
+/*
+Function to wait for the database to become available by pinging it with backoff up to STARTUP_TIMEOUT,
+because sql.Open only connects lazily and a database starting in parallel isn't reachable yet
+@param db *sql.DB Database handle to ping
+*/
+func waitForDatabase(db *sql.DB) {
+
+	// Ping until the database answers or the timeout is exceeded
+	deadline := time.Now().Add(startupTimeout)
+	backoff := startupInitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.Ping()
+		if err == nil {
+			return
+		}
+
+		// Give up immediately on rejected credentials, the settings could be read and the server answered
+		if isAuthenticationError(err) {
+			checkError(fmt.Errorf("authentication failed, the server rejected the credentials of the configured user: %w", err))
+		}
+
+		// Give up after the timeout with the last error, which is a connection or connection string error
+		if !time.Now().Add(backoff).Before(deadline) {
+			checkError(fmt.Errorf("database not available after %d attempts within STARTUP_TIMEOUT %s (connection or connection settings error): %w", attempt, startupTimeout, err))
+		}
+
+		// Wait with exponential backoff before the next attempt
+		fmt.Printf("Connection attempt %d failed (%v), retrying in %s...\n", attempt, err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > startupMaxBackoff {
+			backoff = startupMaxBackoff
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+/*
+@author 1Zero64
+Row counts of the event store and the materialized view
+*/
+
+// Importing packages
+import (
+	// Package for byte buffers
+	"bytes"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for input and output interfaces
+	"io"
+	// Package for the HTTP server
+	"net/http"
+	// Package for operating system functionality
+	"os"
+	// Package for sorting Slices
+	"sort"
+)
+
+// Name printed for measurements without an event stream
+const nullStreamName = "-"
+
+// Object structure for the row counts of a table
+type TableCounts struct {
+	// Number of rows
+	rows int64
+	// Maximum id (NULL for an empty table)
+	maxId sql.NullInt64
+	// Number of rows per event stream
+	streams map[string]int64
+}
+
+/*
+Function to print the row counts, the maximum ids and the counts per event stream of the event store and the materialized view
+@param db *sql.DB Database connection to Postgres database
+*/
+func showCounts(db *sql.DB) {
+	writeCounts(os.Stdout, readTableCounts(db, "event_store"), readTableCounts(db, "materialized_view"))
+}
+
+/*
+Function to create the handler serving the counts of the event store and the materialized view as text on GET /counts
+@param db *sql.DB Database connection to Postgres database
+@return HTTP handler
+*/
+func countsHandler(db *sql.DB) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+
+		// Answer a failed count with an internal server error instead of a dropped connection
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				http.Error(writer, fmt.Sprint(recovered), http.StatusInternalServerError)
+			}
+		}()
+
+		// Render the counts completely before writing them, so a failure can't leave a partial table
+		var buffer bytes.Buffer
+		writeCounts(&buffer, readTableCounts(db, "event_store"), readTableCounts(db, "materialized_view"))
+		writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		buffer.WriteTo(writer)
+	}
+}
+
+/*
+Function to read the row count, the maximum id and the counts per event stream of a table with the configured filters
+@param db *sql.DB Database connection to Postgres database
+@param table Name of the table to count
+@return Counts of the table
+*/
+func readTableCounts(db *sql.DB, table string) TableCounts {
+
+	// Read total count and maximum id with the same filter as the read of the event store
+	condition, args := eventStoreCondition("")
+	query := fmt.Sprintf("SELECT COUNT(*), MAX(id) FROM %s", table)
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	var counts TableCounts
+	err := db.QueryRow(query, args...).Scan(&counts.rows, &counts.maxId)
+	// Check on error with handler
+	checkError(err)
+
+	// Read counts per event stream
+	counts.streams = countByStream(db, table)
+
+	// Return counts
+	return counts
+}
+
+/*
+Function to write the counts of the event store and the materialized view as aligned tables
+@param writer Destination of the tables
+@param store Counts of the event store
+@param view Counts of the materialized view
+*/
+func writeCounts(writer io.Writer, store TableCounts, view TableCounts) {
+
+	// Write totals as aligned table
+	fmt.Fprintln(writer)
+	fmt.Fprintf(writer, "%-20s %18s %18s %18s\n", "", "event_store", "materialized_view", "Difference")
+	fmt.Fprintf(writer, "%-20s %18d %18d %18d\n", "Rows", store.rows, view.rows, store.rows-view.rows)
+	fmt.Fprintf(writer, "%-20s %18s %18s\n", "Max id", formatNullInt(store.maxId), formatNullInt(view.maxId))
+
+	// Collect all event stream names of both tables in alphabetical order
+	names := make([]string, 0)
+	for name := range store.streams {
+		names = append(names, name)
+	}
+	for name := range view.streams {
+		if _, found := store.streams[name]; !found {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	// Write counts per event stream
+	fmt.Fprintln(writer)
+	fmt.Fprintf(writer, "%-20s %18s %18s %18s\n", "Event stream", "event_store", "materialized_view", "Difference")
+	for _, name := range names {
+		fmt.Fprintf(writer, "%-20s %18d %18d %18d\n", name, store.streams[name], view.streams[name], store.streams[name]-view.streams[name])
+	}
+}
+
+/*
+Function to count the rows of a table per event stream with the configured filters
+@param db *sql.DB Database connection to Postgres database
+@param table Name of the table to count
+@return Number of rows per event stream, rows without an event stream are counted as nullStreamName
+*/
+func countByStream(db *sql.DB, table string) map[string]int64 {
+
+	// Execute grouped count query with the same filter as the read of the event store
+	condition, args := eventStoreCondition("")
+	query := fmt.Sprintf("SELECT event_stream, COUNT(*) FROM %s", table)
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	rows, err := db.Query(query+" GROUP BY event_stream", args...)
+	// Check on error with handler
+	checkError(err)
+
+	// Close rows object later, when surrounding function returns
+	defer rows.Close()
+
+	// Collect counts into a map
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var stream sql.NullString
+		var count int64
+		err = rows.Scan(&stream, &count)
+		checkError(err)
+		if !stream.Valid {
+			stream.String = nullStreamName
+		}
+		counts[stream.String] = count
+	}
+	checkError(rows.Err())
+
+	// Return counts
+	return counts
+}
+
+/*
+Function to format a nullable integer for the console
+@param value Nullable integer
+@return Formatted value or "-" for NULL
+*/
+func formatNullInt(value sql.NullInt64) string {
+	if !value.Valid {
+		return "-"
+	}
+	return fmt.Sprint(value.Int64)
+}
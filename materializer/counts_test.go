@@ -0,0 +1,71 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the row counts of the event store and the materialized view
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for the HTTP status codes
+	"net/http"
+	// Package for recording HTTP responses
+	"net/http/httptest"
+	// Package for string manipulation
+	"strings"
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Test that the counts are written as aligned tables with the streams of both tables and NULL streams
+@param t Test state
+*/
+func TestWriteCounts(t *testing.T) {
+	store := TableCounts{rows: 5, maxId: sql.NullInt64{Int64: 9, Valid: true}, streams: map[string]int64{"b": 3, "a": 1, nullStreamName: 1}}
+	view := TableCounts{rows: 2, streams: map[string]int64{"a": 1, "c": 1}}
+
+	var builder strings.Builder
+	writeCounts(&builder, store, view)
+	lines := strings.Split(builder.String(), "\n")
+
+	want := []string{
+		"",
+		"                            event_store  materialized_view         Difference",
+		"Rows                                  5                  2                  3",
+		"Max id                                9                  -",
+		"",
+		"Event stream                event_store  materialized_view         Difference",
+		"-                                     1                  0                  1",
+		"a                                     1                  1                  0",
+		"b                                     3                  0                  3",
+		"c                                     0                  1                 -1",
+		"",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), builder.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
+
+/*
+Test that a failing count is answered with an internal server error by the /counts handler
+@param t Test state
+*/
+func TestCountsHandlerFailure(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	countsHandler(openStubDatabase(t)).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/counts", nil))
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", recorder.Code, http.StatusInternalServerError)
+	}
+	if !strings.Contains(recorder.Body.String(), "stub driver") {
+		t.Errorf("body = %q, want the error of the count", recorder.Body.String())
+	}
+}
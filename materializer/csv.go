@@ -0,0 +1,288 @@
+package main
+
+/*
+@author 1Zero64
+Reading measurements from and writing transformed measurements to CSV files
+*/
+
+// Importing packages
+import (
+	// Package for reading and writing CSV files
+	"encoding/csv"
+	// Package for formatted printing
+	"fmt"
+	// Package for I/O primitives
+	"io"
+	// Package for pseudo-random numbers
+	"math/rand"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for converting strings to numbers
+	"strconv"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Columns of a measurement CSV file, matching the Measurement fields
+var measurementCsvColumns = []string{"id", "sensor_id", "temperature", "humidity", "event_stream", "created_on", "processed_on"}
+
+/*
+Function to read all measurements from a CSV file with a header row naming the Measurement fields in any order
+@param path Path of the CSV file
+@return Array of all read measurements
+*/
+func readCsvMeasurements(path string) []Measurement {
+
+	// Open CSV file and check on error with handler
+	file, err := os.Open(path)
+	checkError(err)
+
+	// Close file later, when surrounding function returns
+	defer file.Close()
+
+	// Read header row and map column names to their positions
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	checkError(err)
+	positions := make(map[string]int)
+	for position, column := range header {
+		positions[column] = position
+	}
+
+	// Check that all columns are present
+	for _, column := range measurementCsvColumns {
+		if _, found := positions[column]; !found {
+			checkError(fmt.Errorf("%s: missing column %q in header", path, column))
+		}
+	}
+
+	// Initialize an array for measurements
+	measurements := make([]Measurement, 0)
+
+	// Initialize seeded random number generator for reproducible sampling
+	random := rand.New(rand.NewSource(sampleSeed))
+
+	// Iterate through all records of the file
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		// Handle records, that aren't valid CSV (the error already contains the line number)
+		var measurement Measurement
+		if err != nil {
+			handleRowError(measurement, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+
+		// Parse the record into the measurement and handle malformed records with their line number
+		if err = parseCsvMeasurement(record, positions, &measurement); err != nil {
+			line, _ := reader.FieldPos(0)
+			handleRowError(measurement, fmt.Errorf("%s line %d: %w", path, line, err))
+			continue
+		}
+
+		// Skip records probabilistically, if only a sample is materialized
+		if sampleRate < 1 && random.Float64() >= sampleRate {
+			continue
+		}
+
+		// Insert measurement into measurements array
+		measurements = append(measurements, measurement)
+	}
+
+	// Return measurements array
+	return measurements
+}
+
+/*
+Function to parse a CSV record into a measurement
+@param record Fields of the CSV record
+@param positions Positions of the columns in the record
+@param measurement Measurement to set the parsed attributes into
+@return Error of the first field, that couldn't be parsed
+*/
+func parseCsvMeasurement(record []string, positions map[string]int, measurement *Measurement) error {
+
+	// Parse attributes field by field
+	var err error
+	if measurement.id, err = strconv.ParseInt(record[positions["id"]], 10, 64); err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+	if measurement.sensor_id, err = strconv.ParseInt(record[positions["sensor_id"]], 10, 64); err != nil {
+		return fmt.Errorf("invalid sensor_id: %w", err)
+	}
+	temperature, err := parseCsvReading(record[positions["temperature"]])
+	if err != nil {
+		return fmt.Errorf("invalid temperature: %w", err)
+	}
+	humidity, err := parseCsvReading(record[positions["humidity"]])
+	if err != nil {
+		return fmt.Errorf("invalid humidity: %w", err)
+	}
+	measurement.setReadings(temperature, humidity)
+	measurement.event_stream = record[positions["event_stream"]]
+	if measurement.created_on, err = time.Parse(csvTimeLayout, record[positions["created_on"]]); err != nil {
+		return fmt.Errorf("invalid created_on: %w", err)
+	}
+	if measurement.processed_on, err = time.Parse(csvTimeLayout, record[positions["processed_on"]]); err != nil {
+		return fmt.Errorf("invalid processed_on: %w", err)
+	}
+	return nil
+}
+
+/*
+Function to parse a reading of a CSV field, where an empty field is NULL like in the event store
+@param field Field of the CSV record
+@return Reading or nil for an empty field and an error, if the field isn't a number
+*/
+func parseCsvReading(field string) (*reading, error) {
+	if field == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(field, readingBits)
+	if err != nil {
+		return nil, err
+	}
+	parsed := reading(value)
+	return &parsed, nil
+}
+
+// Object structure for a CSV file of transformed measurements
+type TransformedCsvWriter struct {
+	// Underlying output file
+	file *os.File
+	// CSV writer on the file
+	writer *csv.Writer
+	// Id of the last written measurement
+	lastId int64
+	// Flag whether the file was completely written and closed
+	closed bool
+	// Transformed measurements held back to write them by severity on close (only with -sort-severity)
+	held []TransformedMeasurement
+}
+
+/*
+Function to create a CSV file for transformed measurements and write its header row
+@param path Path of the CSV file, an existing file is replaced
+@return Pointer to the CSV writer
+*/
+func newTransformedCsvWriter(path string) *TransformedCsvWriter {
+
+	// Append to the file of an interrupted export, which already has its header row
+	if resumeFromId > 0 {
+		file := openResumedCsvFile(path)
+		return &TransformedCsvWriter{file: file, writer: csv.NewWriter(file), lastId: resumeFromId}
+	}
+
+	// Create file and check on error with handler
+	file, err := os.Create(path)
+	checkError(err)
+
+	// Write header row with the columns of the materialized view
+	writer := csv.NewWriter(file)
+	err = writer.Write([]string{"id", "created_on", "danger", "event_stream", "humidity", "latency", "processed_on", "sensor_id", "temperature", "heat_index", "unknown_sensor", "latency_us"})
+	checkError(err)
+
+	// Return CSV writer
+	return &TransformedCsvWriter{file: file, writer: writer}
+}
+
+/*
+Function to write a transformed measurement as CSV record
+@param transformedMeasurement Transformed measurement to write
+@return Error of the write, if one occured
+*/
+func (output *TransformedCsvWriter) write(transformedMeasurement TransformedMeasurement) error {
+
+	// Hold back the measurement, if the output is ordered by severity, which is only known after the last one
+	if sortSeverity {
+		output.held = append(output.held, transformedMeasurement)
+		return nil
+	}
+	err := output.record(transformedMeasurement)
+	if err == nil {
+		output.lastId = transformedMeasurement.id
+	}
+	return err
+}
+
+/*
+Function to write the CSV record of a transformed measurement into the file
+@param transformedMeasurement Transformed measurement to write
+@return Error of the write, if one occured
+*/
+func (output *TransformedCsvWriter) record(transformedMeasurement TransformedMeasurement) error {
+	return output.writer.Write([]string{
+		strconv.FormatInt(transformedMeasurement.id, 10),
+		transformedMeasurement.created_on.Format(csvTimeLayout),
+		transformedMeasurement.danger,
+		transformedMeasurement.event_stream,
+		strconv.FormatFloat(float64(transformedMeasurement.humidity), 'f', -1, readingBits),
+		strconv.FormatFloat(float64(transformedMeasurement.latency), 'f', -1, 32),
+		transformedMeasurement.processed_on.Format(csvTimeLayout),
+		strconv.FormatInt(transformedMeasurement.sensor_id, 10),
+		strconv.FormatFloat(float64(transformedMeasurement.temperature), 'f', -1, readingBits),
+		formatNullableReading(transformedMeasurement.heat_index),
+		strconv.FormatBool(transformedMeasurement.unknown_sensor),
+		strconv.FormatInt(transformedMeasurement.latency_us, 10),
+	})
+}
+
+/*
+Function to format a nullable reading for a CSV cell, an undefined (NaN or infinite) value leaves the cell empty like NULL
+in the database
+@param value Reading to format
+@return Formatted reading or an empty string
+*/
+func formatNullableReading(value reading) string {
+	if nullableFloat(value) == nil {
+		return ""
+	}
+	return strconv.FormatFloat(float64(value), 'f', -1, readingBits)
+}
+
+/*
+Function to flush and close the CSV file of a failed run and print the last exported id to resume the export from.
+A partially flushed last record is cut off by the resumed export
+*/
+func (output *TransformedCsvWriter) abort() {
+	// Leave a completely written file and a file ordered by severity, which can't be resumed, as it is
+	if output.closed {
+		return
+	}
+	output.writer.Flush()
+	output.file.Close()
+	if err := output.writer.Error(); err != nil {
+		fmt.Printf("Export to %s failed to flush its last records (%v), resume it with the id of its last complete record\n", output.file.Name(), err)
+		return
+	}
+	if !sortSeverity {
+		printResumeHint(output.file.Name(), output.lastId, "failed")
+	}
+}
+
+/*
+Function to flush and close the CSV file
+*/
+func (output *TransformedCsvWriter) close() {
+
+	// Write the held back measurements by severity, the most urgent first
+	if sortSeverity {
+		sortBySeverity(output.held)
+		for _, transformedMeasurement := range output.held {
+			checkError(output.record(transformedMeasurement))
+		}
+		output.held = nil
+	}
+
+	// Flush buffered records and check on error with handler
+	output.writer.Flush()
+	checkError(output.writer.Error())
+
+	// Close file
+	err := output.file.Close()
+	checkError(err)
+	output.closed = true
+}
@@ -0,0 +1,174 @@
+package main
+
+/*
+@author 1Zero64
+Embedded web dashboard with the summary of the last run
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for HTML templates escaping the values
+	"html/template"
+	// Package for the HTTP server
+	"net/http"
+	// Package for synchronization of goroutines
+	"sync"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Summary of the last finished run shown on the dashboard (nil before the first run)
+var lastRunSummary *RunSummary
+
+// Mutex guarding the last run summary between runs and dashboard requests
+var lastRunSummaryMutex sync.Mutex
+
+// Template of the dashboard page without external dependencies
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Materializer</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { padding: 0.3em 1em; text-align: left; border-bottom: 1px solid #ddd; }
+.bar { background: #c0392b; height: 1em; }
+</style>
+</head>
+<body>
+<h1>Materializer</h1>
+{{if .}}
+<h2>Last run {{.RunId}}</h2>
+<table>
+<tr><th>Started</th><td>{{.Start}}</td></tr>
+<tr><th>Duration</th><td>{{.Duration}}</td></tr>
+<tr><th>Measurements</th><td>{{.Measurements}}</td></tr>
+{{if .Stopped}}<tr><th>Stopped early</th><td>{{.Stopped}}</td></tr>{{end}}
+</table>
+<h2>Danger levels</h2>
+<table>
+<tr><th>Level</th><th>Measurements</th><th>Share</th><th></th></tr>
+{{range .Levels}}<tr><td>{{.Name}}</td><td>{{.Count}}</td><td>{{printf "%.2f" .Percent}}%</td><td style="width: 300px"><div class="bar" style="width: {{printf "%.1f" .Percent}}%"></div></td></tr>
+{{end}}</table>
+<h2>Event streams</h2>
+<table>
+<tr><th>Event stream</th><th>Measurements</th><th>Avg latency ({{.LatencyUnit}})</th></tr>
+{{range .Streams}}<tr><td>{{.Name}}</td><td>{{.Count}}</td><td>{{printf "%.3f" .AvgLatency}}</td></tr>
+{{end}}</table>
+{{else}}
+<p>No run finished yet.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// Object structure for a row of the danger level or event stream tables of the dashboard
+type DashboardRow struct {
+	// Name of the danger level or event stream
+	Name string
+	// Number of measurements
+	Count int
+	// Share of all measurements in percent
+	Percent float64
+	// Average latency in the latency unit
+	AvgLatency float64
+}
+
+// Object structure for the values rendered into the dashboard
+type DashboardPage struct {
+	// Unique identifier of the run
+	RunId string
+	// Start of the run in RFC 3339
+	Start string
+	// Duration of the run
+	Duration time.Duration
+	// Number of transformed measurements
+	Measurements int
+	// Reason, why the run was stopped early (empty for a complete run)
+	Stopped string
+	// Rows of the danger level histogram
+	Levels []DashboardRow
+	// Rows of the event stream table
+	Streams []DashboardRow
+	// Unit of the latencies
+	LatencyUnit string
+}
+
+/*
+Function to remember the summary of a finished run for the dashboard
+@param summary Summary of the finished run
+*/
+func publishRunSummary(summary *RunSummary) {
+	lastRunSummaryMutex.Lock()
+	defer lastRunSummaryMutex.Unlock()
+	lastRunSummary = summary
+}
+
+/*
+Function to serve the dashboard on HTTP_PORT in the background, if configured
+@param db *sql.DB Database connection to Postgres database, used for the counts on /counts
+*/
+func startDashboard(db *sql.DB) {
+
+	// The dashboard is disabled without a port
+	if httpPort == "" {
+		return
+	}
+
+	// Render the summary of the last run on GET /
+	http.HandleFunc("/", func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path != "/" {
+			http.NotFound(writer, request)
+			return
+		}
+
+		// Copy the values of the last run into the page
+		lastRunSummaryMutex.Lock()
+		summary := lastRunSummary
+		var page *DashboardPage
+		if summary != nil {
+			page = &DashboardPage{
+				RunId:        summary.runId,
+				Start:        summary.start.Format(time.RFC3339),
+				Duration:     summary.duration.Round(time.Millisecond),
+				Measurements: summary.measurements,
+				LatencyUnit:  latencyUnit.Name,
+			}
+			if summary.stopped != nil {
+				page.Stopped = summary.stopped.Error()
+			}
+			for _, level := range dangerLevels {
+				row := DashboardRow{Name: level, Count: summary.dangerLevels[level]}
+				if summary.measurements > 0 {
+					row.Percent = float64(row.Count) / float64(summary.measurements) * 100
+				}
+				page.Levels = append(page.Levels, row)
+			}
+			for _, name := range summary.streamNames() {
+				stream := summary.streams[name]
+				page.Streams = append(page.Streams, DashboardRow{Name: name, Count: stream.measurements, AvgLatency: stream.latencySum / float64(stream.measurements)})
+			}
+		}
+		lastRunSummaryMutex.Unlock()
+
+		// Render the page
+		writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := dashboardTemplate.Execute(writer, page); err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	// Serve the counts of the event store and the materialized view on GET /counts
+	http.HandleFunc("/counts", countsHandler(db))
+
+	// Serve in the background, the interactive menu keeps running in the foreground
+	go func() {
+		checkError(http.ListenAndServe(":"+httpPort, nil))
+	}()
+	fmt.Printf("Dashboard available on http://localhost:%s/, counts on http://localhost:%s/counts\n", httpPort, httpPort)
+}
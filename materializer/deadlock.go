@@ -0,0 +1,50 @@
+package main
+
+/*
+@author 1Zero64
+Retry of writes failing with a transient deadlock between concurrent writers
+*/
+
+// Importing packages
+import (
+	// Package for inspecting errors
+	"errors"
+	// Package for atomic counters shared by the concurrent writes
+	"sync/atomic"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+/*
+Function to check whether an error is a deadlock detected by the server (SQLSTATE 40P01)
+@param err Error of a statement
+@return True for deadlocks, which are transient and can be retried
+*/
+func isDeadlock(err error) bool {
+
+	// Both drivers report server errors with their SQLSTATE
+	var serverError interface{ SQLState() string }
+	return errors.As(err, &serverError) && serverError.SQLState() == "40P01"
+}
+
+/*
+Function to execute a write and retry it up to DEADLOCK_RETRIES times with a linearly growing DEADLOCK_BACKOFF,
+if it fails with a deadlock. Other errors are returned immediately
+@param write Write to execute, which has to roll back completely on failure (a single statement or transaction)
+@param retried Counter of writes, that had to be retried at least once
+@return Error of the last attempt
+*/
+func retryOnDeadlock(write func() error, retried *atomic.Int64) error {
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if err == nil || !isDeadlock(err) || attempt > deadlockRetries {
+			return err
+		}
+
+		// Count the write once and wait before the next attempt
+		if attempt == 1 {
+			retried.Add(1)
+		}
+		time.Sleep(deadlockBackoff * time.Duration(attempt))
+	}
+}
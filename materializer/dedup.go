@@ -0,0 +1,101 @@
+package main
+
+/*
+@author 1Zero64
+Deduplication of replayed measurements sharing sensor and creation timestamp
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Enumerations for the deduplication policies
+const (
+	DedupLatest = "latest"
+	DedupFirst  = "first"
+)
+
+// Object structure for the key identifying semantically duplicate measurements
+type DedupKey struct {
+	// Id of the sensor
+	sensorId int64
+	// Creation timestamp of the measurement
+	createdOn time.Time
+}
+
+/*
+Function to get the ORDER BY of the DISTINCT ON read, which keeps the first row of every (sensor_id, created_on) group
+@return Order of the rows within a group by the deduplication policy
+*/
+func dedupOrder() string {
+	if dedupPolicy == DedupFirst {
+		return "sensor_id, created_on, processed_on, id"
+	}
+	return "sensor_id, created_on, processed_on DESC, id DESC"
+}
+
+/*
+Function to check whether a measurement replaces the kept one of its group by the deduplication policy
+@param candidate Measurement to check
+@param kept Measurement kept so far
+@return True, if the candidate is kept instead
+*/
+func replacesDuplicate(candidate Measurement, kept Measurement) bool {
+	if candidate.processed_on.Equal(kept.processed_on) {
+		return (dedupPolicy == DedupFirst) == (candidate.id < kept.id)
+	}
+	return (dedupPolicy == DedupFirst) == candidate.processed_on.Before(kept.processed_on)
+}
+
+/*
+Function to collapse measurements sharing (sensor_id, created_on) to one by the deduplication policy in a single pass.
+The kept measurements stay at the position of the first measurement of their group
+@param measurements Measurements to deduplicate
+@return Deduplicated measurements and the number of collapsed duplicates
+*/
+func deduplicateMeasurements(measurements []Measurement) ([]Measurement, int) {
+
+	// Remember the position of the kept measurement of every group
+	positions := make(map[DedupKey]int, len(measurements))
+	deduplicated := make([]Measurement, 0, len(measurements))
+	for _, measurement := range measurements {
+		key := DedupKey{sensorId: measurement.sensor_id, createdOn: measurement.created_on}
+		if position, found := positions[key]; found {
+			if replacesDuplicate(measurement, deduplicated[position]) {
+				deduplicated[position] = measurement
+			}
+			continue
+		}
+		positions[key] = len(deduplicated)
+		deduplicated = append(deduplicated, measurement)
+	}
+
+	// Return kept measurements and number of collapsed ones
+	return deduplicated, len(measurements) - len(deduplicated)
+}
+
+/*
+Function to count the measurements of the event store, that the deduplication collapses
+@param db *sql.DB Database connection to Postgres database
+@param condition Optional condition of the WHERE clause to filter measurements (empty to read all)
+@param args Arguments for the placeholders of the condition
+@return Number of collapsed duplicates
+*/
+func countDuplicates(db *sql.DB, condition string, args ...interface{}) int {
+
+	// Count rows minus distinct groups with the same filters as the read
+	condition, args = eventStoreCondition(condition, args...)
+	query := "SELECT COUNT(*) - COUNT(DISTINCT (sensor_id, created_on)) FROM event_store"
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	var duplicates int
+	err := readHandle(db).QueryRow(query, args...).Scan(&duplicates)
+	// Check on error with handler
+	checkError(err)
+	return duplicates
+}
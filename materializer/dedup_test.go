@@ -0,0 +1,109 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the deduplication of replayed measurements
+*/
+
+// Importing packages
+import (
+	// Package for automated tests
+	"testing"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+/*
+Function to create a measurement with the fields of the deduplication key and the tie-breakers
+@param id Id of the measurement
+@param sensor Id of the sensor
+@param createdOn Seconds of the creation after a fixed time point
+@param processedOn Seconds of the processing after a fixed time point
+@return Measurement
+*/
+func dedupMeasurement(id int64, sensor int64, createdOn int, processedOn int) Measurement {
+	start := time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC)
+	return Measurement{id: id, sensor_id: sensor, created_on: start.Add(time.Duration(createdOn) * time.Second), processed_on: start.Add(time.Duration(processedOn) * time.Second)}
+}
+
+/*
+Test the tie-breaking of two duplicates by processed_on and, for equal processed_on, by id
+@param t Test state
+*/
+func TestReplacesDuplicate(t *testing.T) {
+	saved := dedupPolicy
+	defer func() { dedupPolicy = saved }()
+
+	cases := []struct {
+		name            string
+		candidate, kept Measurement
+		// Expected result with the policies latest and first
+		latest, first bool
+	}{
+		{"later processed_on", dedupMeasurement(1, 7, 0, 5), dedupMeasurement(2, 7, 0, 3), true, false},
+		{"earlier processed_on", dedupMeasurement(3, 7, 0, 1), dedupMeasurement(2, 7, 0, 3), false, true},
+		{"equal processed_on, greater id", dedupMeasurement(4, 7, 0, 3), dedupMeasurement(2, 7, 0, 3), true, false},
+		{"equal processed_on, smaller id", dedupMeasurement(1, 7, 0, 3), dedupMeasurement(2, 7, 0, 3), false, true},
+	}
+	for _, testCase := range cases {
+		dedupPolicy = DedupLatest
+		if got := replacesDuplicate(testCase.candidate, testCase.kept); got != testCase.latest {
+			t.Errorf("%s with latest: %t, want %t", testCase.name, got, testCase.latest)
+		}
+		dedupPolicy = DedupFirst
+		if got := replacesDuplicate(testCase.candidate, testCase.kept); got != testCase.first {
+			t.Errorf("%s with first: %t, want %t", testCase.name, got, testCase.first)
+		}
+	}
+}
+
+/*
+Test the collapsing of adjacent duplicates and of duplicates, which arrive in different read pages
+@param t Test state
+*/
+func TestDeduplicateMeasurements(t *testing.T) {
+	saved := dedupPolicy
+	defer func() { dedupPolicy = saved }()
+
+	// First page of the read, ending with the replayed measurement 4 of the group of 1
+	firstPage := []Measurement{
+		dedupMeasurement(1, 7, 0, 1),
+		dedupMeasurement(2, 7, 0, 2),
+		dedupMeasurement(3, 8, 0, 1),
+		dedupMeasurement(4, 7, 60, 61),
+	}
+	// Second page with a later replay of measurement 4 and a duplicate of 3 with the same processed_on
+	secondPage := []Measurement{
+		dedupMeasurement(5, 9, 0, 1),
+		dedupMeasurement(6, 7, 60, 65),
+		dedupMeasurement(7, 8, 0, 1),
+	}
+	measurements := append(append([]Measurement(nil), firstPage...), secondPage...)
+
+	cases := []struct {
+		policy string
+		// Ids of the kept measurements in the order of their groups
+		want []int64
+	}{
+		{DedupLatest, []int64{2, 7, 6, 5}},
+		{DedupFirst, []int64{1, 3, 4, 5}},
+	}
+	for _, testCase := range cases {
+		dedupPolicy = testCase.policy
+		deduplicated, collapsed := deduplicateMeasurements(measurements)
+		if collapsed != 3 || len(deduplicated) != len(testCase.want) {
+			t.Errorf("%s: %d kept and %d collapsed, want %d and 3", testCase.policy, len(deduplicated), collapsed, len(testCase.want))
+			continue
+		}
+		for i, id := range testCase.want {
+			if deduplicated[i].id != id {
+				t.Errorf("%s: kept measurement %d has id %d, want %d", testCase.policy, i, deduplicated[i].id, id)
+			}
+		}
+	}
+
+	// Measurements of different sensors or creation timestamps aren't duplicates
+	if _, collapsed := deduplicateMeasurements(secondPage); collapsed != 0 {
+		t.Errorf("%d collapsed of distinct measurements, want 0", collapsed)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+/*
+@author 1Zero64
+Consistency report between the event store and the materialized view
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+)
+
+// Number of sample ids to print per difference
+const diffSampleSize = 10
+
+/*
+Function to report the measurements of the event store missing in the materialized view
+and the rows of the materialized view without source measurement (orphans)
+@param db *sql.DB Database connection to Postgres database
+*/
+func diffReport(db *sql.DB) {
+
+	// Compare ids in both directions
+	missingCount, missingSample := diffIds(db, "SELECT id FROM event_store EXCEPT SELECT id FROM materialized_view")
+	orphanCount, orphanSample := diffIds(db, "SELECT id FROM materialized_view EXCEPT SELECT id FROM event_store")
+
+	// Print counts and samples
+	fmt.Println()
+	fmt.Printf("Missing in materialized_view: %d measurements", missingCount)
+	if missingCount > 0 {
+		fmt.Printf(", e.g. ids %v", missingSample)
+	}
+	fmt.Println()
+	fmt.Printf("Orphans in materialized_view: %d rows", orphanCount)
+	if orphanCount > 0 {
+		fmt.Printf(", e.g. ids %v", orphanSample)
+	}
+	fmt.Println()
+
+	// Print overall result
+	if missingCount == 0 && orphanCount == 0 {
+		fmt.Println("The materialized view is consistent with the event store")
+	}
+}
+
+/*
+Function to count the ids of a difference query and collect a sample of them
+@param db *sql.DB Database connection to Postgres database
+@param query EXCEPT query selecting the differing ids
+@return Number of differing ids and a sample of the smallest ones
+*/
+func diffIds(db *sql.DB, query string) (int64, []int64) {
+
+	// Count differing ids
+	var count int64
+	err := db.QueryRow("SELECT COUNT(*) FROM (" + query + ") AS difference").Scan(&count)
+	checkError(err)
+
+	// Select sample of the smallest differing ids
+	rows, err := db.Query(fmt.Sprintf("SELECT id FROM (%s) AS difference ORDER BY id LIMIT %d", query, diffSampleSize))
+	checkError(err)
+
+	// Close rows object later, when surrounding function returns
+	defer rows.Close()
+
+	// Collect sample ids
+	sample := make([]int64, 0, diffSampleSize)
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		checkError(err)
+		sample = append(sample, id)
+	}
+	checkError(rows.Err())
+
+	// Return count and sample
+	return count, sample
+}
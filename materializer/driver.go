@@ -0,0 +1,250 @@
+package main
+
+/*
+@author 1Zero64
+Selection of the Postgres database driver and benchmark comparing the drivers
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for synchronizing the registered data sources
+	"sync"
+	// Package for measuring and displaying time values
+	"time"
+
+	// Package to use PostgreSQL database with the pgx driver
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Enumerations for the database drivers
+const (
+	DriverPq  = "postgres"
+	DriverPgx = "pgx"
+)
+
+// Object structure for the driver and connection string a connection pool was opened with
+type DataSource struct {
+	// Name of the database driver
+	driver string
+	// Connection string of the database
+	dsn string
+}
+
+// Data sources of the opened connection pools, so sessions outside of a capped pool can be opened to the same database
+var dataSources = make(map[*sql.DB]DataSource)
+var dataSourcesMutex sync.Mutex
+
+// Object structure for the benchmark result of a single database driver
+type DriverBenchmarkResult struct {
+	// Name of the database driver
+	driver string
+	// Number of materialized measurements
+	measurements int
+	// Mean duration of the iterations in seconds
+	meanDuration float64
+	// Median duration of the iterations in seconds
+	medianDuration float64
+	// Throughput in measurements per second based on the mean duration
+	throughput float64
+	// MD5 checksum of the materialized view contents after the last iteration
+	checksum string
+}
+
+/*
+Function to open the Postgres database with the given driver and the database information from .env variables
+@param driver Name of the database driver
+@param prefix Prefix of the .env variables (DB_ for the primary, DB_READ_ for the read replica falling back to the primary ones)
+@return Database handle
+*/
+func openDatabase(driver string, prefix string) *sql.DB {
+
+	// Build connection string to Postgres database with the database information from .env variables
+	psqlconn := connectionString(prefix)
+
+	// Open database and check on error with handler
+	db, err := sql.Open(driver, psqlconn)
+	checkError(err)
+	registerDataSource(db, driver, psqlconn)
+
+	// Limit the connection pool, if configured
+	db.SetMaxOpenConns(dbMaxOpenConnections)
+
+	// Return database handle
+	return db
+}
+
+/*
+Function to remember the driver and connection string of an opened connection pool
+@param db Database handle
+@param driver Name of the database driver
+@param dsn Connection string of the database
+*/
+func registerDataSource(db *sql.DB, driver string, dsn string) {
+	dataSourcesMutex.Lock()
+	defer dataSourcesMutex.Unlock()
+	dataSources[db] = DataSource{driver: driver, dsn: dsn}
+}
+
+/*
+Function to open a separate pool of a single connection to the database of an opened connection pool, which doesn't count
+against DB_MAX_OPEN_CONNS of the pool
+@param db Database handle
+@return Separate database handle or nil, if the pool wasn't opened by openDatabase
+*/
+func openSeparatePool(db *sql.DB) *sql.DB {
+	dataSourcesMutex.Lock()
+	source, found := dataSources[db]
+	dataSourcesMutex.Unlock()
+	if !found {
+		return nil
+	}
+
+	// Open database and check on error with handler
+	separate, err := sql.Open(source.driver, source.dsn)
+	checkError(err)
+	separate.SetMaxOpenConns(1)
+	return separate
+}
+
+/*
+Function to open the primary database for a benchmark, with asynchronous commits, if the unsafe benchmark setting is enabled
+@param driver Name of the database driver
+@return Database handle
+*/
+func openBenchmarkDatabase(driver string) *sql.DB {
+
+	// Open the primary database as usual without the unsafe benchmark setting
+	if !benchmarkSynchronousCommitOff {
+		return openDatabase(driver, "DB_")
+	}
+
+	// Open database without waiting for the WAL flush on commit and check on error with handler
+	psqlconn := withSetting(connectionString("DB_"), "synchronous_commit", "off")
+	db, err := sql.Open(driver, psqlconn)
+	checkError(err)
+	registerDataSource(db, driver, psqlconn)
+
+	// Limit the connection pool, if configured
+	db.SetMaxOpenConns(dbMaxOpenConnections)
+
+	// Return database handle
+	return db
+}
+
+/*
+Function to get the effective synchronous_commit setting of the database connections
+@param db *sql.DB Database connection to Postgres database
+@return Value of synchronous_commit
+*/
+func synchronousCommit(db *sql.DB) string {
+	var setting string
+	err := db.QueryRow("SHOW synchronous_commit").Scan(&setting)
+	// Check on error with handler
+	checkError(err)
+	return setting
+}
+
+/*
+Function to print the selected driver, its SQL dialect and placeholder style and a sample rendered insert statement
+*/
+func printDialect() {
+
+	// Describe the driver package behind the driver name
+	driverPackage := "github.com/lib/pq"
+	if dbDriver == DriverPgx {
+		driverPackage = "github.com/jackc/pgx/v5/stdlib"
+	}
+
+	fmt.Printf("Driver:\t\t\t%s (%s)\n", dbDriver, driverPackage)
+	fmt.Printf("SQL dialect:\t\tPostgreSQL\n")
+	fmt.Printf("Placeholder style:\t$1, $2, ... (numbered)\n")
+	fmt.Printf("Sample insert:\t\t%s\n", insertStatement("materialized_view"))
+}
+
+/*
+Function to calculate a checksum of the materialized view contents to compare the results of different runs
+@param db *sql.DB Database connection to Postgres database
+@return MD5 checksum of all rows ordered by id
+*/
+func materializedViewChecksum(db *sql.DB) string {
+
+	// Aggregate the text representation of all rows in a stable order and hash it
+	var checksum sql.NullString
+	err := db.QueryRow("SELECT md5(string_agg(view::text, E'\\n' ORDER BY id)) FROM materialized_view view").Scan(&checksum)
+	// Check on error with handler
+	checkError(err)
+	return checksum.String
+}
+
+/*
+Function to execute the same materialize workload under both database drivers back to back and print the comparison
+@param iterations Number of iterations per driver
+*/
+func driverMicrobenchmark(iterations int) {
+
+	// Generate unique identifier of the benchmark run, shared by all iterations
+	runId := newRunId()
+
+	// Print information about starting the test
+	fmt.Printf("Starting driver comparison (run %s)...\n", runId)
+	if benchmarkSynchronousCommitOff {
+		fmt.Println("Warning: UNSAFE benchmark setting synchronous_commit=off, commits don't wait for the WAL flush to disk")
+	}
+
+	// Benchmark every driver with its own connection pool
+	results := make([]DriverBenchmarkResult, 0, 2)
+	for _, driver := range []string{DriverPq, DriverPgx} {
+		db := openBenchmarkDatabase(driver)
+
+		// Run the iterations and collect their durations
+		result := DriverBenchmarkResult{driver: driver}
+		durations := make([]float64, 0, iterations)
+		for i := 0; i < iterations; i++ {
+			start := time.Now()
+			summary := materialize(context.Background(), db, runId, nil)
+			elapsed := time.Since(start)
+			if !benchmarkIncludeClean {
+				elapsed -= summary.cleanDuration
+			}
+			result.measurements = summary.measurements
+			durations = append(durations, elapsed.Seconds())
+			fmt.Printf("%s: iteration %d/%d finished\n", driver, i+1, iterations)
+		}
+
+		// Calculate mean, median and throughput and remember the written contents
+		result.meanDuration = mean(durations)
+		result.medianDuration = median(durations)
+		if result.meanDuration > 0 {
+			result.throughput = float64(result.measurements) / result.meanDuration
+		}
+		result.checksum = materializedViewChecksum(db)
+		results = append(results, result)
+
+		// Close the connection pool of the driver
+		checkError(db.Close())
+	}
+
+	// Print comparison table
+	fmt.Print("Driver comparison finished\n\n")
+	fmt.Printf("Run id: %s, %d iterations per driver\n", runId, iterations)
+	if benchmarkSynchronousCommitOff {
+		fmt.Println("Synchronous commit: off (UNSAFE benchmark setting)")
+	}
+	fmt.Printf("%-10s %12s %18s %18s %22s %34s\n", "Driver", "Measurements", "Mean (seconds)", "Median (seconds)", "Throughput (rows/s)", "View checksum")
+	for _, result := range results {
+		fmt.Printf("%-10s %12d %18f %18f %22f %34s\n", result.driver, result.measurements, result.meanDuration, result.medianDuration, result.throughput, result.checksum)
+	}
+
+	// Report whether both drivers wrote identical view contents
+	if results[0].checksum == results[1].checksum {
+		fmt.Println("Both drivers produced identical materialized view contents")
+	} else {
+		fmt.Println("Warning: the drivers produced different materialized view contents")
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the pq and pgx database drivers against each other on a Postgres database given by TEST_DATABASE_URL
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package for automated tests
+	"testing"
+)
+
+// Fixture of the pushdown test with an infinite temperature, which is clamped and leaves the heat index NULL
+const driverFixture = pushdownFixture + `,
+	(10, '2024-01-05 10:00:09', 'kafka', 45, '2024-01-05 10:00:09.5', 11, 'Infinity')`
+
+/*
+Test that the pq and pgx drivers write the same rows for the same fixture, including the floats, the fractional
+timestamps and a NULL heat index
+@param t Test state
+*/
+func TestDriversWriteSameRows(t *testing.T) {
+	useDatabaseRun(t)
+	nonFinitePolicy = NonFiniteClamp
+
+	drivers := [2]string{DriverPq, DriverPgx}
+	var dumps [2][][]string
+	for i, driver := range drivers {
+		db := openTestDatabaseWithDriver(t, driver)
+		createTestEventStore(t, db, driverFixture)
+		captureStdout(t, func() { materialize(context.Background(), db, "test", nil) })
+		dumps[i] = dumpMaterializedView(t, db)
+	}
+	if len(dumps[0]) != 10 {
+		t.Fatalf("%s wrote %d rows, want all 10 of the fixture", drivers[0], len(dumps[0]))
+	}
+
+	// The clamped row has to cover the NULL heat index for the comparison
+	heatIndexColumn := 10
+	if got := dumps[0][9][heatIndexColumn]; got != "NULL" {
+		t.Errorf("heat index of the clamped row %s, want NULL", got)
+	}
+	compareDumps(t, drivers, dumps)
+}
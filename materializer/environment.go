@@ -0,0 +1,104 @@
+package main
+
+/*
+@author 1Zero64
+Selection of the environment specific .env file and protection of environments against destructive operations
+*/
+
+// Importing packages
+import (
+	// Package for inspecting errors
+	"errors"
+	// Package for formatted printing
+	"fmt"
+	// Package for file system interfaces
+	"io/fs"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for string manipulation
+	"strings"
+
+	// Package for .env functionality
+	"github.com/joho/godotenv"
+)
+
+// Name of the selected environment (empty for the plain .env file)
+var appEnv string
+
+// Flag whether the selected environment is protected against destructive operations
+var protectedEnvironment bool
+
+// Flag whether destructive operations run against a protected environment anyway
+var force bool
+
+/*
+Function to get the environment selected with the --env flag or the APP_ENV variable.
+The flag is looked up in the arguments directly, because the .env files have to be loaded before the flags are parsed
+@return Name of the environment (empty for none)
+*/
+func selectedEnvironment() string {
+
+	// Take the value of an -env/--env flag in the forms -env=name and -env name
+	for i, argument := range os.Args[1:] {
+		for _, prefix := range []string{"-env", "--env"} {
+			if argument == prefix && i+2 < len(os.Args) {
+				return os.Args[i+2]
+			}
+			if strings.HasPrefix(argument, prefix+"=") {
+				return strings.TrimPrefix(argument, prefix+"=")
+			}
+		}
+	}
+
+	// Fall back to the APP_ENV variable
+	return os.Getenv("APP_ENV")
+}
+
+/*
+Function to load the .env file of the selected environment with fallback to .env, also if the environment file is missing.
+Process variables take precedence over .env.<name>, which takes precedence over .env
+*/
+func loadEnvironment() {
+
+	// Load the plain .env file without a selected environment
+	appEnv = selectedEnvironment()
+	if appEnv == "" {
+		// Load .env variables and check on error with handler
+		checkError(godotenv.Load())
+		return
+	}
+
+	// Load the environment file first, as godotenv never overrides variables, so it takes precedence over .env
+	environmentFile := ".env." + appEnv
+	var loaded []string
+	if err := godotenv.Load(environmentFile); err == nil {
+		loaded = append(loaded, environmentFile)
+	} else if errors.Is(err, fs.ErrNotExist) {
+		fmt.Printf("Warning: %s not found, falling back to .env\n", environmentFile)
+	} else {
+		checkError(err)
+	}
+
+	// Fall back to .env for the remaining variables, which is required without the environment file
+	if err := godotenv.Load(); err == nil {
+		loaded = append(loaded, ".env")
+	} else if len(loaded) == 0 || !errors.Is(err, fs.ErrNotExist) {
+		checkError(err)
+	}
+
+	// Print selected environment, loaded files and the targeted database, unless a healthcheck prints only its result
+	if healthcheckRequested() {
+		return
+	}
+	fmt.Printf("Environment: %s (loaded %s), database host %s\n", appEnv, strings.Join(loaded, ", "), connectionSummary())
+}
+
+/*
+Function to refuse a destructive operation against a protected environment, unless --force is given
+@param operation Description of the destructive operation
+*/
+func requireUnprotected(operation string) {
+	if protectedEnvironment && !force {
+		checkError(fmt.Errorf("refusing to %s: environment %q is PROTECTED, use --force to run it anyway", operation, appEnv))
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the precedence of the environment specific .env files and of the protected environments
+*/
+
+// Importing packages
+import (
+	// Package with interface to operating system functionality
+	"os"
+	// Package for manipulating file paths
+	"path/filepath"
+	// Package for string manipulation
+	"strings"
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Function to change into a directory with the given .env files and to select an environment. The variables of the files
+are unset before and restored after the test, as godotenv sets them in the process
+@param t Test state
+@param environment Name of the selected environment (empty for none)
+@param files Content of the .env files by their name
+*/
+func useEnvironmentFiles(t *testing.T, environment string, files map[string]string) {
+	directory := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	working, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(directory); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(working) })
+
+	// Register the variables for restoring and unset them
+	for _, name := range []string{"MATERIALIZER_TEST_FILE", "MATERIALIZER_TEST_SHARED"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	t.Setenv("APP_ENV", environment)
+	savedEnv := appEnv
+	t.Cleanup(func() { appEnv = savedEnv })
+}
+
+/*
+Test that the selected .env.<name> takes precedence over .env, which fills the remaining variables
+@param t Test state
+*/
+func TestLoadEnvironmentFileBeatsDotEnv(t *testing.T) {
+	useEnvironmentFiles(t, "bench", map[string]string{
+		".env":       "MATERIALIZER_TEST_FILE=dotenv\nMATERIALIZER_TEST_SHARED=dotenv\n",
+		".env.bench": "MATERIALIZER_TEST_FILE=bench\n",
+	})
+	captureStdout(t, loadEnvironment)
+
+	if got := os.Getenv("MATERIALIZER_TEST_FILE"); got != "bench" {
+		t.Errorf("variable of both files = %q, want the one of .env.bench", got)
+	}
+	if got := os.Getenv("MATERIALIZER_TEST_SHARED"); got != "dotenv" {
+		t.Errorf("variable only in .env = %q, want it filled from .env", got)
+	}
+}
+
+/*
+Test that a variable of the process takes precedence over both files
+@param t Test state
+*/
+func TestLoadEnvironmentProcessBeatsFiles(t *testing.T) {
+	useEnvironmentFiles(t, "bench", map[string]string{
+		".env":       "MATERIALIZER_TEST_FILE=dotenv\n",
+		".env.bench": "MATERIALIZER_TEST_FILE=bench\n",
+	})
+	os.Setenv("MATERIALIZER_TEST_FILE", "process")
+	captureStdout(t, loadEnvironment)
+
+	if got := os.Getenv("MATERIALIZER_TEST_FILE"); got != "process" {
+		t.Errorf("variable of the process = %q, want it kept", got)
+	}
+}
+
+/*
+Test the fallback to .env with a warning, if the file of the selected environment is missing
+@param t Test state
+*/
+func TestLoadEnvironmentFallsBackToDotEnv(t *testing.T) {
+	useEnvironmentFiles(t, "prod", map[string]string{
+		".env": "MATERIALIZER_TEST_FILE=dotenv\n",
+	})
+	output := captureStdout(t, loadEnvironment)
+
+	if got := os.Getenv("MATERIALIZER_TEST_FILE"); got != "dotenv" {
+		t.Errorf("variable = %q, want the one of .env", got)
+	}
+	if !strings.Contains(output, ".env.prod not found") || !strings.Contains(output, "loaded .env)") {
+		t.Errorf("output %q doesn't name the missing and the loaded file", output)
+	}
+
+	// Without any of both files the configuration can't be loaded
+	useEnvironmentFiles(t, "prod", nil)
+	expectFailure(t, func() { captureStdout(t, loadEnvironment) })
+}
+
+/*
+Test that clean and purge are refused in a protected environment, unless forced
+@param t Test state
+*/
+func TestRequireUnprotectedRefusesWithoutForce(t *testing.T) {
+	savedProtected, savedForce, savedRetention := protectedEnvironment, force, retention
+	defer func() { protectedEnvironment, force, retention = savedProtected, savedForce, savedRetention }()
+	protectedEnvironment, force, retention = true, false, 1
+	db := openStubDatabase(t)
+
+	// The refusal comes before any statement reaches the database
+	for name, operation := range map[string]func(){
+		"clean": func() { cleanMaterializedView(db) },
+		"purge": func() { purgeMaterializedView(db, false) },
+	} {
+		if message := expectFailure(t, operation); !strings.Contains(message, "PROTECTED, use --force") {
+			t.Errorf("%s in a protected environment: %s, want a refusal", name, message)
+		}
+	}
+
+	// Forced or unprotected operations pass the check
+	force = true
+	requireUnprotected("clean the materialized view")
+	protectedEnvironment, force = false, false
+	requireUnprotected("clean the materialized view")
+}
@@ -0,0 +1,72 @@
+package main
+
+/*
+@author 1Zero64
+Estimate of the run duration from the per-row cost of the last run
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Name of the persisted per-row cost of the last complete run in seconds
+const perRowCostStat = "per_row_cost_seconds"
+
+/*
+Function to store a statistic of a run, so later runs can use it
+@param db *sql.DB Database connection to Postgres database
+@param name Name of the statistic
+@param value Value of the statistic
+*/
+func storeRunStat(db *sql.DB, name string, value float64) {
+	_, err := db.Exec("INSERT INTO materializer_stats (name, value, updated_on) VALUES ($1, $2, NOW()) ON CONFLICT (name) DO UPDATE SET value = EXCLUDED.value, updated_on = EXCLUDED.updated_on", name, value)
+	// Check on error with handler
+	checkError(err)
+}
+
+/*
+Function to load a statistic stored by an earlier run
+@param db *sql.DB Database connection to Postgres database
+@param name Name of the statistic
+@return Value of the statistic and whether it was stored before
+*/
+func loadRunStat(db *sql.DB, name string) (float64, bool) {
+	var value float64
+	err := db.QueryRow("SELECT value FROM materializer_stats WHERE name = $1", name).Scan(&value)
+	if err == sql.ErrNoRows {
+		return 0, false
+	}
+	// Check on error with handler
+	checkError(err)
+	return value, true
+}
+
+/*
+Function to print the estimated duration of a full rebuild from the row count of the event store and the per-row cost of the last run
+@param db *sql.DB Database connection to Postgres database
+*/
+func printRunEstimate(db *sql.DB) {
+
+	// Count the measurements of the event store
+	var rows int64
+	err := readHandle(db).QueryRow("SELECT COUNT(*) FROM event_store").Scan(&rows)
+	// Check on error with handler
+	checkError(err)
+
+	// Without a complete run before there is no per-row cost to estimate with
+	cost, ok := loadRunStat(db, perRowCostStat)
+	if !ok {
+		fmt.Printf("No estimate for %d rows yet, the per-row cost is stored after the first complete run\n", rows)
+		return
+	}
+
+	// Print the estimate rounded to seconds
+	estimate := time.Duration(cost * float64(rows) * float64(time.Second)).Round(time.Second)
+	fmt.Printf("Estimated ~%.0f seconds for %d rows (%s per row in the last run)\n", estimate.Seconds(), rows, time.Duration(cost*float64(time.Second)))
+}
@@ -0,0 +1,170 @@
+package main
+
+/*
+@author 1Zero64
+Query plans of the event store read and a representative insert into the view, captured once per microbenchmark outside the timed iterations
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for encoding JSON
+	"encoding/json"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for manipulating file paths
+	"path/filepath"
+	// Package for string manipulation
+	"strings"
+)
+
+// Object structure for the captured plan of a query
+type QueryPlan struct {
+	// Name of the query, read or insert
+	Name string `json:"name"`
+	// Explained statement
+	Query string `json:"query"`
+	// Plan in the JSON format of EXPLAIN (omitted, if truncated)
+	Plan json.RawMessage `json:"plan,omitempty"`
+	// First BENCHMARK_EXPLAIN_LIMIT bytes of a larger plan (omitted, if complete)
+	TruncatedPlan string `json:"truncated_plan,omitempty"`
+	// Size of the complete plan in bytes
+	Bytes int `json:"bytes"`
+	// Note on a truncated plan
+	Note string `json:"note,omitempty"`
+}
+
+/*
+Function to execute EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) for a statement
+@param queryer Database connection or transaction to execute the statement on
+@param name Name of the query
+@param query Statement to explain
+@param args Arguments for the placeholders of the statement
+@return Captured plan, truncated to BENCHMARK_EXPLAIN_LIMIT bytes
+*/
+func explainQuery(queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}, name string, query string, args ...interface{}) QueryPlan {
+
+	// Execute the statement with the plan as single JSON value
+	var plan string
+	err := queryer.QueryRow("EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) "+query, args...).Scan(&plan)
+	checkError(err)
+
+	// Keep only the first bytes of a large plan, which isn't valid JSON anymore
+	captured := QueryPlan{Name: name, Query: query, Bytes: len(plan)}
+	if benchmarkExplainLimit > 0 && len(plan) > benchmarkExplainLimit {
+		captured.TruncatedPlan = plan[:benchmarkExplainLimit]
+		captured.Note = fmt.Sprintf("plan truncated from %d to %d bytes (BENCHMARK_EXPLAIN_LIMIT)", len(plan), benchmarkExplainLimit)
+	} else {
+		captured.Plan = json.RawMessage(plan)
+	}
+	return captured
+}
+
+/*
+Function to explain the insert of the first measurement of the event store into the view. The insert is executed
+by ANALYZE, so it runs in a transaction with the removal of an already materialized row, which is rolled back afterwards
+@param db *sql.DB Database connection to Postgres database
+@return Captured plan or nil, if the event store is empty
+*/
+func explainInsert(db *sql.DB) *QueryPlan {
+
+	// Take the first measurement of the event store as representative row
+	rows, err := readHandle(db).Query("SELECT * FROM event_store ORDER BY id LIMIT 1")
+	checkError(err)
+	defer rows.Close()
+	if !rows.Next() {
+		return nil
+	}
+	transformedMeasurement := transformMeasurement(scanMeasurement(rows))
+	rows.Close()
+
+	// Create the monthly partition of the measurement, if the view is partitioned
+	if partitionedView {
+		ensurePartition(transformedMeasurement.created_on, db)
+	}
+
+	// Explain the insert in a transaction, which is always rolled back
+	tx, err := db.Begin()
+	checkError(err)
+	defer tx.Rollback()
+
+	// Remove the already materialized row, so the insert doesn't conflict with it
+	_, err = tx.Exec("DELETE FROM materialized_view WHERE id = $1", transformedMeasurement.id)
+	checkError(err)
+	plan := explainQuery(tx, "insert", insertStatement("materialized_view"), insertArguments(transformedMeasurement)...)
+	return &plan
+}
+
+/*
+Function to capture the plans of the event store read and a representative insert into the view
+@param db *sql.DB Database connection to Postgres database
+@return Captured plans (empty for CSV sources and outputs)
+*/
+func captureQueryPlans(db *sql.DB) []QueryPlan {
+	plans := make([]QueryPlan, 0, 2)
+
+	// Explain the read of the event store with the filters and order of the run
+	if sourceMode == ModeDb {
+		checkReplicaLag(db)
+		query, args := measurementsQuery("")
+		plans = append(plans, explainQuery(readHandle(db), "read", query, args...))
+	}
+
+	// Explain the insert into the view
+	if outputMode == ModeDb {
+		if plan := explainInsert(db); plan != nil {
+			plans = append(plans, *plan)
+		}
+	}
+	return plans
+}
+
+/*
+Function to get the planning and execution time of a captured plan
+@param plan Captured plan
+@return Description of the times or a note, if the plan is truncated
+*/
+func planTimes(plan QueryPlan) string {
+	if plan.Plan == nil {
+		return plan.Note
+	}
+	var explained []struct {
+		PlanningTime  float64 `json:"Planning Time"`
+		ExecutionTime float64 `json:"Execution Time"`
+	}
+	if err := json.Unmarshal(plan.Plan, &explained); err != nil || len(explained) == 0 {
+		return "times unavailable"
+	}
+	return fmt.Sprintf("planning %.3f ms, execution %.3f ms", explained[0].PlanningTime, explained[0].ExecutionTime)
+}
+
+/*
+Function to write each captured plan into a file next to the benchmark results, or into the working directory without BENCHMARK_EXPORT
+@param plans Captured plans
+@param runId Unique identifier of the benchmark run
+*/
+func writeQueryPlans(plans []QueryPlan, runId string) {
+
+	// Name the files after the results file or the run
+	prefix := "explain-" + runId
+	if benchmarkExport != "" {
+		prefix = strings.TrimSuffix(benchmarkExport, filepath.Ext(benchmarkExport)) + ".explain"
+	}
+
+	for _, plan := range plans {
+		// Write the plan as is or the truncated text with its note
+		path := prefix + "-" + plan.Name + ".json"
+		content := []byte(plan.Plan)
+		if plan.Plan == nil {
+			path = prefix + "-" + plan.Name + ".txt"
+			content = []byte("-- " + plan.Note + "\n" + plan.TruncatedPlan + "\n")
+		}
+		checkError(os.WriteFile(path, content, 0644))
+		fmt.Printf("Query plan of the %s (%s) written to %s\n", plan.Name, planTimes(plan), path)
+	}
+}
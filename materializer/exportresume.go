@@ -0,0 +1,107 @@
+package main
+
+/*
+@author 1Zero64
+Resuming an interrupted CSV or Parquet export after the last exported id with -resume-from
+*/
+
+// Importing packages
+import (
+	// Package for byte slice manipulation
+	"bytes"
+	// Package for formatted printing
+	"fmt"
+	// Package for I/O primitives
+	"io"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for converting strings to numbers
+	"strconv"
+)
+
+// Maximum number of bytes read from the end of a CSV file to find its last complete record
+const csvTailSize = 64 * 1024
+
+/*
+Function to add the resume boundary to the condition of a query on the event store
+@param condition Optional condition of the WHERE clause to filter measurements (empty to read all)
+@param args Arguments for the placeholders of the condition
+@return Condition and its arguments reading only measurements after -resume-from
+*/
+func resumeCondition(condition string, args []interface{}) (string, []interface{}) {
+	args = append(args, resumeFromId)
+	if condition != "" {
+		condition += " AND "
+	}
+	return condition + fmt.Sprintf("id > $%d", len(args)), args
+}
+
+/*
+Function to open a CSV file of an interrupted export for appending. A partially written last record is cut off and the id of
+the last complete record has to match -resume-from, so the resumed export neither duplicates nor skips rows at the boundary
+@param path Path of the CSV file
+@return File positioned at its end
+*/
+func openResumedCsvFile(path string) *os.File {
+
+	// Open the existing file, a missing one can't be resumed
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	checkError(err)
+
+	// Read the end of the file
+	info, err := file.Stat()
+	checkError(err)
+	offset := info.Size() - csvTailSize
+	if offset < 0 {
+		offset = 0
+	}
+	tail := make([]byte, info.Size()-offset)
+	_, err = file.ReadAt(tail, offset)
+	checkError(err)
+
+	// Cut off the partially written record after the last line break
+	end := bytes.LastIndexByte(tail, '\n')
+	if end < 0 {
+		file.Close()
+		checkError(fmt.Errorf("can't resume %s: no complete record in its last %d bytes", path, csvTailSize))
+	}
+	if int64(end+1) < int64(len(tail)) {
+		fmt.Printf("Cutting off %d bytes of a partially written record at the end of %s\n", int64(len(tail))-int64(end+1), path)
+		checkError(file.Truncate(offset + int64(end+1)))
+	}
+
+	// Compare the id of the last complete record with the resume boundary (0 for only the header)
+	lastLine := tail[bytes.LastIndexByte(tail[:end], '\n')+1 : end]
+	lastId := int64(0)
+	if field, _, _ := bytes.Cut(lastLine, []byte(",")); string(field) != "id" {
+		lastId, err = strconv.ParseInt(string(field), 10, 64)
+		if err != nil {
+			file.Close()
+			checkError(fmt.Errorf("can't resume %s: the last record doesn't start with an id: %w", path, err))
+		}
+	}
+	if lastId == 0 {
+		file.Close()
+		checkError(fmt.Errorf("can't resume %s: it has no records yet, rerun the export without -resume-from", path))
+	}
+	if lastId != resumeFromId {
+		file.Close()
+		checkError(fmt.Errorf("can't resume %s from id %d: its last complete record has id %d, resume with -resume-from %d", path, resumeFromId, lastId, lastId))
+	}
+
+	// Append after the last complete record
+	_, err = file.Seek(0, io.SeekEnd)
+	checkError(err)
+	fmt.Printf("Resuming the export into %s after id %d\n", path, resumeFromId)
+	return file
+}
+
+/*
+Function to print how to continue an export, which stopped or failed
+@param path Path of the export file
+@param lastId Id of the last exported measurement
+@param reason Reason, why the export stopped
+*/
+func printResumeHint(path string, lastId int64, reason interface{}) {
+	fmt.Printf("Export to %s stopped (%v) after id %d, continue it with -resume-from %d\n", path, reason, lastId, lastId)
+}
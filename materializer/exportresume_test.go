@@ -0,0 +1,172 @@
+package main
+
+/*
+@author 1Zero64
+Tests of resuming an interrupted CSV export with -resume-from
+*/
+
+// Importing packages
+import (
+	// Package for reading and writing CSV files
+	"encoding/csv"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for manipulating file paths
+	"path/filepath"
+	// Package for string manipulation
+	"strings"
+	// Package for automated tests
+	"testing"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Header of a CSV export of transformed measurements
+const csvExportHeader = "id,created_on,danger,event_stream,humidity,latency,processed_on,sensor_id,temperature,heat_index,unknown_sensor,latency_us\n"
+
+/*
+Function to build a CSV record of a transformed measurement with an id
+@param id Id of the record
+@return Record with its line break
+*/
+func csvExportRecord(id int64) string {
+	return fmt.Sprintf("%d,2024-01-05T10:00:00Z,Low,room-1,45,1,2024-01-05T10:00:01Z,7,4,4,false,1000000\n", id)
+}
+
+/*
+Function to call a function, which is expected to fail with a panic
+@param t Test state
+@param function Function to call
+@return Text of the raised failure
+*/
+func expectFailure(t *testing.T, function func()) (message string) {
+	t.Helper()
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			t.Fatal("expected a failure")
+		}
+		message = fmt.Sprint(recovered)
+	}()
+	function()
+	return ""
+}
+
+/*
+Function to resume a CSV file after an id and return its content after closing it again
+@param t Test state
+@param path Path of the CSV file
+@param id Id to resume after
+@return Content of the file
+*/
+func resumeCsvFile(t *testing.T, path string, id int64) string {
+	t.Helper()
+	saved := resumeFromId
+	defer func() { resumeFromId = saved }()
+	resumeFromId = id
+	openResumedCsvFile(path).Close()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+/*
+Test the checks of the end of the file to resume
+@param t Test state
+*/
+func TestOpenResumedCsvFile(t *testing.T) {
+	directory := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(directory, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	complete := csvExportHeader + csvExportRecord(1) + csvExportRecord(2)
+
+	// A cleanly ended file is kept as it is
+	if got := resumeCsvFile(t, write("clean.csv", complete), 2); got != complete {
+		t.Errorf("clean file changed to %q", got)
+	}
+
+	// A partially written record is cut off
+	if got := resumeCsvFile(t, write("partial.csv", complete+"3,2024-01-05T1"), 2); got != complete {
+		t.Errorf("partial record not cut off: %q", got)
+	}
+
+	// A file with only the header can't be resumed
+	resumeFromId = 1
+	defer func() { resumeFromId = 0 }()
+	path := write("header.csv", csvExportHeader)
+	if message := expectFailure(t, func() { openResumedCsvFile(path) }); !strings.Contains(message, "no records yet") {
+		t.Errorf("header-only file: %s", message)
+	}
+
+	// The last complete record has to be the resume boundary
+	path = write("mismatch.csv", complete+"3,2024-01")
+	if message := expectFailure(t, func() { openResumedCsvFile(path) }); !strings.Contains(message, "resume with -resume-from 2") {
+		t.Errorf("id mismatch: %s", message)
+	}
+}
+
+/*
+Test that an export interrupted in the middle of a record and resumed with -resume-from contains every id exactly once
+@param t Test state
+*/
+func TestInterruptedCsvExportResumes(t *testing.T) {
+	savedLayout, savedResume := csvTimeLayout, resumeFromId
+	defer func() { csvTimeLayout, resumeFromId = savedLayout, savedResume }()
+	csvTimeLayout, resumeFromId = time.RFC3339, 0
+	path := filepath.Join(t.TempDir(), "export.csv")
+	measurement := func(id int64) TransformedMeasurement {
+		return TransformedMeasurement{Measurement: Measurement{id: id, event_stream: "room-1", created_on: time.Unix(0, 0).UTC(), processed_on: time.Unix(1, 0).UTC()}, danger: Low}
+	}
+
+	// Export the first records and fail, the crash leaves a partially written record behind
+	output := newTransformedCsvWriter(path)
+	for id := int64(1); id <= 5; id++ {
+		if err := output.write(measurement(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output.abort()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("6,1970-01-01T00:")
+	file.Close()
+
+	// Resume after the printed last id and export the remaining records
+	resumeFromId = output.lastId
+	output = newTransformedCsvWriter(path)
+	for id := resumeFromId + 1; id <= 8; id++ {
+		if err := output.write(measurement(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output.close()
+
+	// Every id has to be exported exactly once in order after a single header
+	file, err = os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record[0])
+	}
+	if got := strings.Join(ids, ","); got != "id,1,2,3,4,5,6,7,8" {
+		t.Errorf("exported ids = %s, want the header and 1 to 8", got)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+/*
+@author 1Zero64
+Fingerprint of the source data, the transformation configuration and the view to skip rebuilds of an unchanged event store
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for non-cryptographic hash functions
+	"hash/fnv"
+)
+
+// Names of the persisted fingerprint values of the last successful rebuild
+const (
+	sourceCountStat          = "source_count"
+	sourceMaxIdStat          = "source_max_id"
+	sourceMaxProcessedOnStat = "source_max_processed_on"
+	transformConfigStat      = "transform_config_hash"
+	viewCountStat            = "view_count"
+	viewMaxIdStat            = "view_max_id"
+)
+
+// Object structure for the cheap fingerprint of the source data, the transformation configuration and the view
+type SourceFingerprint struct {
+	// Number of measurements
+	count float64
+	// Newest id
+	maxId float64
+	// Newest processed_on as Unix time in seconds with microseconds
+	maxProcessedOn float64
+	// Hash of the effective transformation configuration
+	config float64
+	// Number of rows of the view
+	viewCount float64
+	// Newest id of the view
+	viewMaxId float64
+}
+
+/*
+Function to hash the effective transformation configuration, so changed thresholds (also of the danger_thresholds table),
+profiles, scoring and policies trigger a rebuild. The thresholds have to be refreshed before
+@return 32-bit FNV-1a hash of the configuration, exactly representable in the stored float
+*/
+func transformConfigHash() float64 {
+	hash := fnv.New32a()
+	fmt.Fprintf(hash, "thresholds=%v streams=%v scoring=%s weights=%v scores=%v tolerance=%v dedup=%s hysteresis=%v",
+		thresholds, streamThresholds, scoringMode, scoringWeights, scoreThresholds, tolerance, dedupPolicy, hysteresisMargin)
+	fmt.Fprintf(hash, " null=%s nonfinite=%s skew=%s/%s sensors=%s/%s latencyfloat=%v reading=%s",
+		nullPolicy, nonFinitePolicy, futureSkew, futureSkewPolicy, sensorTable, unknownSensorPolicy, latencyFloatColumn, readingColumnType)
+	return float64(hash.Sum32())
+}
+
+/*
+Function to compute the fingerprint of the (stream filtered) event store from its count, newest id and newest processed_on,
+of the transformation configuration and of the view from its count and newest id
+@param db *sql.DB Database connection to Postgres database
+@return Fingerprint of the source data
+*/
+func sourceFingerprint(db *sql.DB) SourceFingerprint {
+
+	// Aggregate the event store with the same filter as the read
+	condition, args := eventStoreCondition("")
+	query := "SELECT COUNT(*), COALESCE(MAX(id), 0), COALESCE(EXTRACT(EPOCH FROM MAX(processed_on)), 0) FROM event_store"
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	var fingerprint SourceFingerprint
+	err := readHandle(db).QueryRow(query, args...).Scan(&fingerprint.count, &fingerprint.maxId, &fingerprint.maxProcessedOn)
+	// Check on error with handler
+	checkError(err)
+
+	// Hash the configuration with the thresholds the run would use
+	refreshThresholds(db)
+	fingerprint.config = transformConfigHash()
+
+	// Add the view, which a clean, purge or -fill-gaps changed
+	fingerprint.readView(db)
+	return fingerprint
+}
+
+/*
+Function to read the count and newest id of the view into the fingerprint
+@param db *sql.DB Database connection to Postgres database
+*/
+func (fingerprint *SourceFingerprint) readView(db *sql.DB) {
+	err := db.QueryRow("SELECT COUNT(*), COALESCE(MAX(id), 0) FROM materialized_view").Scan(&fingerprint.viewCount, &fingerprint.viewMaxId)
+	// Check on error with handler
+	checkError(err)
+}
+
+/*
+Function to check whether the fingerprint equals the stored one of the last successful rebuild
+@param db *sql.DB Database connection to Postgres database
+@param fingerprint Fingerprint of the current source data, configuration and view
+@return True, if a fingerprint was stored and all values are equal
+*/
+func sourceUnchanged(db *sql.DB, fingerprint SourceFingerprint) bool {
+	current := map[string]float64{
+		sourceCountStat:          fingerprint.count,
+		sourceMaxIdStat:          fingerprint.maxId,
+		sourceMaxProcessedOnStat: fingerprint.maxProcessedOn,
+		transformConfigStat:      fingerprint.config,
+		viewCountStat:            fingerprint.viewCount,
+		viewMaxIdStat:            fingerprint.viewMaxId,
+	}
+	for name, value := range current {
+		if stored, found := loadRunStat(db, name); !found || stored != value {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Function to store the fingerprint of a successful rebuild for the next run
+@param db *sql.DB Database connection to Postgres database
+@param fingerprint Fingerprint of the source data and configuration of the rebuild and of the rebuilt view
+*/
+func storeSourceFingerprint(db *sql.DB, fingerprint SourceFingerprint) {
+	storeRunStat(db, sourceCountStat, fingerprint.count)
+	storeRunStat(db, sourceMaxIdStat, fingerprint.maxId)
+	storeRunStat(db, sourceMaxProcessedOnStat, fingerprint.maxProcessedOn)
+	storeRunStat(db, transformConfigStat, fingerprint.config)
+	storeRunStat(db, viewCountStat, fingerprint.viewCount)
+	storeRunStat(db, viewMaxIdStat, fingerprint.viewMaxId)
+}
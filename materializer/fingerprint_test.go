@@ -0,0 +1,46 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the fingerprint of the transformation configuration
+*/
+
+// Importing packages
+import (
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Test that the configuration hash is stable for the same configuration and changes with the thresholds and the classification settings
+@param t Test state
+*/
+func TestTransformConfigHash(t *testing.T) {
+
+	// Restore the configuration after the test
+	savedThresholds, savedStreams, savedScoring, savedTolerance, savedDedup := thresholds, streamThresholds, scoringMode, tolerance, dedupPolicy
+	defer func() {
+		thresholds, streamThresholds, scoringMode, tolerance, dedupPolicy = savedThresholds, savedStreams, savedScoring, savedTolerance, savedDedup
+	}()
+	thresholds, streamThresholds, scoringMode, tolerance, dedupPolicy = defaultThresholds, map[string]Thresholds{}, "threshold", 1e-6, ""
+	base := transformConfigHash()
+	if again := transformConfigHash(); again != base {
+		t.Fatalf("hash not stable: %v != %v", again, base)
+	}
+
+	// Every change of the classification has to change the hash
+	changes := map[string]func(){
+		"danger_thresholds set": func() { thresholds.temperature[3] = 11 },
+		"stream profile":        func() { streamThresholds = map[string]Thresholds{"freezer": defaultThresholds} },
+		"scoring mode":          func() { scoringMode = "weighted" },
+		"tolerance":             func() { tolerance = 0.01 },
+		"dedup":                 func() { dedupPolicy = "latest" },
+	}
+	for name, change := range changes {
+		thresholds, streamThresholds, scoringMode, tolerance, dedupPolicy = defaultThresholds, map[string]Thresholds{}, "threshold", 1e-6, ""
+		change()
+		if transformConfigHash() == base {
+			t.Errorf("%s: hash unchanged", name)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+/*
+@author 1Zero64
+Freshness of the event store data and ingest lag of the stream consumer
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Object structure for the freshness of the event store
+type Freshness struct {
+	// Flag whether the event store contained measurements to measure the freshness of
+	measured bool
+	// Newest created_on of the event store
+	newestCreatedOn time.Time
+	// Newest processed_on of the event store
+	newestProcessedOn time.Time
+}
+
+/*
+Function to measure the freshness of the (stream filtered) event store with the newest created_on and processed_on
+@param db *sql.DB Database connection to Postgres database
+@return Freshness of the event store
+*/
+func measureFreshness(db *sql.DB) Freshness {
+
+	// Query newest timestamps of the measurements in scope of the run
+	condition, args := eventStoreCondition("")
+	query := "SELECT MAX(created_on), MAX(processed_on) FROM event_store"
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	var createdOn, processedOn sql.NullTime
+	err := readHandle(db).QueryRow(query, args...).Scan(&createdOn, &processedOn)
+	// Check on error with handler
+	checkError(err)
+
+	// Return freshness, which isn't measured for an empty event store
+	return Freshness{measured: createdOn.Valid, newestCreatedOn: createdOn.Time, newestProcessedOn: processedOn.Time}
+}
+
+/*
+Function to get the age of the newest measurement, which grows, if no new data is created
+@return Duration since the newest created_on
+*/
+func (freshness Freshness) dataAge() time.Duration {
+	return sinceNow(freshness.newestCreatedOn)
+}
+
+/*
+Function to get the ingest lag, which grows, if the stream consumer doesn't keep up
+@return Duration since the newest processed_on
+*/
+func (freshness Freshness) ingestLag() time.Duration {
+	return sinceNow(freshness.newestProcessedOn)
+}
+
+/*
+Function to print the data freshness and ingest lag and warn about a lagging consumer beyond INGEST_LAG_THRESHOLD
+*/
+func (freshness Freshness) print() {
+
+	// Nothing to print without measurements
+	if !freshness.measured {
+		return
+	}
+
+	// Print both metrics next to each other
+	fmt.Printf("Data freshness: newest created_on %s (%s ago)\n", freshness.newestCreatedOn.Format(time.RFC3339), freshness.dataAge().Round(time.Second))
+	fmt.Printf("Ingest lag: newest processed_on %s (%s ago)\n", freshness.newestProcessedOn.Format(time.RFC3339), freshness.ingestLag().Round(time.Second))
+
+	// Warn about a consumer falling behind, if a threshold is configured
+	if ingestLagThreshold > 0 && freshness.ingestLag() > ingestLagThreshold {
+		fmt.Printf("Warning: the stream consumer is lagging, nothing was processed for %s (INGEST_LAG_THRESHOLD %s)\n", freshness.ingestLag().Round(time.Second), ingestLagThreshold)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+/*
+@author 1Zero64
+Anti-join mode to fill only the measurements missing from the materialized view
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+)
+
+// Condition of the read to select only measurements of the event store without a row in the materialized view.
+// NOT EXISTS is planned as anti-join like a LEFT JOIN ... IS NULL, but keeps the columns of the stream filter unambiguous
+const missingFromViewCondition = "NOT EXISTS (SELECT 1 FROM materialized_view m WHERE m.id = event_store.id)"
+
+/*
+Function to warn, if the materialized view has no index leading with id, so the anti-join can't look up the view rows efficiently
+@param db *sql.DB Database connection to Postgres database
+*/
+func checkGapIndex(db *sql.DB) {
+
+	// Look for an index (like the primary key) with id as first column
+	var indexed bool
+	err := db.QueryRow(`SELECT EXISTS (
+		SELECT 1 FROM pg_index i
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = i.indkey[0]
+		WHERE i.indrelid = 'materialized_view'::regclass AND a.attname = 'id'
+	)`).Scan(&indexed)
+	// Check on error with handler
+	checkError(err)
+
+	// Hint on the missing index
+	if !indexed {
+		fmt.Println("Warning: materialized_view has no primary key or index on id, the anti-join has to scan the whole view. Consider: CREATE INDEX ON materialized_view (id)")
+	}
+}
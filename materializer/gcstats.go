@@ -0,0 +1,54 @@
+package main
+
+/*
+@author 1Zero64
+Garbage collection statistics of microbenchmark iterations
+*/
+
+// Importing packages
+import (
+	// Package for interaction with the Go runtime
+	"runtime"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Object structure for the garbage collections during a microbenchmark iteration
+type GCStatistics struct {
+	// Number of garbage collections
+	NumGC uint32 `json:"num_gc"`
+	// Total stop-the-world pause time in seconds
+	TotalPause float64 `json:"total_pause"`
+	// Longest single pause in seconds
+	MaxPause float64 `json:"max_pause"`
+}
+
+/*
+Function to calculate the garbage collections between two memory statistics snapshots
+@param before Memory statistics before the iteration
+@param after Memory statistics after the iteration
+@return Garbage collection statistics of the iteration
+*/
+func gcDelta(before *runtime.MemStats, after *runtime.MemStats) GCStatistics {
+
+	// Calculate number of collections and total pause time
+	statistics := GCStatistics{
+		NumGC:      after.NumGC - before.NumGC,
+		TotalPause: time.Duration(after.PauseTotalNs - before.PauseTotalNs).Seconds(),
+	}
+
+	// Find the longest pause of the new collections in the circular buffer of the recent pauses
+	collections := statistics.NumGC
+	if collections > uint32(len(after.PauseNs)) {
+		collections = uint32(len(after.PauseNs))
+	}
+	for i := uint32(0); i < collections; i++ {
+		pause := time.Duration(after.PauseNs[(after.NumGC-i+255)%256]).Seconds()
+		if pause > statistics.MaxPause {
+			statistics.MaxPause = pause
+		}
+	}
+
+	// Return statistics
+	return statistics
+}
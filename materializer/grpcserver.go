@@ -0,0 +1,229 @@
+package main
+
+/*
+@author 1Zero64
+gRPC service exposing the materialize process, the microbenchmark, the clean and the counts to remote clients
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for inspecting errors
+	"errors"
+	// Package for formatted printing
+	"fmt"
+	// Package for network listeners
+	"net"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for synchronization of goroutines
+	"sync"
+
+	// Generated messages and stubs of the gRPC service
+	pb "Users/nikokauz/git/ESC-Streaming-Architectures-Thesis-Materializer/materializer/materializerpb"
+
+	// Package for the gRPC server
+	"google.golang.org/grpc"
+	// Package with the gRPC status codes
+	"google.golang.org/grpc/codes"
+	// Package for errors with a gRPC status
+	"google.golang.org/grpc/status"
+)
+
+// Object structure for the gRPC service of the materializer
+type GrpcServer struct {
+	pb.UnimplementedMaterializerServer
+	// Database connection to Postgres database
+	db *sql.DB
+	// Mutex held by the running mutating operation, read-only operations don't take it
+	mutating sync.Mutex
+}
+
+/*
+Function to serve the gRPC service on GRPC_ADDRESS until the process is stopped
+@param db *sql.DB Database connection to Postgres database
+*/
+func serveGrpc(db *sql.DB) {
+
+	// Listen on the configured address
+	listener, err := net.Listen("tcp", grpcAddress)
+	// Check on error with handler
+	checkError(err)
+
+	// Serve the requests until the process is stopped
+	server := grpc.NewServer()
+	pb.RegisterMaterializerServer(server, &GrpcServer{db: db})
+	fmt.Printf("gRPC service listening on %s\n", listener.Addr())
+	checkError(server.Serve(listener))
+}
+
+/*
+Function to take the lock of the mutating operations without waiting for a running one
+@return Error with status ABORTED, if another mutating operation is running
+*/
+func (server *GrpcServer) lockMutating() error {
+	if !server.mutating.TryLock() {
+		return status.Error(codes.Aborted, "another mutating operation is running")
+	}
+	return nil
+}
+
+/*
+Function to turn a panic of an operation into the error of the call, so a failed call doesn't terminate the service.
+Must be deferred directly by the operation
+@param err Pointer to the error returned by the operation
+*/
+func recoverGrpcError(err *error) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "gRPC call failed: %v\n", recovered)
+
+	// A lock held by another materializer instance is a conflict like a running operation of this one
+	var held *LockHeldError
+	if failure, ok := recovered.(error); ok && errors.As(failure, &held) {
+		*err = status.Error(codes.Aborted, held.Error())
+		return
+	}
+	*err = status.Errorf(codes.Internal, "%v", recovered)
+}
+
+/*
+Function to materialize the event store, streaming the progress and finally the result of the run. A cancelled call stops the run
+@param request Empty request
+@param stream Stream of the progress to the client
+@return Error of the run or of sending its result
+*/
+func (server *GrpcServer) Materialize(request *pb.MaterializeRequest, stream pb.Materializer_MaterializeServer) (err error) {
+	if err = server.lockMutating(); err != nil {
+		return err
+	}
+	defer server.mutating.Unlock()
+	defer recoverGrpcError(&err)
+
+	// Run with the context of the call and send its progress, a failed send ends with the cancelled call
+	ctx := withProgressListener(stream.Context(), func(processed int64, total int64) {
+		stream.Send(&pb.MaterializeProgress{Processed: processed, Total: total})
+	})
+	summary := materializeView(ctx, server.db)
+
+	// Send the result of the run as last message
+	return stream.Send(&pb.MaterializeProgress{Result: runResult(summary)})
+}
+
+/*
+Function to run the microbenchmark of the menu with its warmup, convergence check, resume and statistics
+@param ctx Context of the call, a cancelled call stops the benchmark
+@param request Number of (in convergence mode maximum) iterations
+@return Durations and mean of the iterations
+*/
+func (server *GrpcServer) RunBenchmark(ctx context.Context, request *pb.RunBenchmarkRequest) (response *pb.RunBenchmarkResponse, err error) {
+	if request.Iterations < 1 {
+		return nil, status.Error(codes.InvalidArgument, "iterations must be at least 1")
+	}
+	if err = server.lockMutating(); err != nil {
+		return nil, err
+	}
+	defer server.mutating.Unlock()
+	defer recoverGrpcError(&err)
+
+	// Run the iterations like the microbenchmark of the menu, a cancelled call stops them within the in-flight iteration
+	interrupt := contextBenchmarkInterrupt(ctx)
+	defer interrupt.stop()
+	results := runMicrobenchmark(server.db, int(request.Iterations), interrupt)
+	if ctx.Err() != nil {
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	if results == nil {
+		return nil, status.Error(codes.Internal, "no iteration completed")
+	}
+	results.print()
+	results.export()
+	return &pb.RunBenchmarkResponse{
+		RunId:            results.RunId,
+		DurationsSeconds: results.Durations,
+		MeanSeconds:      results.Mean,
+		Measurements:     int64(results.Measurements),
+	}, nil
+}
+
+/*
+Function to delete all rows of the materialized view under the lock of the target table
+@param ctx Context of the call
+@param request Empty request
+@return Empty response
+*/
+func (server *GrpcServer) Clean(ctx context.Context, request *pb.CleanRequest) (response *pb.CleanResponse, err error) {
+	if err = server.lockMutating(); err != nil {
+		return nil, err
+	}
+	defer server.mutating.Unlock()
+	defer recoverGrpcError(&err)
+
+	// Lock the view against other instances like a materialize run
+	lock := acquireMaterializeLock(server.db, "materialized_view")
+	defer lock.release()
+	cleanMaterializedView(server.db)
+	return &pb.CleanResponse{}, nil
+}
+
+/*
+Function to read the counts of the event store and the materialized view and the result of the last run
+@param ctx Context of the call
+@param request Empty request
+@return Counts and result of the last run
+*/
+func (server *GrpcServer) GetStats(ctx context.Context, request *pb.GetStatsRequest) (response *pb.GetStatsResponse, err error) {
+	defer recoverGrpcError(&err)
+
+	// Read the counts with the configured filters
+	response = &pb.GetStatsResponse{
+		EventStore:       tableStats(readTableCounts(server.db, "event_store")),
+		MaterializedView: tableStats(readTableCounts(server.db, "materialized_view")),
+	}
+
+	// Add the result of the last run of this process
+	lastRunSummaryMutex.Lock()
+	if lastRunSummary != nil {
+		response.LastRun = runResult(lastRunSummary)
+	}
+	lastRunSummaryMutex.Unlock()
+	return response, nil
+}
+
+/*
+Function to convert the summary of a run into its message
+@param summary Summary of the run (nil, if skipped because the event store is unchanged)
+@return Result of the run
+*/
+func runResult(summary *RunSummary) *pb.RunResult {
+	if summary == nil {
+		return &pb.RunResult{Skipped: true}
+	}
+	result := &pb.RunResult{
+		RunId:           summary.runId,
+		Measurements:    int64(summary.measurements),
+		DurationSeconds: summary.duration.Seconds(),
+		DangerLevels:    make(map[string]int64),
+	}
+	if summary.stopped != nil {
+		result.Stopped = summary.stopped.Error()
+	}
+	for level, count := range summary.dangerLevels {
+		result.DangerLevels[level] = int64(count)
+	}
+	return result
+}
+
+/*
+Function to convert the counts of a table into its message
+@param counts Counts of the table
+@return Counts message
+*/
+func tableStats(counts TableCounts) *pb.TableStats {
+	return &pb.TableStats{Rows: counts.rows, MaxId: counts.maxId.Int64, Streams: counts.streams}
+}
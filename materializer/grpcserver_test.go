@@ -0,0 +1,139 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the gRPC service with the generated client over an in-memory connection
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package for reading and writing CSV files
+	"encoding/csv"
+	// Package for end of stream errors
+	"io"
+	// Package for network listeners
+	"net"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for automated tests
+	"testing"
+
+	// Generated messages and stubs of the gRPC service
+	pb "Users/nikokauz/git/ESC-Streaming-Architectures-Thesis-Materializer/materializer/materializerpb"
+
+	// Package for the gRPC server and client
+	"google.golang.org/grpc"
+	// Package with the gRPC status codes
+	"google.golang.org/grpc/codes"
+	// Package for connections without transport security
+	"google.golang.org/grpc/credentials/insecure"
+	// Package for errors with a gRPC status
+	"google.golang.org/grpc/status"
+	// Package for in-memory connections
+	"google.golang.org/grpc/test/bufconn"
+)
+
+/*
+Function to serve the gRPC service over an in-memory connection and return a client of it
+@param t Test state
+@param server Service to serve
+@return Generated client of the service
+*/
+func startTestGrpcServer(t *testing.T, server *GrpcServer) pb.MaterializerClient {
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	pb.RegisterMaterializerServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	connection, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, address string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { connection.Close() })
+	return pb.NewMaterializerClient(connection)
+}
+
+/*
+Test a tiny materialization from a CSV file into a CSV file over the API: the progress is streamed and the last message
+carries the result of the run
+@param t Test state
+*/
+func TestGrpcMaterializeEndToEnd(t *testing.T) {
+	// Three measurements of two event streams
+	outputCsvPath := useCsvSourceAndOutput(t, csvSourceHeader+
+		"1,7,4,45,room-1,2024-01-05T10:00:00Z,2024-01-05T10:00:01Z\n"+
+		"2,7,5,46,room-1,2024-01-05T10:01:00Z,2024-01-05T10:01:01Z\n"+
+		"3,8,6,47,room-2,2024-01-05T10:02:00Z,2024-01-05T10:02:01Z\n")
+
+	// Materialize over the API and collect the streamed messages
+	client := startTestGrpcServer(t, &GrpcServer{})
+	stream, err := client.Materialize(context.Background(), &pb.MaterializeRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []*pb.MaterializeProgress
+	for {
+		message, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, message)
+	}
+
+	// The progress is streamed before the result, which ends the stream
+	if len(messages) < 2 {
+		t.Fatalf("received %d messages, want progress and a result", len(messages))
+	}
+	if progress := messages[len(messages)-2]; progress.Processed != 3 || progress.Total != 3 {
+		t.Errorf("last progress = %d/%d, want 3/3", progress.Processed, progress.Total)
+	}
+	result := messages[len(messages)-1].Result
+	if result == nil || result.Skipped || result.Stopped != "" || result.RunId == "" || result.Measurements != 3 {
+		t.Fatalf("result = %v, want a complete run of 3 measurements", result)
+	}
+
+	// The transformed measurements are written into the output file
+	file, err := os.Open(outputCsvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Errorf("output has %d records, want the header and 3 rows", len(records))
+	}
+}
+
+/*
+Test that a mutating call fails with ABORTED while another mutating operation runs
+@param t Test state
+*/
+func TestGrpcMutatingOperationsExcludeEachOther(t *testing.T) {
+	server := &GrpcServer{}
+	client := startTestGrpcServer(t, server)
+
+	// Hold the lock like a running materialization
+	server.mutating.Lock()
+	defer server.mutating.Unlock()
+
+	if _, err := client.Clean(context.Background(), &pb.CleanRequest{}); status.Code(err) != codes.Aborted {
+		t.Errorf("Clean during a running operation = %v, want ABORTED", err)
+	}
+	if _, err := client.RunBenchmark(context.Background(), &pb.RunBenchmarkRequest{Iterations: 1}); status.Code(err) != codes.Aborted {
+		t.Errorf("RunBenchmark during a running operation = %v, want ABORTED", err)
+	}
+	if _, err := client.RunBenchmark(context.Background(), &pb.RunBenchmarkRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("RunBenchmark without iterations = %v, want INVALID_ARGUMENT", err)
+	}
+}
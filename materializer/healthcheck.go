@@ -0,0 +1,83 @@
+package main
+
+/*
+@author 1Zero64
+Healthcheck subcommand for container and orchestration probes
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Maximum duration of the healthcheck, so a probe finishes even when the database is unreachable
+const healthcheckTimeout = 2 * time.Second
+
+/*
+Function to check whether the healthcheck subcommand is requested.
+The arguments are looked up directly, because the configuration is loaded before the flags are parsed
+@return True, if healthcheck is given as argument
+*/
+func healthcheckRequested() bool {
+	for _, argument := range os.Args[1:] {
+		if argument == "healthcheck" {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Function to print an unhealthy result and exit with 1 instead of panicking, if a configuration error occurs during the healthcheck
+*/
+func recoverHealthcheck() {
+	if recovered := recover(); recovered != nil {
+		fmt.Printf("unhealthy: %v\n", recovered)
+		os.Exit(1)
+	}
+}
+
+/*
+Function to ping the database with a short timeout and check the tables exist, printing a one-line result
+@param db *sql.DB Database connection to Postgres database
+@return Exit code 0 for healthy and 1 for unhealthy
+*/
+func healthcheck(db *sql.DB) int {
+
+	// Bound the whole check by the timeout
+	ctx, cancel := context.WithTimeout(context.Background(), healthcheckTimeout)
+	defer cancel()
+
+	// Ping database
+	if err := db.PingContext(ctx); err != nil {
+		fmt.Printf("unhealthy: database %s not reachable: %v\n", connectionSummary(), err)
+		return 1
+	}
+
+	// Check the tables of the materialize process exist, if enabled
+	if healthcheckTables {
+		for _, table := range []string{"event_store", "materialized_view"} {
+			var exists bool
+			if err := db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", table).Scan(&exists); err != nil {
+				fmt.Printf("unhealthy: checking table %s failed: %v\n", table, err)
+				return 1
+			}
+			if !exists {
+				fmt.Printf("unhealthy: table %s doesn't exist\n", table)
+				return 1
+			}
+		}
+	}
+
+	fmt.Printf("healthy: database %s reachable\n", connectionSummary())
+	return 0
+}
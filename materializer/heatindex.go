@@ -0,0 +1,58 @@
+package main
+
+/*
+@author 1Zero64
+Calculation of the heat index as a combined temperature-humidity stress indicator
+*/
+
+// Importing packages
+import (
+	// Package for math functions
+	"math"
+)
+
+/*
+Function to calculate the heat index (perceived temperature) of a measurement following the NOAA procedure.
+The Rothfusz regression is only valid for heat indices of 80°F (26.7°C) and above. Below that range the simpler
+Steadman formula is used as fallback, which is what NOAA itself uses for mild conditions and cold storage rooms
+@param temperature Measured temperature in Grad Celsius
+@param humidity Measured relative humidity in percentage
+@return Heat index in Grad Celsius or NaN, if it can't be computed from the given values
+*/
+func calculateHeatIndex(temperature reading, humidity reading) reading {
+
+	// Convert temperature to Fahrenheit, because the regression coefficients are defined for Fahrenheit
+	t := float64(temperature)*9/5 + 32
+	rh := float64(humidity)
+
+	// Calculate heat index with the simple Steadman formula
+	heatIndex := 0.5 * (t + 61.0 + ((t - 68.0) * 1.2) + (rh * 0.094))
+
+	// Use the Rothfusz regression, if the average of simple heat index and temperature reaches its validity range
+	if (heatIndex+t)/2 >= 80 {
+		heatIndex = -42.379 + 2.04901523*t + 10.14333127*rh -
+			0.22475541*t*rh - 0.00683783*t*t - 0.05481717*rh*rh +
+			0.00122874*t*t*rh + 0.00085282*t*rh*rh - 0.00000199*t*t*rh*rh
+
+		// Adjustment for low humidity in hot conditions
+		if rh < 13 && t >= 80 && t <= 112 {
+			heatIndex -= ((13 - rh) / 4) * math.Sqrt((17-math.Abs(t-95))/17)
+		}
+
+		// Adjustment for high humidity in warm conditions
+		if rh > 85 && t >= 80 && t <= 87 {
+			heatIndex += ((rh - 85) / 10) * ((87 - t) / 5)
+		}
+	}
+
+	// Convert heat index back to Grad Celsius
+	heatIndex = (heatIndex - 32) * 5 / 9
+
+	// Check for NaN or infinite results (e.g. corrupt input values) and mark them as not computable
+	if math.IsNaN(heatIndex) || math.IsInf(heatIndex, 0) {
+		return reading(math.NaN())
+	}
+
+	// Return calculated heat index
+	return reading(heatIndex)
+}
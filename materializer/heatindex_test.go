@@ -0,0 +1,95 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the heat index against the NOAA heat index chart
+*/
+
+// Importing packages
+import (
+	// Package for math functions
+	"math"
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Function to calculate the heat index of a reading given in Fahrenheit, as the NOAA chart uses it
+@param fahrenheit Temperature in Fahrenheit
+@param humidity Relative humidity in percentage
+@return Heat index in Fahrenheit
+*/
+func heatIndexFahrenheit(fahrenheit float64, humidity float64) float64 {
+	celsius := reading((fahrenheit - 32) * 5 / 9)
+	return float64(calculateHeatIndex(celsius, reading(humidity)))*9/5 + 32
+}
+
+/*
+Test the heat index against the values of the NOAA chart, which are rounded to whole degrees
+@param t Test state
+*/
+func TestCalculateHeatIndexNoaaChart(t *testing.T) {
+	cases := []struct {
+		temperature, humidity, want float64
+	}{
+		{90, 70, 106},
+		{80, 60, 82},
+		{80, 80, 84},
+		{84, 70, 90},
+		{86, 90, 105},
+		{90, 40, 91},
+		{90, 50, 95},
+		{96, 50, 108},
+		{100, 40, 109},
+		{104, 40, 119},
+		// The Steadman formula below the validity range of the regression
+		{80, 40, 80},
+	}
+	for _, testCase := range cases {
+		if got := heatIndexFahrenheit(testCase.temperature, testCase.humidity); math.Abs(got-testCase.want) > 1 {
+			t.Errorf("%.0f°F at %.0f%%: %.2f°F, want %.0f°F ±1", testCase.temperature, testCase.humidity, got, testCase.want)
+		}
+	}
+}
+
+/*
+Test the Steadman formula for mild conditions and the adjustments of the regression for low and high humidity
+@param t Test state
+*/
+func TestCalculateHeatIndexBranches(t *testing.T) {
+	cases := []struct {
+		name                        string
+		temperature, humidity, want float64
+	}{
+		// 0.5 * (70 + 61 + 2 * 1.2 + 50 * 0.094)
+		{"Steadman at 70°F", 70, 50, 69.05},
+		{"Steadman in a cold room", 32, 80, 28.66},
+		// Regression 90.18°F minus (13 - 5) / 4 * sqrt((17 - 0) / 17)
+		{"low humidity adjustment", 95, 5, 88.18},
+		// Regression 92.97°F plus (95 - 85) / 10 * (87 - 82) / 5
+		{"high humidity adjustment", 82, 95, 93.97},
+	}
+	for _, testCase := range cases {
+		if got := heatIndexFahrenheit(testCase.temperature, testCase.humidity); math.Abs(got-testCase.want) > 0.05 {
+			t.Errorf("%s: %.3f°F, want %.2f°F", testCase.name, got, testCase.want)
+		}
+	}
+}
+
+/*
+Test that a NaN reading gives a NaN heat index, which is written as NULL
+@param t Test state
+*/
+func TestCalculateHeatIndexNaNIsNull(t *testing.T) {
+	for _, heatIndex := range []reading{
+		calculateHeatIndex(reading(math.NaN()), 50),
+		calculateHeatIndex(30, reading(math.NaN())),
+	} {
+		if !math.IsNaN(float64(heatIndex)) {
+			t.Errorf("heat index %v, want NaN", heatIndex)
+		}
+		if value := nullableFloat(heatIndex); value != nil {
+			t.Errorf("nullableFloat(%v) = %v, want NULL", heatIndex, value)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+/*
+@author 1Zero64
+Latency histogram with configurable bucket boundaries accumulated during the transformation
+*/
+
+// Importing packages
+import (
+	// Package for formatted printing
+	"fmt"
+	// Package for sorting Slices
+	"sort"
+	// Package for converting strings into numbers
+	"strconv"
+	// Package for string manipulation
+	"strings"
+)
+
+// Default exponential bucket boundaries in the latency unit (1, 2, 4, ... 65536)
+var defaultLatencyBuckets = exponentialBuckets(1, 2, 17)
+
+// Object structure for a latency histogram
+type LatencyHistogram struct {
+	// Ascending upper boundaries of the buckets in the latency unit (inclusive)
+	bounds []float64
+	// Number of latencies per bucket, the last one counts latencies above all boundaries
+	counts []int
+}
+
+/*
+Function to create exponential bucket boundaries
+@param start First boundary
+@param factor Factor between two boundaries
+@param count Number of boundaries
+@return Ascending bucket boundaries
+*/
+func exponentialBuckets(start float64, factor float64, count int) []float64 {
+	bounds := make([]float64, count)
+	for i := range bounds {
+		bounds[i] = start
+		start *= factor
+	}
+	return bounds
+}
+
+/*
+Function to parse comma-separated bucket boundaries in the latency unit
+@param value Comma-separated ascending boundaries, e.g. 1,2,4,8
+@return Parsed boundaries or an error, if a boundary isn't a number or they aren't strictly ascending
+*/
+func parseBuckets(value string) ([]float64, error) {
+	parts := strings.Split(value, ",")
+	bounds := make([]float64, 0, len(parts))
+	for _, part := range parts {
+		bound, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		if len(bounds) > 0 && bound <= bounds[len(bounds)-1] {
+			return nil, fmt.Errorf("bucket boundaries must be ascending, but %v follows %v", bound, bounds[len(bounds)-1])
+		}
+		bounds = append(bounds, bound)
+	}
+	return bounds, nil
+}
+
+/*
+Function to create an empty latency histogram
+@param bounds Ascending upper boundaries of the buckets in the latency unit
+@return Pointer to the histogram
+*/
+func newLatencyHistogram(bounds []float64) *LatencyHistogram {
+	return &LatencyHistogram{bounds: bounds, counts: make([]int, len(bounds)+1)}
+}
+
+/*
+Function to count a latency into its bucket
+@param latency Latency in the latency unit
+*/
+func (histogram *LatencyHistogram) add(latency float64) {
+	histogram.counts[sort.SearchFloat64s(histogram.bounds, latency)]++
+}
+
+/*
+Function to print the histogram as ASCII bar chart with bars scaled to the fullest bucket
+*/
+func (histogram *LatencyHistogram) print() {
+
+	// Find fullest bucket and total for the scaling
+	var maxCount, total int
+	for _, count := range histogram.counts {
+		maxCount = max(maxCount, count)
+		total += count
+	}
+
+	// Print a bar per bucket scaled to 50 characters for the fullest bucket
+	fmt.Printf("Latency histogram (%s):\n", latencyUnit.Name)
+	for i, count := range histogram.counts {
+		label := "> " + strconv.FormatFloat(histogram.bounds[len(histogram.bounds)-1], 'f', -1, 64)
+		if i < len(histogram.bounds) {
+			label = "<= " + strconv.FormatFloat(histogram.bounds[i], 'f', -1, 64)
+		}
+		var share, width float64
+		if total > 0 {
+			share = float64(count) / float64(total)
+			width = float64(count) / float64(maxCount)
+		}
+		fmt.Printf("%-12s %10d %6.2f%% %s\n", label, count, share*100, strings.Repeat("#", int(width*50+0.5)))
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+/*
+@author 1Zero64
+Hysteresis of the danger levels per sensor, so readings hovering at a threshold don't flap between two levels
+*/
+
+// Object structure for the hysteresis state of a run
+type Hysteresis struct {
+	// Last danger level per sensor
+	levels map[int64]string
+	// Number of level changes suppressed, because the reading didn't pass the threshold by the margin
+	held int
+}
+
+/*
+Function to create the hysteresis state of a run
+@return Pointer to the empty state or nil, if HYSTERESIS_MARGIN is disabled
+*/
+func newHysteresis() *Hysteresis {
+	if hysteresisMargin <= 0 {
+		return nil
+	}
+	return &Hysteresis{levels: make(map[int64]string)}
+}
+
+/*
+Function to get the rank of a danger level in the order of severity
+@param level Danger level
+@return Index of the level from No (0) to Critical (4)
+*/
+func dangerRank(level string) int {
+	for rank, candidate := range dangerLevels {
+		if candidate == level {
+			return rank
+		}
+	}
+	return 0
+}
+
+/*
+Function to shift all thresholds by a margin
+@param thresholds Thresholds to shift
+@param margin Margin added to every threshold (negative to lower them)
+@return Shifted thresholds
+*/
+func shiftThresholds(thresholds Thresholds, margin float64) Thresholds {
+	for i := range thresholds.temperature {
+		thresholds.temperature[i] += margin
+		thresholds.humidity[i] += margin
+	}
+	return thresholds
+}
+
+/*
+Function to classify a transformed measurement with hysteresis against the previous level of its sensor.
+The level escalates only, if the readings exceed the higher threshold by the margin, and de-escalates only,
+if they drop below the lower threshold by the margin. The first measurement of a sensor keeps its plain level
+@param transformedMeasurement Transformed measurement with its plain danger level
+@return Danger level with hysteresis
+*/
+func (hysteresis *Hysteresis) classify(transformedMeasurement TransformedMeasurement) string {
+
+	// Take the plain level for the first measurement of a sensor
+	previous, found := hysteresis.levels[transformedMeasurement.sensor_id]
+	level := transformedMeasurement.danger
+	if found {
+		// Classify with the thresholds raised and lowered by the margin
+		thresholds := thresholdsFor(transformedMeasurement.event_stream)
+		escalated := classifyWithThresholds(shiftThresholds(thresholds, hysteresisMargin), transformedMeasurement.temperature, transformedMeasurement.humidity)
+		deescalated := classifyWithThresholds(shiftThresholds(thresholds, -hysteresisMargin), transformedMeasurement.temperature, transformedMeasurement.humidity)
+
+		// Change the level only beyond the margin, otherwise keep the previous one
+		switch {
+		case dangerRank(escalated) > dangerRank(previous):
+			level = escalated
+		case dangerRank(deescalated) < dangerRank(previous):
+			level = deescalated
+		default:
+			level = previous
+		}
+		if level != transformedMeasurement.danger {
+			hysteresis.held++
+		}
+	}
+
+	// Remember the level as state of the next measurement of the sensor
+	hysteresis.levels[transformedMeasurement.sensor_id] = level
+	return level
+}
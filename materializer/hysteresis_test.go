@@ -0,0 +1,58 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the hysteresis of the danger levels against readings hovering at a threshold
+*/
+
+// Importing packages
+import (
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Test that readings hovering around the Critical threshold of 10 keep a stable level, which only changes once the reading
+passes the threshold by the margin of 0.2 in either direction
+@param t Test state
+*/
+func TestHysteresisKeepsLevelWithinMargin(t *testing.T) {
+	savedMargin, savedTolerance, savedThresholds := hysteresisMargin, tolerance, thresholds
+	defer func() { hysteresisMargin, tolerance, thresholds = savedMargin, savedTolerance, savedThresholds }()
+	hysteresisMargin, tolerance, thresholds = 0.2, 0, defaultThresholds
+
+	hysteresis := newHysteresis()
+	steps := []struct {
+		temperature reading
+		want        string
+	}{
+		{9.9, High},
+		// Hovering within the margin above and below the threshold
+		{10.1, High},
+		{9.95, High},
+		{10.05, High},
+		// Escalates only beyond 10.2
+		{10.3, Critical},
+		// De-escalates only below 9.8
+		{9.9, Critical},
+		{9.7, High},
+	}
+	for i, step := range steps {
+		transformed := TransformedMeasurement{Measurement: Measurement{sensor_id: 7, temperature: step.temperature}}
+		transformed.danger = classifyWithThresholds(thresholds, step.temperature, 0)
+		if got := hysteresis.classify(transformed); got != step.want {
+			t.Errorf("step %d at %.2f: %s, want %s", i+1, step.temperature, got, step.want)
+		}
+	}
+
+	// The readings at 10.1, 10.05 and the second 9.9 differ from their plain level
+	if hysteresis.held != 3 {
+		t.Errorf("%d held level changes, want 3", hysteresis.held)
+	}
+
+	// The state is kept per sensor, the first reading of another sensor takes its plain level
+	other := TransformedMeasurement{Measurement: Measurement{sensor_id: 8, temperature: 10.1}, danger: Critical}
+	if got := hysteresis.classify(other); got != Critical {
+		t.Errorf("first reading of another sensor: %s, want %s", got, Critical)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+/*
+@author 1Zero64
+Diagnostic of the transformation of a single measurement without materializing it
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+/*
+Function to read a single measurement of the event store, transform it and print the raw values, the latency, the danger level
+and the exceeded thresholds, to answer why a measurement got its danger level
+@param db *sql.DB Database connection to Postgres database
+@param id Id of the measurement
+*/
+func inspectMeasurement(db *sql.DB, id int64) {
+
+	// Read the measurement regardless of the stream filter and sampling of the runs, restoring both afterwards
+	defer func(filter string, rate float64) {
+		streamFilter = filter
+		sampleRate = rate
+	}(streamFilter, sampleRate)
+	streamFilter = ""
+	sampleRate = 1
+	measurements := readMeasurements(db, "id = $1", id)
+
+	// Handle a non-existent id gracefully
+	if len(measurements) == 0 {
+		fmt.Printf("No measurement with id %d found in the event store\n", id)
+		return
+	}
+
+	// Transform the measurement with the pure transformation of the runs
+	transformedMeasurement := transformMeasurement(measurements[0])
+
+	// Print raw values
+	fmt.Println()
+	fmt.Printf("Measurement %d\n", transformedMeasurement.id)
+	fmt.Printf("Sensor id:\t%d\n", transformedMeasurement.sensor_id)
+	fmt.Printf("Event stream:\t%s\n", transformedMeasurement.event_stream)
+	fmt.Printf("Temperature:\t%v\n", transformedMeasurement.temperature)
+	fmt.Printf("Humidity:\t%v\n", transformedMeasurement.humidity)
+	fmt.Printf("Created on:\t%s\n", transformedMeasurement.created_on.Format(time.RFC3339Nano))
+	fmt.Printf("Processed on:\t%s\n", transformedMeasurement.processed_on.Format(time.RFC3339Nano))
+
+	// Print computed values
+	fmt.Printf("Latency:\t%v %s (%d us)\n", transformedMeasurement.latency, latencyUnit.Name, transformedMeasurement.latency_us)
+	fmt.Printf("Heat index:\t%v\n", transformedMeasurement.heat_index)
+	fmt.Printf("Danger level:\t%s\n", transformedMeasurement.danger)
+
+	// Print every threshold with the reading and whether it is exceeded including the tolerance
+	fmt.Printf("\n%-10s %22s %22s\n", "Level", "Temperature threshold", "Humidity threshold")
+	for i, level := range dangerLevels[1:] {
+		fmt.Printf("%-10s %14v %-7s %14v %-7s\n", level,
+			thresholds.temperature[i], exceededMark(transformedMeasurement.temperature, thresholds.temperature[i]),
+			thresholds.humidity[i], exceededMark(transformedMeasurement.humidity, thresholds.humidity[i]))
+	}
+	fmt.Printf("Tolerance:\t%v\n", tolerance)
+}
+
+/*
+Function to get a mark for a table cell, whether a value exceeds a threshold
+@param value Value to check
+@param threshold Threshold to compare against
+@return Mark for an exceeded threshold or an empty string
+*/
+func exceededMark(value reading, threshold float64) string {
+	if exceeds(value, threshold) {
+		return "(x)"
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package main
+
+/*
+@author 1Zero64
+Interrupt handling of the microbenchmark to print the statistics of the completed iterations
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for receiving operating system signals
+	"os/signal"
+	// Package for atomic flags shared with the signal handler
+	"sync/atomic"
+)
+
+// Object structure for the cause of a run stopped by an interrupt or SIGTERM, which is a cancellation of its context
+type InterruptError struct {
+	// Received signal
+	Signal os.Signal
+}
+
+/*
+Function to describe the received signal
+@return Error message
+*/
+func (err *InterruptError) Error() string {
+	return fmt.Sprintf("%v signal received", err.Signal)
+}
+
+/*
+Function to get the context error of the cancellation
+@return context.Canceled
+*/
+func (err *InterruptError) Unwrap() error {
+	return context.Canceled
+}
+
+/*
+Function to create a context, which is cancelled with an InterruptError on one of the given signals
+@param signals Signals to cancel the context on
+@return Context and the function to stop receiving the signals
+*/
+func notifyInterruptContext(signals ...os.Signal) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+	go func() {
+		select {
+		case receivedSignal := <-received:
+			cancel(&InterruptError{Signal: receivedSignal})
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(received)
+		cancel(nil)
+	}
+}
+
+// Enumerations for the handling of the in-flight iteration on an interrupt
+const (
+	InterruptFinish  = "finish"
+	InterruptAbandon = "abandon"
+)
+
+// Object structure for the interrupt handler of a running benchmark
+type BenchmarkInterrupt struct {
+	// Context of the iterations, cancelled on an interrupt, if the in-flight iteration is abandoned
+	ctx context.Context
+	// Function to cancel the context of the iterations with the cause of the cancellation
+	cancel context.CancelCauseFunc
+	// Flag whether an interrupt was received
+	interrupted atomic.Bool
+	// Channel closed, when the benchmark finished and the handler stops
+	done chan struct{}
+}
+
+/*
+Function to catch interrupts during a benchmark. The first interrupt stops the benchmark after (BENCHMARK_INTERRUPT=finish)
+or within (BENCHMARK_INTERRUPT=abandon) the in-flight iteration, a second one aborts immediately without statistics
+@return Pointer to the interrupt handler, stopped with stop()
+*/
+func watchBenchmarkInterrupt() *BenchmarkInterrupt {
+
+	// Initialize handler with a cancellable context for the iterations
+	interrupt := contextBenchmarkInterrupt(context.Background())
+
+	// Receive interrupts instead of terminating the process
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt)
+
+	// Handle interrupts in the background until the benchmark finished
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case <-interrupt.done:
+			return
+		case <-signals:
+		}
+
+		// Stop the benchmark after or within the in-flight iteration
+		interrupt.interrupted.Store(true)
+		if benchmarkInterruptPolicy == InterruptAbandon {
+			fmt.Println("\nInterrupted, abandoning the in-flight iteration. Interrupt again to abort immediately without statistics")
+			interrupt.cancel(&InterruptError{Signal: os.Interrupt})
+		} else {
+			fmt.Println("\nInterrupted, finishing the in-flight iteration. Interrupt again to abort immediately without statistics")
+		}
+
+		// Abort immediately on a second interrupt
+		select {
+		case <-interrupt.done:
+		case <-signals:
+			fmt.Println("\nAborted")
+			os.Exit(130)
+		}
+	}()
+
+	// Return handler
+	return interrupt
+}
+
+/*
+Function to create an interrupt handler, which stops a benchmark within the in-flight iteration, when a context is cancelled
+instead of on interrupts, like the context of a gRPC call
+@param parent Context, whose cancellation interrupts the benchmark
+@return Pointer to the interrupt handler, stopped with stop()
+*/
+func contextBenchmarkInterrupt(parent context.Context) *BenchmarkInterrupt {
+	ctx, cancel := context.WithCancelCause(parent)
+	return &BenchmarkInterrupt{ctx: ctx, cancel: cancel, done: make(chan struct{})}
+}
+
+/*
+Function to check whether the benchmark was interrupted
+@return True, if an interrupt was received or the context of the iterations was cancelled
+*/
+func (interrupt *BenchmarkInterrupt) requested() bool {
+	return interrupt.interrupted.Load() || interrupt.ctx.Err() != nil
+}
+
+/*
+Function to stop handling interrupts, so they terminate the process again
+*/
+func (interrupt *BenchmarkInterrupt) stop() {
+	close(interrupt.done)
+	interrupt.cancel(nil)
+}
@@ -0,0 +1,120 @@
+package main
+
+/*
+@author 1Zero64
+Per-iteration table of the microbenchmark with durations, throughput and phase times
+*/
+
+// Importing packages
+import (
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for aligned text columns
+	"text/tabwriter"
+)
+
+// Object structure for the results of a single microbenchmark iteration
+type IterationResult struct {
+	// Duration of the iteration in seconds
+	duration float64
+	// Number of processed datapoints
+	rows int
+	// Duration of the clean phase in seconds
+	clean float64
+	// Duration of the read phase in seconds
+	read float64
+	// Duration of the transform/write phase in seconds
+	write float64
+	// Garbage collections of the iteration (nil, if not collected)
+	gc *GCStatistics
+}
+
+/*
+Function to combine the recorded values of the microbenchmark iterations into one result per iteration
+@param durations Duration of each iteration in seconds
+@param rows Number of datapoints processed each iteration
+@param clean Clean phase durations of each iteration
+@param read Read phase durations of each iteration
+@param write Transform/write phase durations of each iteration
+@param gcStatistics Garbage collection statistics of each iteration (empty, if not collected)
+@return Results in the order of the iterations
+*/
+func iterationResults(durations []float64, rows int, clean []float64, read []float64, write []float64, gcStatistics []GCStatistics) []IterationResult {
+	results := make([]IterationResult, 0, len(durations))
+	for i, duration := range durations {
+		result := IterationResult{duration: duration, rows: rows}
+		// Phases and garbage collections of resumed iterations can be missing in older results files
+		if i < len(clean) && i < len(read) && i < len(write) {
+			result.clean, result.read, result.write = clean[i], read[i], write[i]
+		}
+		if i < len(gcStatistics) {
+			result.gc = &gcStatistics[i]
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+/*
+Function to print a row of the iteration table
+@param writer Writer aligning the columns
+@param iteration Number of the iteration, starting with 1
+@param result Results of the iteration
+*/
+func printIterationRow(writer *tabwriter.Writer, iteration int, result IterationResult) {
+
+	// Calculate the throughput, which is undefined for an empty duration
+	throughput := "n/a"
+	if result.duration > 0 {
+		throughput = fmt.Sprintf("%.0f", float64(result.rows)/result.duration)
+	}
+
+	// Print the pauses only, if garbage collection statistics were collected
+	fmt.Fprintf(writer, "%d\t%f\t%d\t%s\t%f\t%f\t%f", iteration, result.duration, result.rows, throughput, result.clean, result.read, result.write)
+	if benchmarkGCStats {
+		if result.gc != nil {
+			fmt.Fprintf(writer, "\t%d\t%f", result.gc.NumGC, result.gc.TotalPause)
+		} else {
+			fmt.Fprint(writer, "\tn/a\tn/a")
+		}
+	}
+	fmt.Fprintln(writer)
+}
+
+/*
+Function to print the results of each microbenchmark iteration as aligned table, limited to the first and last
+BENCHMARK_TABLE_LIMIT iterations for long benchmarks
+@param results Results in the order of the iterations
+*/
+func printIterationTable(results []IterationResult) {
+
+	// Print the header with the garbage collection columns, if collected
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(writer, "Iteration\tDuration (s)\tRows\tRows/s\tClean (s)\tRead (s)\tWrite (s)")
+	if benchmarkGCStats {
+		fmt.Fprint(writer, "\tGCs\tGC pause (s)")
+	}
+	fmt.Fprintln(writer)
+
+	// Print all iterations or only the first and last ones of a long benchmark
+	omitted := 0
+	for i, result := range results {
+		if benchmarkTableLimit > 0 && i >= benchmarkTableLimit && i < len(results)-benchmarkTableLimit {
+			omitted++
+			continue
+		}
+		if omitted > 0 {
+			fmt.Fprintf(writer, "...\t\t\t\t\t\t\n")
+		}
+		printIterationRow(writer, i+1, result)
+		omitted = 0
+	}
+	writer.Flush()
+
+	// Note the omitted iterations, which the export still contains
+	if benchmarkTableLimit > 0 && len(results) > 2*benchmarkTableLimit {
+		fmt.Printf("%d iterations omitted, showing the first and last %d (BENCHMARK_TABLE_LIMIT). Exports contain all iterations\n", len(results)-2*benchmarkTableLimit, benchmarkTableLimit)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+/*
+@author 1Zero64
+Streaming latency statistics accumulated during the transformation without a second query against the view
+*/
+
+// Importing packages
+import (
+	// Package for math functions
+	"math"
+	// Package for pseudo-random numbers
+	"math/rand"
+	// Package for sorting Slices
+	"sort"
+)
+
+// Number of latencies kept in the reservoir sample for the quantiles, which are exact up to this count
+const latencyReservoirSize = 10000
+
+// Object structure for the streaming statistics of the latencies of a run or an event stream
+type LatencyAccumulator struct {
+	// Number of accumulated valid latencies
+	count int
+	// Number of excluded negative latencies (processed before created)
+	negative int
+	// Number of excluded NaN or infinite latencies
+	invalid int
+	// Minimum valid latency
+	min float64
+	// Maximum valid latency
+	max float64
+	// Sum of the valid latencies
+	sum float64
+	// Uniform sample of the valid latencies for the quantiles
+	reservoir []float64
+	// Seeded random source of the reservoir sampling, so the quantiles of a run are reproducible
+	random *rand.Rand
+}
+
+/*
+Function to create an empty latency accumulator
+@return Pointer to the accumulator
+*/
+func newLatencyAccumulator() *LatencyAccumulator {
+	return &LatencyAccumulator{min: math.Inf(1), max: math.Inf(-1), random: rand.New(rand.NewSource(1))}
+}
+
+/*
+Function to add a latency, counting negative and non-finite ones separately instead of including them
+@param latency Latency in the latency unit
+*/
+func (accumulator *LatencyAccumulator) add(latency float64) {
+
+	// Exclude invalid latencies from the statistics
+	if !isFinite(latency) {
+		accumulator.invalid++
+		return
+	}
+	if latency < 0 {
+		accumulator.negative++
+		return
+	}
+
+	// Update count, extremes and sum
+	accumulator.count++
+	accumulator.min = math.Min(accumulator.min, latency)
+	accumulator.max = math.Max(accumulator.max, latency)
+	accumulator.sum += latency
+
+	// Keep every latency in the reservoir until it's full, then replace a random one with decreasing probability
+	if len(accumulator.reservoir) < latencyReservoirSize {
+		accumulator.reservoir = append(accumulator.reservoir, latency)
+	} else if index := accumulator.random.Intn(accumulator.count); index < latencyReservoirSize {
+		accumulator.reservoir[index] = latency
+	}
+}
+
+/*
+Function to get the mean of the valid latencies
+@return Mean (0 without valid latencies)
+*/
+func (accumulator *LatencyAccumulator) mean() float64 {
+	if accumulator.count == 0 {
+		return 0
+	}
+	return accumulator.sum / float64(accumulator.count)
+}
+
+/*
+Function to get the extremes of the valid latencies
+@return Minimum and maximum (0 without valid latencies)
+*/
+func (accumulator *LatencyAccumulator) extremes() (float64, float64) {
+	if accumulator.count == 0 {
+		return 0, 0
+	}
+	return accumulator.min, accumulator.max
+}
+
+/*
+Function to estimate quantiles of the valid latencies from the reservoir sample
+@param quantiles Quantiles between 0 and 1
+@return Estimated quantiles in the same order (exact up to latencyReservoirSize latencies)
+*/
+func (accumulator *LatencyAccumulator) quantiles(quantiles ...float64) []float64 {
+	sorted := append([]float64(nil), accumulator.reservoir...)
+	sort.Float64s(sorted)
+	values := make([]float64, 0, len(quantiles))
+	for _, q := range quantiles {
+		values = append(values, quantile(sorted, q))
+	}
+	return values
+}
@@ -0,0 +1,194 @@
+package main
+
+/*
+@author 1Zero64
+Statistics of the event latency stored in the materialized view
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for reading and writing CSV files
+	"encoding/csv"
+	// Package for encoding JSON
+	"encoding/json"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for converting strings to numbers
+	"strconv"
+	// Package for string manipulation
+	"strings"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Object structure for the latency statistics of all or a single event stream
+type LatencyStatistics struct {
+	// Event stream of the statistics ("all" for the overall statistics)
+	Stream string `json:"stream"`
+	// Number of measurements with a valid latency
+	Count int64 `json:"count"`
+	// Minimum latency in the unit of the report
+	Min float64 `json:"min"`
+	// Maximum latency in the unit of the report
+	Max float64 `json:"max"`
+	// Mean latency in the unit of the report
+	Mean float64 `json:"mean"`
+	// Median latency in the unit of the report
+	Median float64 `json:"median"`
+	// 95th percentile of the latency in the unit of the report
+	P95 float64 `json:"p95"`
+	// 99th percentile of the latency in the unit of the report
+	P99 float64 `json:"p99"`
+	// Standard deviation of the latency in the unit of the report
+	StdDev float64 `json:"stddev"`
+}
+
+// Object structure for the latency report with the invalid latencies excluded from the statistics
+type LatencyReport struct {
+	// Statistics overall and per event stream
+	Statistics []LatencyStatistics `json:"statistics"`
+	// Number of measurements with a negative latency
+	Negative int64 `json:"negative"`
+	// Number of measurements without latency
+	Null int64 `json:"null"`
+	// Number of measurements with a NaN or infinite latency
+	NonFinite int64 `json:"non_finite"`
+	// Unit of the latencies recorded on the view (falling back to the LATENCY_UNIT)
+	Unit string `json:"unit"`
+	// Version and build information of the binary
+	Build BuildInfo `json:"build"`
+}
+
+/*
+Function to compute the latency statistics of the materialized view overall and per event stream.
+Negative, NULL, NaN and infinite latencies are excluded from the statistics and counted separately
+@param db *sql.DB Database connection to Postgres database
+@param from Optional start of the created_on range (inclusive, zero time for no limit)
+@param to Optional end of the created_on range (exclusive, zero time for no limit)
+@return Latency report
+*/
+func computeLatencyStatistics(db *sql.DB, from time.Time, to time.Time) LatencyReport {
+
+	// Build created_on range condition
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	if !from.IsZero() {
+		args = append(args, from)
+		conditions = append(conditions, fmt.Sprintf("created_on >= $%d", len(args)))
+	}
+	if !to.IsZero() {
+		args = append(args, to)
+		conditions = append(conditions, fmt.Sprintf("created_on < $%d", len(args)))
+	}
+	rangeCondition := "TRUE"
+	if len(conditions) > 0 {
+		rangeCondition = strings.Join(conditions, " AND ")
+	}
+
+	// Initialize report with the unit the view was written in
+	var report LatencyReport
+	unit := viewLatencyUnit(db)
+	report.Unit = unit.Name
+
+	// Read the latency of both representations, preferring the exact latency_us
+	source := "(SELECT event_stream, created_on, " + latencyExpression(unit) + " AS latency FROM materialized_view) view"
+
+	// Count invalid latencies in the range, Postgres sorts NaN above Infinity
+	err := db.QueryRow("SELECT COUNT(*) FILTER (WHERE latency < 0 AND latency > '-Infinity'), COUNT(*) FILTER (WHERE latency IS NULL), "+
+		"COUNT(*) FILTER (WHERE latency IN ('NaN', 'Infinity', '-Infinity')) FROM "+source+" WHERE "+rangeCondition, args...).
+		Scan(&report.Negative, &report.Null, &report.NonFinite)
+	checkError(err)
+
+	// Compute statistics of valid latencies overall and per event stream with grouping sets
+	rows, err := db.Query(`SELECT COALESCE(event_stream, ''), GROUPING(event_stream), COUNT(latency),
+		COALESCE(MIN(latency), 0), COALESCE(MAX(latency), 0), COALESCE(AVG(latency), 0),
+		COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY latency), 0),
+		COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY latency), 0),
+		COALESCE(percentile_cont(0.99) WITHIN GROUP (ORDER BY latency), 0),
+		COALESCE(stddev_pop(latency), 0)
+		FROM `+source+` WHERE latency >= 0 AND latency < 'Infinity' AND `+rangeCondition+`
+		GROUP BY GROUPING SETS ((), (event_stream))
+		ORDER BY GROUPING(event_stream) DESC, event_stream`, args...)
+	checkError(err)
+
+	// Close rows object later, when surrounding function returns
+	defer rows.Close()
+
+	// Collect statistics
+	for rows.Next() {
+		var statistics LatencyStatistics
+		var overall int
+		err = rows.Scan(&statistics.Stream, &overall, &statistics.Count, &statistics.Min, &statistics.Max, &statistics.Mean,
+			&statistics.Median, &statistics.P95, &statistics.P99, &statistics.StdDev)
+		checkError(err)
+		if overall == 1 {
+			statistics.Stream = "all"
+		}
+		report.Statistics = append(report.Statistics, statistics)
+	}
+	checkError(rows.Err())
+
+	// Return report
+	return report
+}
+
+/*
+Function to print the latency report as a table
+@param report Latency report to print
+*/
+func printLatencyReport(report LatencyReport) {
+
+	// Print header and a row per statistics
+	fmt.Println()
+	unit := " (" + report.Unit + ")"
+	fmt.Printf("%-20s %10s %12s %12s %12s %12s %12s %12s %12s\n", "Event stream", "Count", "Min"+unit, "Max"+unit, "Mean"+unit, "Median"+unit, "p95"+unit, "p99"+unit, "Stddev"+unit)
+	for _, statistics := range report.Statistics {
+		fmt.Printf("%-20s %10d %12.3f %12.3f %12.3f %12.3f %12.3f %12.3f %12.3f\n", statistics.Stream, statistics.Count,
+			statistics.Min, statistics.Max, statistics.Mean, statistics.Median, statistics.P95, statistics.P99, statistics.StdDev)
+	}
+
+	// Print excluded latencies
+	fmt.Printf("\nExcluded: %d negative latencies, %d NULL latencies, %d NaN or infinite latencies\n", report.Negative, report.Null, report.NonFinite)
+}
+
+/*
+Function to export the latency report as CSV or JSON file, depending on the file extension
+@param report Latency report to export
+@param path Path of the .csv or .json file
+*/
+func exportLatencyReport(report LatencyReport, path string) {
+
+	// Create export file and check on error with handler
+	file, err := os.Create(path)
+	checkError(err)
+
+	// Close file later, when surrounding function returns
+	defer file.Close()
+
+	// Write JSON document
+	if strings.HasSuffix(path, ".json") {
+		report.Build = buildInfo()
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		checkError(encoder.Encode(report))
+		return
+	}
+
+	// Write CSV with a row per statistics and the excluded latencies as extra columns
+	writer := csv.NewWriter(file)
+	checkError(writer.Write([]string{"stream", "count", "min", "max", "mean", "median", "p95", "p99", "stddev", "negative", "null", "non_finite", "unit"}))
+	for _, statistics := range report.Statistics {
+		record := []string{statistics.Stream, strconv.FormatInt(statistics.Count, 10)}
+		for _, value := range []float64{statistics.Min, statistics.Max, statistics.Mean, statistics.Median, statistics.P95, statistics.P99, statistics.StdDev} {
+			record = append(record, strconv.FormatFloat(value, 'f', -1, 64))
+		}
+		record = append(record, strconv.FormatInt(report.Negative, 10), strconv.FormatInt(report.Null, 10), strconv.FormatInt(report.NonFinite, 10), report.Unit)
+		checkError(writer.Write(record))
+	}
+	writer.Flush()
+	checkError(writer.Error())
+}
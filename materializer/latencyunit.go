@@ -0,0 +1,134 @@
+package main
+
+/*
+@author 1Zero64
+Unit of the latency written to the materialized view and shown in all outputs
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for string manipulation
+	"strings"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Prefix of the comment on the latency column, which records the unit of the stored latencies
+const latencyUnitCommentPrefix = "latency_unit="
+
+// Object structure for a unit of the latency
+type LatencyUnit struct {
+	// Short name of the unit for labels and metadata (ms, us or s)
+	Name string
+	// Number of nanoseconds per unit
+	nanoseconds float32
+}
+
+// Supported latency units by their short names
+var latencyUnits = map[string]LatencyUnit{
+	"ms": {Name: "ms", nanoseconds: 1000000},
+	"us": {Name: "us", nanoseconds: 1000},
+	"s":  {Name: "s", nanoseconds: 1000000000},
+}
+
+// Unit of the latency in the view and the outputs (milliseconds by default)
+var latencyUnit = latencyUnits["ms"]
+
+/*
+Function to parse a latency unit by its short or long name
+@param value Name of the unit (ms/milliseconds, us/microseconds or s/seconds)
+@return Latency unit and an error for unknown names
+*/
+func parseLatencyUnit(value string) (LatencyUnit, error) {
+
+	// Map the long names to the short ones
+	name := strings.ToLower(strings.TrimSpace(value))
+	switch name {
+	case "milliseconds":
+		name = "ms"
+	case "microseconds", "µs":
+		name = "us"
+	case "seconds":
+		name = "s"
+	}
+	unit, found := latencyUnits[name]
+	if !found {
+		return LatencyUnit{}, fmt.Errorf("unknown latency unit %q, expected ms, us or s", value)
+	}
+	return unit, nil
+}
+
+/*
+Function to convert a duration into the latency unit
+@param duration Duration between creation and processing
+@return Latency in the unit, divided in single precision
+*/
+func (unit LatencyUnit) latency(duration time.Duration) float32 {
+	return float32(duration) / unit.nanoseconds
+}
+
+/*
+Function to convert a value in milliseconds into the latency unit, for settings given in milliseconds
+@param milliseconds Value in milliseconds
+@return Value in the unit
+*/
+func (unit LatencyUnit) fromMilliseconds(milliseconds float64) float64 {
+	return milliseconds * 1000000 / float64(unit.nanoseconds)
+}
+
+/*
+Function to get the recorded latency unit of the latency column of a table from its column comment
+@param db *sql.DB Database connection to Postgres database
+@param table Name of the table
+@return Short name of the unit (empty, if none is recorded)
+*/
+func recordedLatencyUnit(db *sql.DB, table string) string {
+	var comment sql.NullString
+	err := db.QueryRow("SELECT col_description(attrelid, attnum) FROM pg_attribute WHERE attrelid = to_regclass($1) AND attname = 'latency'", table).Scan(&comment)
+	if err == sql.ErrNoRows {
+		return ""
+	}
+	checkError(err)
+	return strings.TrimPrefix(comment.String, latencyUnitCommentPrefix)
+}
+
+/*
+Function to get the unit of the float latency column of the materialized view
+@param db *sql.DB Database connection to Postgres database
+@return Recorded unit of the view or the LATENCY_UNIT, if none or an unknown one is recorded
+*/
+func viewLatencyUnit(db *sql.DB) LatencyUnit {
+	if unit, found := latencyUnits[recordedLatencyUnit(db, "materialized_view")]; found {
+		return unit
+	}
+	return latencyUnit
+}
+
+/*
+Function to record the latency unit in a comment on the latency column of a table,
+warning loudly, if the table was written with another unit before
+@param db *sql.DB Database connection to Postgres database
+@param table Name of the table
+*/
+func recordLatencyUnit(db *sql.DB, table string) {
+
+	// Warn about a changed unit, whose old latencies would be misinterpreted in appended or compared runs
+	if recorded := recordedLatencyUnit(db, table); recorded != "" && recorded != latencyUnit.Name {
+		fmt.Fprintln(os.Stderr, strings.Repeat("!", 80))
+		fmt.Fprintf(os.Stderr, "WARNING: the latency of %s was written in %s before, LATENCY_UNIT is now %s.\n", table, recorded, latencyUnit.Name)
+		if appendOnly {
+			fmt.Fprintln(os.Stderr, "The view isn't cleaned, so it will contain latencies in both units!")
+		}
+		fmt.Fprintln(os.Stderr, strings.Repeat("!", 80))
+	}
+
+	// Record the unit in effect on the column
+	_, err := db.Exec(fmt.Sprintf("COMMENT ON COLUMN %s.latency IS '%s%s'", table, latencyUnitCommentPrefix, latencyUnit.Name))
+	checkError(err)
+}
@@ -0,0 +1,59 @@
+package main
+
+/*
+@author 1Zero64
+Exact latency in bigint microseconds next to the float latency column during the transition
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+)
+
+// SQL expression of the exact latency in microseconds, Postgres timestamps have microsecond precision
+const latencyMicrosecondsExpression = "(EXTRACT(EPOCH FROM processed_on - created_on) * 1000000)::bigint"
+
+/*
+Function to get the value of the float latency column of a transformed measurement
+@param transformedMeasurement Transformed measurement
+@return Latency in the latency unit or nil, if the float column isn't maintained anymore
+*/
+func floatLatencyValue(transformedMeasurement TransformedMeasurement) interface{} {
+	if !latencyFloatColumn {
+		return nil
+	}
+	return transformedMeasurement.latency
+}
+
+/*
+Function to build the SQL expression of the latency of view rows in a unit, which prefers the exact latency_us
+and falls back to the float column for rows written before latency_us existed
+@param unit Unit of the float latency column and of the result
+@return SQL expression of the latency in the unit
+*/
+func latencyExpression(unit LatencyUnit) string {
+	return fmt.Sprintf("COALESCE(latency_us::float8 * 1000 / %d, latency)", int64(unit.nanoseconds))
+}
+
+/*
+Function to backfill latency_us of existing rows of the materialized view from their timestamps
+@param db *sql.DB Database connection to Postgres database
+*/
+func migrateLatencyMicroseconds(db *sql.DB) {
+
+	// Compute the exact latency of all rows without one
+	fmt.Println("Backfilling latency_us of the materialized view...")
+	result, err := db.Exec("UPDATE materialized_view SET latency_us = " + latencyMicrosecondsExpression + " WHERE latency_us IS NULL")
+	checkError(err)
+
+	// Print number of backfilled rows
+	migrated, err := result.RowsAffected()
+	checkError(err)
+	fmt.Printf("Backfilled latency_us of %d rows\n", migrated)
+	if !latencyFloatColumn {
+		fmt.Println("LATENCY_FLOAT_COLUMN is disabled, the float latency column can be dropped once no dashboard reads it anymore")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+/*
+@author 1Zero64
+Leader election of change data capture replicas on the advisory lock of the view, so only one replica consumes and writes the changes
+*/
+
+// Importing packages
+import (
+	// Package for in-memory byte buffers
+	"bytes"
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Object structure for the leadership state of a replica
+type LeaderElection struct {
+	// Flag whether this replica is the leader
+	leader bool
+	// Number of leadership changes of this replica, acquired and lost
+	changes int
+}
+
+/*
+Function to print a leadership change with its time
+@param message Description of the change
+*/
+func logLeadership(message string) {
+	fmt.Printf("%s Leader election (pid %d): %s\n", nowFunc().Format(time.RFC3339), os.Getpid(), message)
+}
+
+/*
+Function to follow the leader until this replica takes over the lock of the view. The lock is retried every LEADER_POLL_INTERVAL,
+so a follower takes over within this interval after the session of the leader ended
+@param ctx Context of the consumer, following stops when it's done
+@param db *sql.DB Database connection to Postgres database
+@param applied Number of changes applied by this replica since the start
+@return Pointer to the lock of the leader or nil, if the consumer was stopped
+*/
+func (election *LeaderElection) follow(ctx context.Context, db *sql.DB, applied int) *MaterializeLock {
+	announced := false
+	for {
+		// Become the leader, if the lock is free
+		if lock := tryMaterializeLock(db, "materialized_view"); lock != nil {
+			election.leader = true
+			election.changes++
+			logLeadership("acquired leadership, consuming the changes")
+			return lock
+		}
+
+		// Name the leader once and export the follower state
+		if !announced {
+			logLeadership(fmt.Sprintf("following the leader (%s), retrying every %s", lockHolders(db, lockKey("materialized_view")), leaderPollInterval))
+			announced = true
+		}
+		if promFile != "" {
+			writeCdcPrometheusFile(promFile, applied, nil, election)
+		}
+
+		// Wait for the next attempt
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(leaderPollInterval):
+		}
+	}
+}
+
+/*
+Function to verify the leadership before a write on the session of the lock, which also executes the writes, so a replica,
+that lost its session, can't write anymore. A lost lock is abandoned and the replica becomes a follower
+@param lock Lock of the leader
+@return Error, if the leadership is lost
+*/
+func (election *LeaderElection) verify(lock *MaterializeLock) error {
+
+	// Check the lock on its session within the poll interval, so a hanging connection doesn't delay the follower state
+	ctx, cancel := context.WithTimeout(context.Background(), leaderPollInterval)
+	defer cancel()
+	err := lock.verify(ctx)
+	if err != nil {
+		election.leader = false
+		election.changes++
+		logLeadership(fmt.Sprintf("lost leadership (%v), stopped writing", err))
+		lock.abandon()
+	}
+	return err
+}
+
+/*
+Function to add the leadership metrics of a replica to the metrics in exposition format
+@param buffer Buffer of the metrics
+*/
+func (election *LeaderElection) writeMetrics(buffer *bytes.Buffer) {
+	leader := 0
+	if election.leader {
+		leader = 1
+	}
+	fmt.Fprintln(buffer, "# HELP materializer_cdc_leader Whether this replica is the leader consuming the changes (1) or a follower (0).")
+	fmt.Fprintln(buffer, "# TYPE materializer_cdc_leader gauge")
+	fmt.Fprintf(buffer, "materializer_cdc_leader{slot=%s} %d\n", prometheusLabel(cdcSlot), leader)
+	fmt.Fprintln(buffer, "# HELP materializer_cdc_leadership_changes_total Number of times this replica acquired or lost the leadership.")
+	fmt.Fprintln(buffer, "# TYPE materializer_cdc_leadership_changes_total counter")
+	fmt.Fprintf(buffer, "materializer_cdc_leadership_changes_total{slot=%s} %d\n", prometheusLabel(cdcSlot), election.changes)
+}
@@ -0,0 +1,58 @@
+package main
+
+/*
+@author 1Zero64
+Locale of the numbers in the printed summaries, e.g. decimal commas and thousands separators for German formatting
+*/
+
+// Importing packages
+import (
+	// Package for formatted printing
+	"fmt"
+
+	// Package for language tags
+	"golang.org/x/text/language"
+	// Package for printing numbers in the format of a language
+	"golang.org/x/text/message"
+)
+
+// Printer of the OUTPUT_LOCALE (nil for the plain formatting)
+var localePrinter *message.Printer
+
+/*
+Function to create the printer of a locale
+@param locale Language tag of the locale, e.g. de or en-US (empty for the plain formatting)
+@return Printer of the locale (nil for the plain formatting) or an error for an invalid tag
+*/
+func newLocalePrinter(locale string) (*message.Printer, error) {
+	if locale == "" {
+		return nil, nil
+	}
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return nil, err
+	}
+	return message.NewPrinter(tag), nil
+}
+
+/*
+Function to format values with the numbers in the OUTPUT_LOCALE
+@param format Format like fmt.Sprintf
+@param args Values to format
+@return Formatted text with localized decimal and thousands separators
+*/
+func localeSprintf(format string, args ...interface{}) string {
+	if localePrinter == nil {
+		return fmt.Sprintf(format, args...)
+	}
+	return localePrinter.Sprintf(format, args...)
+}
+
+/*
+Function to print values with the numbers in the OUTPUT_LOCALE
+@param format Format like fmt.Printf
+@param args Values to print
+*/
+func localePrintf(format string, args ...interface{}) {
+	fmt.Print(localeSprintf(format, args...))
+}
@@ -0,0 +1,55 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the locale of the numbers in the printed summaries
+*/
+
+// Importing packages
+import (
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Test the decimal and thousands separators of the English and German locales and of the plain formatting
+@param t Test state
+*/
+func TestLocaleSprintf(t *testing.T) {
+	saved := localePrinter
+	defer func() { localePrinter = saved }()
+
+	cases := []struct {
+		locale string
+		want   string
+	}{
+		{"", "1234.5 1234567"},
+		{"en", "1,234.5 1,234,567"},
+		{"en-US", "1,234.5 1,234,567"},
+		{"de", "1.234,5 1.234.567"},
+		{"de-DE", "1.234,5 1.234.567"},
+	}
+	for _, testCase := range cases {
+		printer, err := newLocalePrinter(testCase.locale)
+		if err != nil {
+			t.Errorf("newLocalePrinter(%q): %v", testCase.locale, err)
+			continue
+		}
+		localePrinter = printer
+		if got := localeSprintf("%.1f %d", 1234.5, 1234567); got != testCase.want {
+			t.Errorf("%q: %s, want %s", testCase.locale, got, testCase.want)
+		}
+	}
+}
+
+/*
+Test that malformed and unknown language tags are refused
+@param t Test state
+*/
+func TestNewLocalePrinterRefusesUnknownLocale(t *testing.T) {
+	for _, locale := range []string{"zz", "klingon", "de_DE!"} {
+		if printer, err := newLocalePrinter(locale); err == nil || printer != nil {
+			t.Errorf("newLocalePrinter(%q) = %v, %v, want an error", locale, printer, err)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+/*
+@author 1Zero64
+Advisory lock per target table, so concurrent materializer instances (e.g. a cron run and a manual run) don't interleave their writes
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package with the interfaces of the database drivers
+	"database/sql/driver"
+	// Package for formatted printing
+	"fmt"
+	// Package for non-cryptographic hash functions
+	"hash/fnv"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Interval between two attempts to take a held lock within MATERIALIZE_LOCK_TIMEOUT
+const lockPollInterval = time.Second
+
+// Object structure for an advisory lock held by this process
+type MaterializeLock struct {
+	// Target table of the lock
+	table string
+	// Key of the advisory lock
+	key int64
+	// Dedicated connection of the session holding the lock
+	conn *sql.Conn
+	// Separate pool of the connection outside of the capped pool of the operation (nil for a connection of its pool)
+	pool *sql.DB
+	// Flag whether the lock of an outer operation was passed down to a nested operation, which doesn't release it
+	nested bool
+}
+
+// Object structure for the error of a lock held by another session
+type LockHeldError struct {
+	// Target table of the lock
+	Table string
+	// Description of the sessions holding the lock
+	Holders string
+}
+
+/*
+Function to describe the lock held by another session
+@return Error message with the holders
+*/
+func (err *LockHeldError) Error() string {
+	return fmt.Sprintf("another materializer holds the lock on %s (%s), set MATERIALIZE_LOCK_TIMEOUT to wait for it", err.Table, err.Holders)
+}
+
+/*
+Function to derive the key of the advisory lock of a target table
+@param table Name of the target table
+@return Key of the lock
+*/
+func lockKey(table string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte("materializer:" + table))
+	return int64(hash.Sum64())
+}
+
+/*
+Function to describe the sessions holding the advisory lock of a key
+@param db *sql.DB Database connection to Postgres database
+@param key Key of the lock
+@return Pids, clients and start times of the holders or a note, if they aren't visible
+*/
+func lockHolders(db *sql.DB, key int64) string {
+
+	// Find the holding sessions, a bigint key is split into the high (classid) and low (objid) 32 bits
+	rows, err := db.Query(`SELECT l.pid, COALESCE(a.application_name, ''), COALESCE(a.client_addr::text, 'local'), a.backend_start
+		FROM pg_locks l LEFT JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory' AND l.granted AND l.classid = $1::bigint::oid AND l.objid = $2::bigint::oid AND l.objsubid = 1`,
+		int64(uint32(key>>32)), int64(uint32(key)))
+	if err != nil {
+		return fmt.Sprintf("holder unknown: %v", err)
+	}
+	defer rows.Close()
+
+	// Describe every holder
+	holders := ""
+	for rows.Next() {
+		var pid int64
+		var application, client string
+		var started sql.NullTime
+		checkError(rows.Scan(&pid, &application, &client, &started))
+		if holders != "" {
+			holders += "; "
+		}
+		holders += fmt.Sprintf("pid %d, application %q, client %s", pid, application, client)
+		if started.Valid {
+			holders += ", connected since " + started.Time.Format(time.RFC3339)
+		}
+	}
+	if holders == "" {
+		return "holder not visible"
+	}
+	return holders
+}
+
+/*
+Function to take the advisory lock of a target table for a mutating operation. The session-level lock is held on a dedicated
+connection, so it is released with the connection, when the process exits or is killed. A held lock is retried until
+MATERIALIZE_LOCK_TIMEOUT, afterwards a LockHeldError naming the holders aborts the operation. Only a nested operation, which
+got the lock of the table passed down from its outer operation, takes it again, any other operation of this process waits for
+it like another session
+@param db *sql.DB Database connection to Postgres database
+@param table Name of the target table
+@param held Locks held by the outer operations
+@return Pointer to the lock to release, when the operation finishes
+*/
+func acquireMaterializeLock(db *sql.DB, table string, held ...*MaterializeLock) *MaterializeLock {
+
+	// Take the lock of an outer operation on the same table again
+	for _, outer := range held {
+		if outer != nil && outer.table == table {
+			return &MaterializeLock{table: table, key: outer.key, conn: outer.conn, nested: true}
+		}
+	}
+
+	// Try to take the lock until the timeout
+	deadline := time.Now().Add(materializeLockTimeout)
+	waiting := false
+	for {
+		if lock := tryMaterializeLock(db, table); lock != nil {
+			return lock
+		}
+
+		// Give up at the timeout, otherwise name the holders once and wait
+		holders := lockHolders(db, lockKey(table))
+		if !time.Now().Before(deadline) {
+			checkError(&LockHeldError{Table: table, Holders: holders})
+		}
+		if !waiting {
+			fmt.Printf("Waiting up to %s for the lock on %s held by %s\n", materializeLockTimeout, table, holders)
+			waiting = true
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+/*
+Function to take the advisory lock of a target table without waiting for another session holding it
+@param db *sql.DB Database connection to Postgres database
+@param table Name of the target table
+@return Pointer to the lock to release or nil, if another session holds it
+*/
+func tryMaterializeLock(db *sql.DB, table string) *MaterializeLock {
+
+	// Open the dedicated connection of the lock session in a separate pool, so it doesn't take one of DB_MAX_OPEN_CONNS
+	// from the operation for its whole duration
+	lock := &MaterializeLock{table: table, key: lockKey(table), pool: openSeparatePool(db)}
+	pool := db
+	if lock.pool != nil {
+		pool = lock.pool
+	}
+	conn, err := pool.Conn(context.Background())
+	if err != nil {
+		lock.closePool()
+		checkError(err)
+	}
+	lock.conn = conn
+
+	// Try to take the lock, the connection is returned to the pool, if it's held by another session
+	var acquired bool
+	err = conn.QueryRowContext(context.Background(), "SELECT pg_try_advisory_lock($1)", lock.key).Scan(&acquired)
+	if err != nil {
+		discardConnection(conn)
+		lock.closePool()
+		checkError(err)
+	}
+	if !acquired {
+		conn.Close()
+		lock.closePool()
+		return nil
+	}
+	return lock
+}
+
+/*
+Function to verify on the session of the lock, that it still holds the lock
+@param ctx Context limiting the time of the check
+@return Error, if the session is gone or doesn't hold the lock anymore
+*/
+func (lock *MaterializeLock) verify(ctx context.Context) error {
+	var held bool
+	err := lock.conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pg_locks WHERE locktype = 'advisory' AND granted AND pid = pg_backend_pid()
+		AND classid = $1::bigint::oid AND objid = $2::bigint::oid AND objsubid = 1)`, int64(uint32(lock.key>>32)), int64(uint32(lock.key))).Scan(&held)
+	if err != nil {
+		return err
+	}
+	if !held {
+		return fmt.Errorf("the session doesn't hold the lock on %s anymore", lock.table)
+	}
+	return nil
+}
+
+/*
+Function to release the advisory lock after a mutating operation, also when it panicked. A nested operation keeps the lock
+for its outer operation
+*/
+func (lock *MaterializeLock) release() {
+
+	// Keep the lock for the outer operation
+	if lock.nested {
+		return
+	}
+
+	// Unlock the session and return its connection to the pool
+	_, err := lock.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lock.key)
+	if err != nil {
+		fmt.Printf("Warning: could not unlock %s (%v), closing the connection to release the lock with its session\n", lock.table, err)
+		discardConnection(lock.conn)
+		lock.closePool()
+		return
+	}
+	lock.conn.Close()
+	lock.closePool()
+}
+
+/*
+Function to give up a lock, whose session is gone or lost it, without unlocking it. The connection is discarded,
+so a still open session releases the lock with it
+*/
+func (lock *MaterializeLock) abandon() {
+	if lock.nested {
+		return
+	}
+	discardConnection(lock.conn)
+	lock.closePool()
+}
+
+/*
+Function to close the separate pool of the lock session, if it has one
+*/
+func (lock *MaterializeLock) closePool() {
+	if lock.pool != nil {
+		lock.pool.Close()
+	}
+}
+
+/*
+Function to close the physical connection of a session instead of returning it to the pool, which ends its session-level locks
+@param conn Dedicated connection to discard
+*/
+func discardConnection(conn *sql.Conn) {
+	conn.Raw(func(interface{}) error {
+		return driver.ErrBadConn
+	})
+	conn.Close()
+}
@@ -0,0 +1,240 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the advisory lock against a Postgres database given by TEST_DATABASE_URL
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for inspecting errors
+	"errors"
+	// Package for formatted printing
+	"fmt"
+	// Package for operating system functionality
+	"os"
+	// Package for string manipulation
+	"strings"
+	// Package for automated tests
+	"testing"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+/*
+Function to open the test database, the test is skipped without TEST_DATABASE_URL
+@param t Test state
+@return Database handle
+*/
+func openTestDatabase(t *testing.T) *sql.DB {
+	return openTestDatabaseWithDriver(t, DriverPq)
+}
+
+/*
+Function to open the test database with the given driver, the test is skipped without TEST_DATABASE_URL
+@param t Test state
+@param driver Name of the database driver
+@return Database handle
+*/
+func openTestDatabaseWithDriver(t *testing.T, driver string) *sql.DB {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := sql.Open(driver, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerDataSource(db, driver, url)
+	t.Cleanup(func() { db.Close() })
+	if err = db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+/*
+Test two competing runs: while another session holds the lock of a table, taking it fails without a timeout with a
+LockHeldError naming the pid of the holder, and succeeds after the holder released it
+@param t Test state
+*/
+func TestAcquireMaterializeLockHeldByAnotherSession(t *testing.T) {
+	db := openTestDatabase(t)
+	savedTimeout := materializeLockTimeout
+	defer func() { materializeLockTimeout = savedTimeout }()
+	materializeLockTimeout = 0
+	table := "materialized_view_lock_test"
+
+	// Take the lock in the session of the first run
+	holder, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer holder.Close()
+	var pid int64
+	if err = holder.QueryRowContext(context.Background(), "SELECT pg_backend_pid()").Scan(&pid); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = holder.ExecContext(context.Background(), "SELECT pg_advisory_lock($1)", lockKey(table)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second run fails with the holder named
+	failure := func() (recovered interface{}) {
+		defer func() { recovered = recover() }()
+		acquireMaterializeLock(db, table).release()
+		return nil
+	}()
+	err, _ = failure.(error)
+	var held *LockHeldError
+	if !errors.As(err, &held) {
+		t.Fatalf("acquireMaterializeLock panicked with %v, want a LockHeldError", failure)
+	}
+	if held.Table != table || !strings.Contains(held.Holders, fmt.Sprintf("pid %d,", pid)) {
+		t.Errorf("LockHeldError = %q, want table %s held by pid %d", held.Error(), table, pid)
+	}
+
+	// After the first run released the lock, the second takes it
+	if _, err = holder.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey(table)); err != nil {
+		t.Fatal(err)
+	}
+	lock := acquireMaterializeLock(db, table)
+	if err = lock.verify(context.Background()); err != nil {
+		t.Error(err)
+	}
+	lock.release()
+}
+
+/*
+Test that a materialize run with a connection pool capped at a single connection doesn't hang, as the session of the lock
+is opened outside of the pool
+@param t Test state
+*/
+func TestMaterializeWithSingleConnectionPool(t *testing.T) {
+	db := openTestDatabase(t)
+	useDatabaseRun(t)
+	createTestEventStore(t, db, pushdownFixture)
+	db.SetMaxOpenConns(1)
+
+	// Run in the background to fail instead of hanging
+	done := make(chan interface{}, 1)
+	go func() {
+		defer func() { done <- recover() }()
+		captureStdout(t, func() { materialize(context.Background(), db, "test", nil) })
+	}()
+	select {
+	case failure := <-done:
+		if failure != nil {
+			t.Fatalf("materialize panicked with %v", failure)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("materialize with DB_MAX_OPEN_CONNS=1 hangs")
+	}
+	if rows := len(dumpMaterializedView(t, db)); rows != 9 {
+		t.Errorf("%d rows materialized, want all 9 of the fixture", rows)
+	}
+}
+
+/*
+Test that only a nested operation, which got the lock passed down, takes it again and keeps it for the outer operation, while an
+independent operation of the same process is excluded like another session
+@param t Test state
+*/
+func TestNestedAcquireOnlyWithPassedLock(t *testing.T) {
+	db := openTestDatabase(t)
+	savedTimeout := materializeLockTimeout
+	defer func() { materializeLockTimeout = savedTimeout }()
+	materializeLockTimeout = 0
+	table := "materialized_view_lock_test"
+
+	outer := acquireMaterializeLock(db, table)
+	defer outer.release()
+
+	// The nested operation shares the session of the outer one and its release keeps the lock
+	nested := acquireMaterializeLock(db, table, outer)
+	if nested.conn != outer.conn {
+		t.Error("nested lock has its own session, want the one of the outer lock")
+	}
+	nested.release()
+	if err := outer.verify(context.Background()); err != nil {
+		t.Errorf("outer lock after the nested release: %v", err)
+	}
+
+	// An independent operation of this process doesn't take the held lock
+	if message := expectFailure(t, func() { acquireMaterializeLock(db, table).release() }); !strings.Contains(message, "holds the lock on "+table) {
+		t.Errorf("independent acquire failed with %q, want a LockHeldError", message)
+	}
+	if lock := tryMaterializeLock(db, table); lock != nil {
+		lock.release()
+		t.Error("tryMaterializeLock took the lock held by another operation of this process")
+	}
+}
+
+/*
+Function to check, that another session can take the lock of a table, and to release it again
+@param t Test state
+@param db Database handle
+@param table Name of the target table
+*/
+func expectLockFree(t *testing.T, db *sql.DB, table string) {
+	t.Helper()
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var acquired bool
+	if err = conn.QueryRowContext(context.Background(), "SELECT pg_try_advisory_lock($1)", lockKey(table)).Scan(&acquired); err != nil {
+		t.Fatal(err)
+	}
+	if !acquired {
+		t.Fatalf("another session can't take the lock on %s, held by %s", table, lockHolders(db, lockKey(table)))
+	}
+	if _, err = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey(table)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+/*
+Test that a materialize run, which panics while holding the lock of the view, releases it for a competing run
+@param t Test state
+*/
+func TestMaterializeReleasesLockOnPanic(t *testing.T) {
+	db := openTestDatabase(t)
+	useDatabaseRun(t)
+	createTestEventStore(t, db, pushdownFixture)
+
+	// Reading the missing event store fails after the lock was taken
+	if _, err := db.Exec("DROP TABLE event_store"); err != nil {
+		t.Fatal(err)
+	}
+	expectFailure(t, func() {
+		captureStdout(t, func() { materialize(context.Background(), db, "test", nil) })
+	})
+	expectLockFree(t, db, "materialized_view")
+}
+
+/*
+Test that a materialize run stopped by an interrupt releases the lock of the view for a competing run
+@param t Test state
+*/
+func TestMaterializeReleasesLockOnInterrupt(t *testing.T) {
+	db := openTestDatabase(t)
+	useDatabaseRun(t)
+	createTestEventStore(t, db, pushdownFixture)
+
+	// Cancel the context of the run like a received SIGINT
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(&InterruptError{Signal: os.Interrupt})
+	var summary *RunSummary
+	captureStdout(t, func() { summary = materialize(ctx, db, "test", nil) })
+	var interrupt *InterruptError
+	if !errors.As(summary.stopped, &interrupt) {
+		t.Errorf("run stopped by %v, want the interrupt", summary.stopped)
+	}
+	expectLockFree(t, db, "materialized_view")
+}
@@ -0,0 +1,90 @@
+package main
+
+/*
+@author 1Zero64
+Maintenance of the materialized view after a run
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for inspecting errors
+	"errors"
+	// Package for formatted printing
+	"fmt"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+/*
+Function to refresh the planner statistics of the materialized view after a full rebuild.
+Runs VACUUM (ANALYZE) instead of ANALYZE, if enabled and the view was cleaned with DELETE, to remove the dead tuples.
+Executed as own statement outside of any data writes, as VACUUM can't run inside a transaction block
+@param db *sql.DB Database connection to Postgres database
+@return Duration of the maintenance step (0, if skipped)
+*/
+func analyzeMaterializedView(db *sql.DB) time.Duration {
+
+	// Skip the step, if disabled or no full rebuild was done. The aggregation mode writes the aggregate table instead,
+	// leaving the materialized view unchanged
+	if !postAnalyze || appendOnly || sampleRate < 1 || outputMode == ModeCsv || aggregateMode {
+		return 0
+	}
+
+	// Use VACUUM only after DELETE-based cleans, truncated partitions don't leave dead tuples
+	statement := "ANALYZE materialized_view"
+	if postVacuum && !partitionedView {
+		statement = "VACUUM (ANALYZE) materialized_view"
+	}
+
+	// Execute maintenance statement and return its duration
+	return runMaintenance(db, statement)
+}
+
+/*
+Function to run VACUUM (ANALYZE) on the materialized view on demand with the vacuum subcommand, regardless of the post-run settings
+@param db *sql.DB Database connection to Postgres database
+*/
+func vacuumMaterializedView(db *sql.DB) {
+	runMaintenance(db, "VACUUM (ANALYZE) materialized_view")
+}
+
+/*
+Function to execute a maintenance statement on the materialized view and report its duration.
+Missing privileges are reported as warning instead of aborting, as the written view is complete without the maintenance
+@param db *sql.DB Database connection to Postgres database
+@param statement VACUUM or ANALYZE statement
+@return Duration of the statement (0, if skipped)
+*/
+func runMaintenance(db *sql.DB, statement string) time.Duration {
+
+	// Skip the statement for users not owning the view, Postgres would only warn and skip it server-side
+	var owner bool
+	err := db.QueryRow("SELECT pg_has_role(relowner, 'USAGE') FROM pg_class WHERE oid = 'materialized_view'::regclass").Scan(&owner)
+	checkError(err)
+	if !owner {
+		fmt.Printf("Warning: skipping %s, the user doesn't own materialized_view\n", statement)
+		return 0
+	}
+
+	// Save starting time point
+	start := time.Now()
+
+	// Execute maintenance statement and report denied permissions without aborting
+	_, err = db.Exec(statement)
+	var serverError interface{ SQLState() string }
+	if errors.As(err, &serverError) && serverError.SQLState() == "42501" {
+		fmt.Printf("Warning: %s failed, permission denied: %v\n", statement, err)
+		return 0
+	}
+	// Check on other errors with handler
+	checkError(err)
+
+	// Calculate duration and print information about the step
+	elapsed := time.Since(start)
+	fmt.Printf("%s took %f seconds\n", statement, elapsed.Seconds())
+
+	// Return duration of the step
+	return elapsed
+}
@@ -0,0 +1,42 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the maintenance of the materialized view after a run
+*/
+
+// Importing packages
+import (
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Test that the analyze step is skipped without touching the database, if the run didn't rebuild the materialized view
+@param t Test state
+*/
+func TestAnalyzeMaterializedViewSkipsWithoutRebuild(t *testing.T) {
+	savedAnalyze, savedAppend, savedRate, savedOutput, savedAggregate := postAnalyze, appendOnly, sampleRate, outputMode, aggregateMode
+	defer func() {
+		postAnalyze, appendOnly, sampleRate, outputMode, aggregateMode = savedAnalyze, savedAppend, savedRate, savedOutput, savedAggregate
+	}()
+
+	cases := []struct {
+		name      string
+		configure func()
+	}{
+		{"disabled", func() { postAnalyze = false }},
+		{"append-only", func() { appendOnly = true }},
+		{"sampled", func() { sampleRate = 0.5 }},
+		{"CSV output", func() { outputMode = ModeCsv }},
+		{"aggregation", func() { aggregateMode = true }},
+	}
+	for _, testCase := range cases {
+		postAnalyze, appendOnly, sampleRate, outputMode, aggregateMode = true, false, 1, ModeDb, false
+		testCase.configure()
+		// A nil database fails the test by a panic, if the step isn't skipped
+		if duration := analyzeMaterializedView(nil); duration != 0 {
+			t.Errorf("%s: analyzed for %s, want the step skipped", testCase.name, duration)
+		}
+	}
+}
@@ -7,10 +7,16 @@ Main program for materializer to transform measurements into a materialized view
 
 // Importing packages
 import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package for command line flags
+	"flag"
 	// Package to use SQL-like databases
 	"database/sql"
 	// Package for sorting Slices
 	"sort"
+	// Package for string manipulation
+	"strings"
 	// Package for formatted printing
 	"fmt"
 	// Package with interface to operating system functionality
@@ -19,15 +25,13 @@ import (
 	"time"
 	// Package for math functions
 	"math"
-
-	// Package for .env functionality
-	"github.com/joho/godotenv"
+	// Package for pseudo-random numbers
+	"math/rand"
+	// Package for interaction with the Go runtime
+	"runtime"
 
 	// Package to use PostgreSQL database
 	_ "github.com/lib/pq"
-
-	// Package for progress bar
-	"github.com/schollz/progressbar/v3"
 )
 
 // Enumerations for danger level
@@ -40,15 +44,26 @@ const (
 )
 
 /*
-Implicitly called function on initialization of the main application
-Executed only once and before main()
+Function to load the configuration of the main application
+Called first by main() instead of init(), so the package tests run without the .env file and flags of the application
 */
-func init() {
+func initialize() {
 
-	// Load .env variables and check on error with handler
-	if err := godotenv.Load(); err != nil {
-		checkError(err)
+	// Print only the version without a configuration
+	if versionRequested() {
+		return
 	}
+
+	// Report configuration errors of a healthcheck as unhealthy instead of panicking
+	if healthcheckRequested() {
+		defer recoverHealthcheck()
+	}
+
+	// Load .env variables of the selected environment
+	loadEnvironment()
+
+	// Load configuration from .env variables and command line flags
+	loadConfig()
 }
 
 /*
@@ -57,20 +72,107 @@ Triggers the materialize process
 */
 func main() {
 
-	// Build connection string to Postgres database with the database information from .env variables
-	psqlconn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_DATABASE"))
+	// Load the configuration of the application
+	initialize()
 
-	// Open database and check on error with handler
-	db, err := sql.Open("postgres", psqlconn)
-	checkError(err)
+	// Print the version and build information and exit, if requested
+	if versionRequested() {
+		printVersion()
+		return
+	}
+
+	// Print the effective SQL dialect and exit, if requested
+	if showDialect {
+		printDialect()
+		return
+	}
+
+	// Open database with the configured driver
+	db := openDatabase(dbDriver, "DB_")
+
+	// Open the separate reader pool with a single dedicated connection for the pipeline mode, the streaming fallback of MAX_IN_MEMORY and the Parquet export, reading from the replica, if configured
+	if pipelineMode || maxInMemory > 0 || exportParquet != "" {
+		readerDb = openDatabase(dbDriver, "DB_READ_")
+		readerDb.SetMaxOpenConns(1)
+		defer readerDb.Close()
+	}
+
+	// Open the read replica for the event store, if configured
+	if os.Getenv("DB_READ_HOST") != "" {
+		replicaDb = openDatabase(dbDriver, "DB_READ_")
+		defer replicaDb.Close()
+		fmt.Printf("Reading the event store from the replica %s\n", os.Getenv("DB_READ_HOST"))
+	}
+	var err error
+
+	// Run the healthcheck subcommand instead of the interactive menu, if requested
+	if flag.Arg(0) == "healthcheck" {
+		os.Exit(healthcheck(db))
+	}
+
+	// Run a single non-interactive materialize run and exit with its exit code, if requested
+	if batchMode {
+		os.Exit(runBatch(db))
+	}
+
+	// Wait for the database to accept connections
+	waitForDatabase(db)
+
+	// Serve the operations over gRPC instead of running the interactive menu, if requested
+	if flag.Arg(0) == "serve-grpc" {
+		if sourceMode == ModeDb || outputMode == ModeDb {
+			createSchema(db)
+		}
+		serveGrpc(db)
+		return
+	}
+
+	// Run the vacuum subcommand instead of the interactive menu, if requested
+	if flag.Arg(0) == "vacuum" {
+		vacuumMaterializedView(db)
+		return
+	}
+
+	// Export the transformed measurements as Parquet file instead of running the interactive menu, if requested
+	if exportParquet != "" {
+		exportParquetFile(db, exportParquet)
+		return
+	}
 
 	// Print info on successfull connection
-	fmt.Println("Connected with database!")
+	fmt.Printf("Connected with database (%s)!\n", connectionSummary())
+
+	// Bootstrap the materialized view schema, if not existing yet and the database is used
+	if sourceMode == ModeDb || outputMode == ModeDb {
+		createSchema(db)
+	}
+
+	// Backfill latency_us instead of running the interactive menu, if requested
+	if migrateLatencyUs {
+		migrateLatencyMicroseconds(db)
+		return
+	}
+
+	// Run the scheduled jobs instead of the interactive menu, if configured
+	if scheduleFile != "" {
+		runScheduler(db)
+		return
+	}
+
+	// Consume the changes of the event store continuously instead of running the interactive menu, if configured
+	if sourceMode == ModeCdc {
+		consumeChanges(db)
+		return
+	}
+
+	// Serve the dashboard of the last run, if configured
+	startDashboard(db)
+
+	// Materialize a random sample into the sample table instead of running the interactive menu, if requested
+	if sampleFraction > 0 || sampleRows > 0 {
+		materializeTableSample(db)
+		return
+	}
 
 	// Print available functions on console and run the program in a infinite loop
 Loop:
@@ -79,36 +181,142 @@ Loop:
 		fmt.Println("0: Exit")
 		fmt.Println("1: Execute materialize process")
 		fmt.Println("2: Execute materialize microbenchmark")
+		fmt.Println("3: Purge materialized view rows older than the retention period")
+		fmt.Println("4: Purge dry-run (only report rows to be removed)")
+		fmt.Println("5: Show row counts of event store and materialized view")
+		fmt.Println("6: Peek at the most recent rows of the materialized view")
+		fmt.Println("7: Show latency statistics of the materialized view")
+		fmt.Println("8: Execute materialize microbenchmark per event stream")
+		fmt.Println("9: Report differences between event store and materialized view")
+		fmt.Println("10: Tune danger thresholds interactively")
+		fmt.Println("11: Execute materialize microbenchmark sweeping the write concurrency")
+		fmt.Println("12: Compare the lib/pq and pgx database drivers")
+		fmt.Println("13: Inspect the transformation of a single measurement")
+		fmt.Println("14: Materialize a single sensor into its own table")
+		fmt.Println("15: Show version and build information")
+		fmt.Println("16: Compare Go and SQL pushdown materialization")
+		fmt.Println("17: Execute pure transform microbenchmark without database I/O")
 
 		// Get user input
-		var input int
-		fmt.Print("Select a function: ")
-		fmt.Scan(&input)
+		input := consolePrompt.integer("Select a function: ", 0, 17)
 
-		switch input {
-		case 0:
-			// Exit programm
+		// Exit programm
+		if input == 0 {
 			break Loop
-		case 1:
-			// Call materilaize view function
-			materializeView(db)
-		case 2:
-			// Get user input for number of iterations
-			var numberOfIterations int
-			fmt.Print("How many iterations?: ")
-			fmt.Scan(&numberOfIterations)
-
-			// Catch not suitable numbers
-			for numberOfIterations <= 0 {
-				fmt.Print("Please input a correct number: ")
-				fmt.Scan(&numberOfIterations)
-			}
-
-			// Call materializer microbenchmark function with number of iterations
-			microbenchmark(db, numberOfIterations)
-		default:
-			continue
 		}
+
+		// Run the selected function, returning to the menu after a failure, if MENU_RECOVER is enabled
+		func() {
+			defer recoverMenuFunction(db)
+
+			switch input {
+			case 1:
+				// Call materilaize view function
+				materializeView(context.Background(), db)
+			case 2:
+				// Get user input for number of iterations, at least one
+				numberOfIterations := int(consolePrompt.integer("How many iterations?: ", 1, maxInput))
+
+				// Call materializer microbenchmark function with number of iterations
+				microbenchmark(db, numberOfIterations)
+			case 3:
+				// Call purge function to delete old rows
+				purgeMaterializedView(db, false)
+			case 4:
+				// Call purge function to only count old rows
+				purgeMaterializedView(db, true)
+			case 5:
+				// Call show counts function
+				showCounts(db)
+			case 6:
+				// Get user input for number of rows and an optional filter
+				numberOfRows := int(consolePrompt.integer("How many rows? (0 for 10): ", 0, maxInput))
+				if numberOfRows <= 0 {
+					numberOfRows = 10
+				}
+				filter := consolePrompt.text("Filter by sensor id or event stream (- for none): ")
+				if filter == "-" {
+					filter = ""
+				}
+
+				// Call peek function
+				peek(db, numberOfRows, filter)
+			case 7:
+				// Get user input for the optional created_on range
+				from := consolePrompt.date("Created on from (YYYY-MM-DD, - for none): ")
+				to := consolePrompt.date("Created on to, exclusive (YYYY-MM-DD, - for none): ")
+
+				// Compute and print latency statistics
+				report := computeLatencyStatistics(db, from, to)
+				printLatencyReport(report)
+
+				// Get user input for an optional export file
+				exportPath := consolePrompt.text("Export to file (.csv/.json, - for none): ")
+				if exportPath != "-" {
+					exportLatencyReport(report, exportPath)
+				}
+			case 8:
+				// Get user input for number of iterations per event stream, at least one
+				numberOfIterations := int(consolePrompt.integer("How many iterations per event stream?: ", 1, maxInput))
+
+				// Get user input for an optional export file
+				exportPath := consolePrompt.text("Export to CSV file (- for none): ")
+				if exportPath == "-" {
+					exportPath = ""
+				}
+
+				// Call per event stream microbenchmark function
+				streamMicrobenchmark(db, numberOfIterations, exportPath)
+			case 9:
+				// Call diff report function
+				diffReport(db)
+			case 10:
+				// Call threshold tuner function
+				tuneThresholds(db)
+			case 11:
+				// Get user input for number of iterations per worker count, at least one
+				numberOfIterations := int(consolePrompt.integer("How many iterations per worker count?: ", 1, maxInput))
+
+				// Get user input for an optional export file
+				exportPath := consolePrompt.text("Export to CSV file (- for none): ")
+				if exportPath == "-" {
+					exportPath = ""
+				}
+
+				// Call write concurrency sweep function
+				sweepMicrobenchmark(db, numberOfIterations, exportPath)
+			case 12:
+				// Get user input for number of iterations per driver, at least one
+				numberOfIterations := int(consolePrompt.integer("How many iterations per driver?: ", 1, maxInput))
+
+				// Call driver comparison function
+				driverMicrobenchmark(numberOfIterations)
+			case 13:
+				// Get user input for the measurement id
+				id := consolePrompt.integer("Measurement id: ", 0, maxInput)
+
+				// Call inspect function
+				inspectMeasurement(db, id)
+			case 14:
+				// Get user input for the sensor id
+				sensorId := consolePrompt.integer("Sensor id: ", 0, maxInput)
+
+				// Call sensor sub-view function
+				materializeSensorView(db, sensorId)
+			case 15:
+				// Call version function
+				printVersion()
+			case 16:
+				// Call pushdown comparison function
+				pushdownComparison(db)
+			case 17:
+				// Get user input for number of iterations, at least one
+				numberOfIterations := int(consolePrompt.integer("How many iterations?: ", 1, maxInput))
+
+				// Call pure transform microbenchmark function
+				transformMicrobenchmark(db, numberOfIterations)
+			}
+		}()
 	}
 
 	// Close database, when surrounding fucntion returns
@@ -121,67 +329,539 @@ Loop:
 
 /*
 Function to execute the materialize process and write transformed data from event store to materialized view
+@param ctx Context of the run, the run stops gracefully when it's done
 @param db *sql.DB Database connection to Postgres database
+@return Summary of the run (nil, if skipped because the event store is unchanged)
 */
-func materializeView(db *sql.DB) {
+func materializeView(ctx context.Context, db *sql.DB) *RunSummary {
+
+	// Generate unique identifier of the run
+	runId := newRunId()
 
 	// Print information about starting the transformation process
-	fmt.Println("Starting materialize process...")
+	fmt.Printf("Starting materialize process (run %s)...\n", runId)
+
+	// Skip the rebuild, if the source data didn't change since the last successful full rebuild
+	var fingerprint SourceFingerprint
+	fullRebuild := sourceMode == ModeDb && outputMode == ModeDb && sampleRate >= 1 && !appendOnly && !aggregateMode
+	if skipIfUnchanged && fullRebuild {
+		fingerprint = sourceFingerprint(db)
+		if sourceUnchanged(db, fingerprint) {
+			fmt.Printf("View already up to date: the event store, the configuration and the view are unchanged since the last rebuild (%.0f measurements, max id %.0f)\n", fingerprint.count, fingerprint.maxId)
+			return nil
+		}
+	}
+
+	// Estimate the duration of a full rebuild from the event store
+	if sourceMode == ModeDb && !sinceLastRun {
+		printRunEstimate(db)
+	}
 
 	// Save starting time point
 	start := time.Now()
 
 	// Call materialize function with opened database connection
-	numberOfMeasurements := materialize(db)
+	summary := materialize(ctx, db, runId, nil)
 
 	// Save end time point and calculate difference between start and end time to calculate the materialize process time
 	end := time.Now()
 	elapsed := end.Sub(start)
 
 	// Print needed time for materializing
-	fmt.Printf("Time elapsed: %f seconds for %d measurements\n", elapsed.Seconds(), numberOfMeasurements)
+	localePrintf("Run %s finished\n", runId)
+	localePrintf("Time elapsed: %f seconds for %d measurements\n", elapsed.Seconds(), summary.measurements)
+
+	// Store the per-row cost of a complete full run for the estimate of the next run
+	if summary.stopped == nil && summary.measurements > 0 && sourceMode == ModeDb && outputMode == ModeDb && sampleRate >= 1 && !sinceLastRun {
+		storeRunStat(db, perRowCostStat, elapsed.Seconds()/float64(summary.measurements))
+	}
+
+	// Store the fingerprint taken before the complete rebuild, so changes during the run trigger the next one, with the rebuilt view
+	if skipIfUnchanged && fullRebuild && summary.stopped == nil {
+		fingerprint.readView(db)
+		storeSourceFingerprint(db, fingerprint)
+	}
+
+	// Refresh planner statistics of the rebuilt view, timed separately from the materialize process
+	analyzeMaterializedView(db)
+
+	// Measure freshness and ingest lag of the event store outside of the timed region
+	if sourceMode == ModeDb {
+		summary.freshness = measureFreshness(db)
+	}
+
+	// Print further statistics of the run
+	summary.print()
+	return summary
 }
 
 /*
 Function to control the materialize process
+@param ctx Context of the run, the run stops gracefully when it's done
 @param db *sql.DB Database connection to Postgres database
+@param runId Unique identifier of the run
+@param cached Measurements read before to use instead of reading them (nil to read them)
+@return Summary of the materialize run
 */
-func materialize(db *sql.DB) int {
-	// Clean materialized view in database
-	cleanMaterializedView(db)
+func materialize(ctx context.Context, db *sql.DB, runId string, cached []Measurement) *RunSummary {
+	// Lock the target table against concurrent instances, unless the run only writes a CSV file or samples
+	var targetLock *MaterializeLock
+	if outputMode == ModeDb && sampleRate >= 1 {
+		lockTable := "materialized_view"
+		if aggregateMode {
+			lockTable = aggregateTable
+		}
+		targetLock = acquireMaterializeLock(db, lockTable)
+		defer targetLock.release()
+	}
+
+	// Snapshot the pg_stat counters before the run, if enabled
+	var statsBefore PgStats
+	if pgStats {
+		statsBefore = snapshotPgStats(db)
+	}
+
+	// Read the sizes of the involved tables before the run, if enabled
+	var sizesBefore map[string]RelationSize
+	if tableSizes {
+		sizesBefore = readTableSizes(db)
+	}
+
+	// Initialize summary of the run
+	summary := newRunSummary(runId)
 
-	// Read measurements in event store into an array
-	measurements := readMeasurements(db)
+	// Limit the runtime of the run with a deadline, if configured
+	if maxRuntime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, maxRuntime, errMaxRuntimeExceeded)
+		defer cancel()
+	}
+
+	// Activate the thresholds of the danger_thresholds table or the configuration and record them
+	if sourceMode == ModeDb || outputMode == ModeDb {
+		refreshThresholds(db)
+	}
+	summary.thresholds = thresholds
+	summary.thresholdSource = thresholdSource
+
+	// Table to write the transformed measurements into
+	table := "materialized_view"
+
+	// Create CSV output file instead of writing into the materialized view, if configured
+	var csvOutput *TransformedCsvWriter
+	if outputMode == ModeCsv && sampleRate >= 1 {
+		csvOutput = newTransformedCsvWriter(outputCsvPath)
+
+		// Flush the exported rows and print the last exported id, if the run fails
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				csvOutput.abort()
+				panic(recovered)
+			}
+		}()
+	}
+
+	// Record the latency unit on the materialized view and warn about a changed one, before a staging table copies the view
+	if csvOutput == nil && sampleRate >= 1 && !aggregateMode && outputMode == ModeDb {
+		recordLatencyUnit(db, table)
+	}
+
+	// Save starting time point of the clean phase
+	phaseStart := time.Now()
+
+	// Clean materialized view in database, unless it's treated as append-only or rebuilt in a staging table
+	if csvOutput != nil {
+		fmt.Printf("Writing transformed measurements to %s\n", outputCsvPath)
+	} else if sampleRate < 1 {
+		fmt.Printf("Sampling %.2f%% of the measurements as dry-run, the materialized view is neither cleaned nor written\n", sampleRate*100)
+	} else if sinceLastRun {
+		fmt.Println("Materializing only measurements processed since the last run")
+	} else if fillGaps {
+		fmt.Println("Filling gaps: materializing only measurements missing from the materialized view")
+		checkGapIndex(db)
+	} else if aggregateMode {
+		cleanAggregatedView(db)
+	} else if appendOnly {
+		fmt.Println("Warning: APPEND_ONLY is enabled, the materialized view is not cleaned. Rows of deleted source measurements won't be removed")
+	} else if stagingRebuild {
+		table = createStagingTable(db)
+
+		// Discard the partially written staging table, if the run fails
+		if table != "materialized_view" {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					if _, err := db.Exec("DROP TABLE IF EXISTS " + table); err == nil {
+						fmt.Println("Discarded the partially written staging table, the materialized view is unchanged")
+					}
+					panic(recovered)
+				}
+			}()
+		}
+	} else {
+		cleanMaterializedView(db)
+	}
+
+	// Create and clean the danger level tables, if the measurements are routed into them
+	if splitByDanger && csvOutput == nil && sampleRate >= 1 {
+		prepareDangerTables(db)
+	}
+
+	// Save duration of the clean phase and starting time point of the read phase
+	summary.cleanDuration = time.Since(phaseStart)
+	phaseStart = time.Now()
+
+	// Read measurements in event store or CSV file into an array, unless they are cached or streamed by the pipeline reader
+	var measurements []Measurement
+	var pipeline *PipelineReader
+	var pushdownQuery string
+	var pushdownArgs []interface{}
+	if cached != nil {
+		measurements = cached
+		// Duplicates were collapsed, when the cached measurements were read
+		summary.duplicates = -1
+	} else if sourceMode == ModeCsv {
+		measurements = readCsvMeasurements(sourceCsvPath)
+		// Collapse duplicates in a pass over the read measurements, if enabled
+		if dedupPolicy != "" {
+			measurements, summary.duplicates = deduplicateMeasurements(measurements)
+		}
+	} else {
+		// Condition of the read, to read only measurements newer than the watermark of the last run
+		var condition string
+		var args []interface{}
+		if sinceLastRun {
+			// Use the newest processed_on of the view as watermark and read only newer measurements
+			var watermark sql.NullTime
+			err := db.QueryRow("SELECT MAX(processed_on) FROM materialized_view").Scan(&watermark)
+			checkError(err)
+			if watermark.Valid {
+				fmt.Printf("Last run watermark: processed_on %s\n", watermark.Time.Format(time.RFC3339Nano))
+				condition = "processed_on > $1"
+				args = append(args, watermark.Time)
+			}
+		}
+		if fillGaps {
+			// Read only measurements without a row in the view
+			if condition != "" {
+				condition += " AND "
+			}
+			condition += missingFromViewCondition
+		}
+		if resumeFromId > 0 && csvOutput != nil {
+			// Continue an interrupted export after its last exported id
+			condition, args = resumeCondition(condition, args)
+		}
+		// Count the duplicates, which the read query collapses, if enabled
+		if dedupPolicy != "" {
+			summary.duplicates = countDuplicates(db, condition, args...)
+		}
+		if pushdownMode {
+			// Leave the read to the INSERT ... SELECT of the write phase
+			pushdownQuery, pushdownArgs = measurementsQuery(condition, args...)
+		} else if summary.readPath = chooseReadPath(db, condition, args...); summary.readPath == ReadPathStreaming {
+			pipeline = startPipelineReader(ctx, db, condition, args...)
+			// Stop the reader also on a failed run, so its connection is released for the next one
+			defer pipeline.stop()
+		} else {
+			measurements = readMeasurements(db, condition, args...)
+		}
+	}
+
+	// Load valid sensor ids from the sensor registry, if validation is enabled and they are outdated
+	registry := currentSensorRegistry(db)
+
+	// Save duration of the read phase and starting time point of the transform and write phase
+	summary.readDuration = time.Since(phaseStart)
+	phaseStart = time.Now()
+
+	// Transform and write all measurements in the database, the loop below has nothing to iterate then
+	if pushdownMode && pushdownQuery != "" {
+		summary.measurements, summary.nonFiniteDeadLettered = materializePushdown(db, table, pushdownQuery, pushdownArgs...)
+	}
 
 	// Initialize counter for found measurements
 	var counter int
 
-	// Print progress bar of the transforming process
-	bar := progressbar.Default(int64(len(measurements)))
+	// Print progress bar of the transforming process (without a total while the pipeline reader streams the measurements) and notify the listener of the run
+	total := int64(len(measurements))
+	if pipeline != nil {
+		total = -1
+	}
+	bar := newRunProgress(ctx, total)
+
+	// Hourly buckets to aggregate the measurements into instead of writing them one by one, if the aggregation mode is enabled
+	var aggregation map[BucketKey]*HourlyBucket
+	if aggregateMode {
+		aggregation = newHourlyAggregation()
+	}
+
+	// Latest created_on accepted without counting the measurement as future-dated
+	futureLimit := nowFunc().Add(futureSkew)
+
+	// Initialize the background writes bounded by the number of concurrent writes
+	writes := newBackgroundWrites(writeConcurrency)
+	summary.writeConcurrency = writeConcurrency
+
+	// Flag whether the inserts into the view run concurrently in the background
+	asyncWrites := aggregation == nil && csvOutput == nil && sampleRate >= 1 && writeConcurrency > 1
+
+	// Start logging the throughput periodically, if enabled
+	monitor := startThroughputMonitor(total)
+
+	// Initialize the danger level state per sensor, if the hysteresis is enabled
+	hysteresis := newHysteresis()
+
+	// Start the timing trace per chunk, if enabled
+	tracer := newChunkTracer()
 
 	// Iterate through found measurements and transform and write them into the materialized view
-	for _, measurement := range measurements {
+	for index := 0; ; index++ {
+		// Take the next measurement of the read ones or of the pipeline reader
+		var measurement Measurement
+		if pipeline != nil {
+			var ok bool
+			if measurement, ok = pipeline.next(); !ok {
+				break
+			}
+		} else if index < len(measurements) {
+			measurement = measurements[index]
+		} else {
+			break
+		}
+		// Account the time taking the measurement (and handling skipped ones before) to the chunk
+		tracer.lap(ChunkRead)
+		// Stop gracefully before the next measurement, if the deadline is exceeded. Written rows are already committed
+		if ctx.Err() != nil {
+			summary.stopped = context.Cause(ctx)
+			break
+		}
+		// Stop starting writes after a failed background write, which is raised after the in-flight ones finished
+		if writes.failed() {
+			break
+		}
 		// Increment counter for every iterated measurement
 		counter++
-		// Call transform measurement function with current measurement and database connection
-		transformMeasurement(measurement, db)
+		// Check sensor of the measurement against the registry and handle unknown sensors by the configured policy
+		unknownSensor := registry != nil && !registry[measurement.sensor_id]
+		if unknownSensor {
+			// Account unknown sensor in the run summary
+			summary.addUnknownSensor(measurement.sensor_id)
+			// Skip or dead-letter the measurement without writing it into the materialized view
+			if unknownSensorPolicy != UnknownSensorFlag {
+				abortTolerated(measurement, fmt.Errorf("unknown sensor %d, tolerated by UNKNOWN_SENSOR_POLICY %s", measurement.sensor_id, unknownSensorPolicy))
+				if unknownSensorPolicy == UnknownSensorDeadLetter {
+					writeDeadLetter(measurement, "unknown sensor", db)
+				}
+				summary.lastId = measurement.id
+				bar.Add(1)
+				continue
+			}
+		}
+		// Check for a created_on in the future beyond the skew tolerance and handle it by the configured policy
+		if futureSkew > 0 && measurement.created_on.After(futureLimit) {
+			// Account future-dated measurement in the run summary
+			summary.futureMeasurements++
+			if futureSkewPolicy != FutureSkewFlag {
+				abortTolerated(measurement, fmt.Errorf("created_on beyond FUTURE_SKEW %s, tolerated by FUTURE_SKEW_POLICY %s", futureSkew, futureSkewPolicy))
+			}
+			if futureSkewPolicy == FutureSkewSkip {
+				summary.lastId = measurement.id
+				bar.Add(1)
+				continue
+			}
+			// Clamp created_on to processed_on, as a measurement can't be created after it was processed
+			if futureSkewPolicy == FutureSkewClamp {
+				measurement.created_on = measurement.processed_on
+			}
+		}
+		// Drop measurements with a NULL reading, if the NULL policy skips them
+		if measurement.hasNullReading() && nullPolicy != NullPolicyError {
+			abortTolerated(measurement, fmt.Errorf("NULL reading, tolerated by NULL_POLICY %s", nullPolicy))
+		}
+		if measurement.skippedByNullPolicy() {
+			// Account skipped measurement in the run summary
+			summary.nullSkipped++
+			summary.lastId = measurement.id
+			bar.Add(1)
+			continue
+		}
+		// Dead-letter measurements with a NaN or infinite reading, if the non-finite policy doesn't clamp them
+		if measurement.hasNonFiniteReading() {
+			abortTolerated(measurement, fmt.Errorf("NaN or infinite reading, tolerated by NON_FINITE_POLICY %s", nonFinitePolicy))
+		}
+		if measurement.deadLetteredAsNonFinite() {
+			// Account dead-lettered measurement in the run summary
+			summary.nonFiniteDeadLettered++
+			if sourceMode == ModeDb || outputMode == ModeDb {
+				writeDeadLetter(measurement, "non-finite reading", db)
+			}
+			summary.lastId = measurement.id
+			bar.Add(1)
+			continue
+		}
+		// Call transform measurement function with current measurement
+		transformedMeasurement := transformMeasurement(measurement)
+		// Mark measurements of unknown sensors passing through
+		transformedMeasurement.unknown_sensor = unknownSensor
+		// Keep the previous danger level of the sensor, unless the readings pass the threshold by the hysteresis margin
+		if hysteresis != nil {
+			transformedMeasurement.danger = hysteresis.classify(transformedMeasurement)
+		}
+		// Account the time checking and transforming the measurement to the chunk
+		tracer.lap(ChunkTransform)
+		// Write transformed measurement to materialized view and handle a failed insert, unless it's a sampled dry-run or aggregated
+		if aggregation != nil {
+			aggregateMeasurement(aggregation, transformedMeasurement)
+		} else if csvOutput != nil {
+			if err := csvOutput.write(transformedMeasurement); err != nil {
+				handleRowError(measurement, err)
+			}
+		} else if asyncWrites {
+			// Write in the background, blocking while all slots of the semaphore are in use
+			writes.start(func() {
+				if err := retryOnDeadlock(func() error { return writeTransformedMeasurement(transformedMeasurement, table, db) }, &summary.deadlockRetried); err != nil {
+					handleRowError(measurement, err)
+				}
+				if splitByDanger {
+					writeDangerTable(measurement, transformedMeasurement, summary, db)
+				}
+				monitor.add()
+			})
+		} else if sampleRate >= 1 {
+			if err := retryOnDeadlock(func() error { return writeTransformedMeasurement(transformedMeasurement, table, db) }, &summary.deadlockRetried); err != nil {
+				handleRowError(measurement, err)
+			}
+			// Route the measurement additionally into the table of its danger level
+			if splitByDanger {
+				writeDangerTable(measurement, transformedMeasurement, summary, db)
+			}
+		}
+		// Count the processed measurement for the throughput, background writes count themselves when finished
+		if !asyncWrites {
+			monitor.add()
+		}
+		// Add transformed measurement to the run summary
+		summary.add(transformedMeasurement)
+		summary.lastId = measurement.id
 		// Update the progress bar
 		bar.Add(1)
+		// Account the time writing the measurement to the chunk and count it
+		tracer.lap(ChunkWrite)
+		tracer.row()
+	}
+
+	// Wait for the in-flight writes and stop the throughput logging
+	writes.wait()
+	summary.chunks = tracer.finish()
+	summary.throughputSamples = monitor.stop()
+
+	// Stop the pipeline reader and save the idle times of both sides
+	if pipeline != nil {
+		pipeline.stop()
+		if summary.stopped == nil && ctx.Err() != nil {
+			summary.stopped = context.Cause(ctx)
+		}
+		summary.readerIdle = pipeline.readerIdle
+		summary.writerIdle = pipeline.writerIdle
+	}
+
+	// Raise a failed background write on this goroutine, so the menu can recover from it
+	writes.failure.raise()
+
+	// Finish progress output of a complete run, a stopped run keeps its last progress
+	if summary.stopped == nil {
+		bar.Finish()
+	}
+
+	// Write the hourly buckets into the aggregated view, unless it's a sampled dry-run
+	if aggregation != nil && sampleRate >= 1 {
+		writeHourlyAggregation(aggregation, db)
+	}
+
+	// Close the CSV output file and print how to continue a stopped export
+	if csvOutput != nil {
+		csvOutput.close()
+		if summary.stopped != nil {
+			printResumeHint(outputCsvPath, csvOutput.lastId, summary.stopped)
+		}
+	}
+
+	// Save duration of the transform and write phase
+	summary.writeDuration = time.Since(phaseStart)
+
+	// Swap the completely written staging table into place, a partially written one is discarded instead
+	if table != "materialized_view" && csvOutput == nil && sampleRate >= 1 {
+		if summary.stopped == nil {
+			summary.swapDuration = swapStagingTable(db)
+		} else {
+			_, err := db.Exec("DROP TABLE " + table)
+			checkError(err)
+			fmt.Println("Discarded the partially written staging table, the materialized view is unchanged")
+		}
 	}
 
-	// Return number of measurements
-	return len(measurements)
+	// Save number of level changes suppressed by the hysteresis
+	if hysteresis != nil {
+		summary.hysteresisHeld = hysteresis.held
+	}
+
+	// Save duration of the run
+	summary.duration = time.Since(summary.start)
+
+	// Calculate the pg_stat counters of the run after its duration, if enabled
+	if pgStats {
+		statsDelta := pgStatsDelta(statsBefore, snapshotPgStats(db))
+		summary.pgStats = &statsDelta
+	}
+
+	// Compare the sizes of the involved tables after the swap of a staging table, if enabled
+	if tableSizes {
+		summary.tableSizes = tableSizeChanges(sizesBefore, readTableSizes(db))
+	}
+
+	// Purge rows older than the retention period as automatic post-run step under the lock of the run, if enabled
+	if autoPurge && csvOutput == nil && sampleRate >= 1 {
+		purgeMaterializedView(db, false, targetLock)
+	}
+
+	// Write the ids of the measurements breaching the latency SLA, if a file is configured
+	if latencySla > 0 && latencySlaFile != "" {
+		writeSlaBreaches(latencySlaFile, summary.slaBreachIds)
+	}
+
+	// Write metrics of the run for the Prometheus textfile collector, if a file is configured
+	if promFile != "" {
+		writePrometheusFile(promFile, summary)
+	}
+
+	// Show the run on the dashboard
+	publishRunSummary(summary)
+
+	// Write the structured report of the run, if configured
+	if runReportPath != "" {
+		writeRunReport(summary)
+	}
+
+	// Return summary of the run
+	return summary
 }
 
 /*
 Method to read all measurement from event_store in database and return them as an array
 @param db *sql.DB Database connection to Postgres database
+@param condition Optional condition of the WHERE clause to filter measurements (empty to read all)
+@param args Arguments for the placeholders of the condition
 @return Array of all read measurements
 */
-func readMeasurements(db *sql.DB) []Measurement {
+func readMeasurements(db *sql.DB, condition string, args ...interface{}) []Measurement {
+
+	// Build select query on event store with the optional condition
+	query, args := measurementsQuery(condition, args...)
 
 	// Execute select query on event store and return all measurement rows
-	rows, err := db.Query("SELECT * FROM event_store ORDER BY id")
+	// Wait for a lagging read replica and query the replica, if configured, otherwise the primary
+	checkReplicaLag(db)
+	rows, err := readHandle(db).Query(query, args...)
 
 	// Check on error with handler
 	checkError(err)
@@ -192,16 +872,17 @@ func readMeasurements(db *sql.DB) []Measurement {
 	// Initialize an array for measurements
 	measurements := make([]Measurement, 0)
 
+	// Initialize seeded random number generator for reproducible sampling
+	random := rand.New(rand.NewSource(sampleSeed))
+
 	// Iterate through all records in rows
 	for rows.Next() {
-		// Initialize empty measurement object
-		var measurement Measurement
-		// Try to scan a record in row for measurement attributes and set them into the object
-		err = rows.Scan(&measurement.id, &measurement.created_on, &measurement.event_stream, &measurement.humidity, &measurement.processed_on, &measurement.sensor_id, &measurement.temperature)
-		// Check on error with handler
-		checkError(err)
+		// Skip records probabilistically, if only a sample is materialized
+		if sampleRate < 1 && random.Float64() >= sampleRate {
+			continue
+		}
 		// Insert measurement into measurements array
-		measurements = append(measurements, measurement)
+		measurements = append(measurements, scanMeasurement(rows))
 	}
 
 	// Return measurements array
@@ -209,64 +890,276 @@ func readMeasurements(db *sql.DB) []Measurement {
 }
 
 /*
-Transform a measurement by calculating and setting latency in milliseconds and danger level. Write into database
+Function to add the stream filter to the condition of a query on the event store
+@param condition Optional condition of the WHERE clause to filter measurements (empty to read all)
+@param args Arguments for the placeholders of the condition
+@return Condition and its arguments including the stream filter
+*/
+func eventStoreCondition(condition string, args ...interface{}) (string, []interface{}) {
+
+	// Restrict the measurements to a single event stream, if a stream filter is set
+	if streamFilter != "" {
+		args = append(args, streamFilter)
+		if condition != "" {
+			condition += " AND "
+		}
+		condition += fmt.Sprintf("event_stream = $%d", len(args))
+	}
+	return condition, args
+}
+
+/*
+Function to parse the order of the event store read into an ORDER BY clause. Only id and created_on are accepted as columns,
+so the value can't inject SQL. Ties of created_on are broken by id in the same direction for a stable order
+@param value Column optionally followed by asc or desc, e.g. created_on desc
+@return ORDER BY clause without the keywords or an error for unknown columns and directions
+*/
+func parseReadOrder(value string) (string, error) {
+
+	// Split column and optional direction
+	parts := strings.Fields(strings.ToLower(value))
+	if len(parts) == 0 || len(parts) > 2 {
+		return "", fmt.Errorf("expected a column optionally followed by asc or desc")
+	}
+	direction := "ASC"
+	if len(parts) == 2 {
+		if parts[1] != "asc" && parts[1] != "desc" {
+			return "", fmt.Errorf("unknown direction %q, expected asc or desc", parts[1])
+		}
+		direction = strings.ToUpper(parts[1])
+	}
+
+	// Accept only the known columns
+	switch parts[0] {
+	case "id":
+		return "id " + direction, nil
+	case "created_on":
+		return "created_on " + direction + ", id " + direction, nil
+	}
+	return "", fmt.Errorf("unknown column %q, expected id or created_on", parts[0])
+}
+
+/*
+Function to build the select query on the event store
+@param condition Optional condition of the WHERE clause to filter measurements (empty to read all)
+@param args Arguments for the placeholders of the condition
+@return Query and its arguments including the stream filter
+*/
+func measurementsQuery(condition string, args ...interface{}) (string, []interface{}) {
+
+	// Build select query on event store with the optional condition
+	condition, args = eventStoreCondition(condition, args...)
+	query := "SELECT * FROM event_store"
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+
+	// Keep a single measurement per (sensor_id, created_on) by the deduplication policy, if enabled
+	if dedupPolicy != "" {
+		query = "SELECT * FROM (SELECT DISTINCT ON (sensor_id, created_on) * FROM event_store"
+		if condition != "" {
+			query += " WHERE " + condition
+		}
+		query += " ORDER BY " + dedupOrder() + ") deduplicated"
+	}
+	query += " ORDER BY " + readOrder
+
+	// Return query with arguments
+	return query, args
+}
+
+/*
+Function to scan the current record of the event store rows into a measurement
+@param rows Rows of the select query on the event store
+@return Scanned measurement
+*/
+func scanMeasurement(rows *sql.Rows) Measurement {
+
+	// Initialize empty measurement object and pointers for the readings, which stay nil for NULL
+	var measurement Measurement
+	var temperature, humidity *reading
+	// Try to scan a record in row for measurement attributes and set them into the object
+	err := rows.Scan(&measurement.id, &measurement.created_on, &measurement.event_stream, &humidity, &measurement.processed_on, &measurement.sensor_id, &temperature)
+	// Check on error with row error handler
+	if err != nil {
+		handleRowError(measurement, err)
+	}
+	// Set the readings and flag NULL ones, which keep the value 0
+	measurement.setReadings(temperature, humidity)
+	return measurement
+}
+
+/*
+Transform a measurement by calculating and setting latency in the LATENCY_UNIT and danger level
 @param measurement Measurement to be transformed
-@param db *sql.DB Database connection to Postgres database
+@return Transformed measurement
 */
-func transformMeasurement(measurement Measurement, db *sql.DB) {
+func transformMeasurement(measurement Measurement) TransformedMeasurement {
 
 	// Initialize empty transformed measurement object
 	var TransformedMeasurement TransformedMeasurement
 	// Set base attributes with data from given measurement
 	TransformedMeasurement.Measurement = measurement
 
-	// Calculate latency between creation datetime and processed datetime to get it in Nanoseconds then divide it by 1.000.000 to get Milliseconds
-	TransformedMeasurement.latency = (float32(int(TransformedMeasurement.processed_on.UnixNano()) - int(TransformedMeasurement.created_on.UnixNano()))) / 1000000
-
-	// Set danger level by traversing through if-statements, that check temperature and humidity
-	if TransformedMeasurement.temperature > 10 || TransformedMeasurement.humidity > 60 {
-		TransformedMeasurement.danger = Critical
-	} else if TransformedMeasurement.temperature > 7 || TransformedMeasurement.humidity > 50 {
-		TransformedMeasurement.danger = High
-	} else if TransformedMeasurement.temperature > 5 || TransformedMeasurement.humidity > 40 {
-		TransformedMeasurement.danger = Medium
-	} else if TransformedMeasurement.temperature > 3 || TransformedMeasurement.humidity > 20 {
-		TransformedMeasurement.danger = Low
-	} else {
-		TransformedMeasurement.danger = No
+	// Abort on a NULL reading, unless the NULL policy treats it as 0 (skipped measurements aren't transformed)
+	if measurement.hasNullReading() && nullPolicy == NullPolicyError {
+		handleRowError(measurement, fmt.Errorf("NULL reading (temperature null=%t, humidity null=%t) in measurement id=%d, set NULL_POLICY to zero or skip to accept it", measurement.null_temperature, measurement.null_humidity, measurement.id))
 	}
 
-	// Write transformed measurement to materialized view
-	writeTransformedMeasurement(TransformedMeasurement, db)
+	// Clamp NaN and infinite readings to finite ones, which would silently fail every threshold comparison (dead-lettered measurements aren't transformed)
+	if measurement.hasNonFiniteReading() {
+		TransformedMeasurement.temperature = clampReading(measurement.temperature)
+		TransformedMeasurement.humidity = clampReading(measurement.humidity)
+		TransformedMeasurement.clamped = true
+	}
+
+	// Calculate latency between creation datetime and processed datetime, exact in microseconds and converted into the latency unit
+	latency := TransformedMeasurement.processed_on.Sub(TransformedMeasurement.created_on)
+	TransformedMeasurement.latency_us = latency.Microseconds()
+	TransformedMeasurement.latency = latencyUnit.latency(latency)
+
+	// Set danger level by classifying temperature and humidity
+	TransformedMeasurement.danger = classify(thresholdsFor(TransformedMeasurement.event_stream), TransformedMeasurement.temperature, TransformedMeasurement.humidity)
+
+	// Calculate perceived temperature-humidity stress as heat index in Grad Celsius
+	TransformedMeasurement.heat_index = calculateHeatIndex(TransformedMeasurement.temperature, TransformedMeasurement.humidity)
+
+	// Return transformed measurement
+	return TransformedMeasurement
+}
+
+/*
+Function to classify the danger level of a measurement by traversing through if-statements, that check temperature and humidity.
+A value only exceeds a threshold, if it is greater than the threshold plus the configured tolerance. Single precision can't
+represent most decimals exactly: a measured 10.1°C is stored as 10.100000381 and would exceed a threshold of 10.1 without the tolerance,
+while 7.1 is stored as 7.099999905 and stays below. Whole numbers like 10 or 60 are exact and don't flip
+@param thresholds Thresholds of the danger levels (of the profile of the event stream)
+@param temperature Measured temperature in Grad Celsius
+@param humidity Measured humidity in percentage
+@return Danger level of the measurement
+*/
+func classify(thresholds Thresholds, temperature reading, humidity reading) string {
+
+	// Classify by the weighted risk score, if the scoring model is selected
+	if scoringMode == ScoringWeighted {
+		return classifyWeighted(thresholds, temperature, humidity)
+	}
+	return classifyWithThresholds(thresholds, temperature, humidity)
+}
+
+/*
+Function to classify the danger level of a measurement with the given thresholds
+@param thresholds Thresholds of the danger levels
+@param temperature Measured temperature in Grad Celsius
+@param humidity Measured humidity in percentage
+@return Danger level of the measurement
+*/
+func classifyWithThresholds(thresholds Thresholds, temperature reading, humidity reading) string {
+
+	// Check thresholds from the most to the least dangerous level
+	if exceeds(temperature, thresholds.temperature[3]) || exceeds(humidity, thresholds.humidity[3]) {
+		return Critical
+	} else if exceeds(temperature, thresholds.temperature[2]) || exceeds(humidity, thresholds.humidity[2]) {
+		return High
+	} else if exceeds(temperature, thresholds.temperature[1]) || exceeds(humidity, thresholds.humidity[1]) {
+		return Medium
+	} else if exceeds(temperature, thresholds.temperature[0]) || exceeds(humidity, thresholds.humidity[0]) {
+		return Low
+	}
+	return No
+}
+
+/*
+Function to check if a value exceeds a threshold by more than the configured tolerance
+@param value Value to check
+@param threshold Threshold to compare against
+@return True, if the value is greater than the threshold plus tolerance
+*/
+func exceeds(value reading, threshold float64) bool {
+	return float64(value) > threshold+tolerance
+}
+
+/*
+Function to build the insert statement of a transformed measurement
+@param table Name of the table to write into
+@return Insert statement with placeholders
+*/
+func insertStatement(table string) string {
+
+	// Insert all columns of the materialized view
+	insertStmt := "INSERT INTO " + table + " (id, created_on, danger, event_stream, humidity, latency, processed_on, sensor_id, temperature, heat_index, unknown_sensor, latency_us) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)"
+
+	// Ignore already materialized measurements in append-only mode, because the view isn't cleaned before
+	if appendOnly {
+		insertStmt += " ON CONFLICT DO NOTHING"
+	}
+	return insertStmt
 }
 
 /*
 Function to persist a transformed measurement in the database
 @param TransformedMeasurement Transformed measurement to write into materialized view
+@param table Name of the table to write into (the materialized view or one with the same structure)
 @param db *sql.DB Database connection to Postgres database
+@return Error of the insert statement, if one occured
 */
-func writeTransformedMeasurement(TransformedMeasurement TransformedMeasurement, db *sql.DB) {
+func writeTransformedMeasurement(TransformedMeasurement TransformedMeasurement, table string, db *sql.DB) error {
+
+	// Create the monthly partition of the measurement, if the view is partitioned
+	if partitionedView && table == "materialized_view" {
+		ensurePartition(TransformedMeasurement.created_on, db)
+	}
 
 	// Prepare dynamic insert statement
-	insertStmt := "INSERT INTO materialized_view VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	insertStmt := insertStatement(table)
 
 	// Initialize error variable
 	var err error
 
 	// Execute insert statement with attribute data from the trasformed measurement object
-	_, err = db.Exec(insertStmt,
+	_, err = db.Exec(insertStmt, insertArguments(TransformedMeasurement)...)
+
+	// Return error of the insert for the row error handling of the caller
+	return err
+}
+
+/*
+Function to get the values of the insert statement placeholders of a transformed measurement
+@param TransformedMeasurement Transformed measurement to write
+@return Values in the column order of insertStatement
+*/
+func insertArguments(TransformedMeasurement TransformedMeasurement) []interface{} {
+	return []interface{}{
 		TransformedMeasurement.id,
 		TransformedMeasurement.created_on,
 		TransformedMeasurement.danger,
 		TransformedMeasurement.event_stream,
 		TransformedMeasurement.humidity,
-		TransformedMeasurement.latency,
+		floatLatencyValue(TransformedMeasurement),
 		TransformedMeasurement.processed_on,
 		TransformedMeasurement.sensor_id,
-		TransformedMeasurement.temperature)
+		TransformedMeasurement.temperature,
+		nullableFloat(TransformedMeasurement.heat_index),
+		TransformedMeasurement.unknown_sensor,
+		TransformedMeasurement.latency_us,
+	}
+}
 
-	// Check on error with handler
-	checkError(err)
+/*
+Function to convert a float into a nullable database value to prevent NaN or infinite values from being written
+@param value Float value to convert
+@return nil for NaN or infinite values, otherwise the value itself
+*/
+func nullableFloat(value reading) interface{} {
+
+	// Check if value is not a finite number
+	if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+		// Write NULL instead of the invalid value
+		return nil
+	}
+
+	// Return valid value
+	return value
 }
 
 /*
@@ -275,12 +1168,80 @@ Function to clean up the materialized view by deleting all data
 */
 func cleanMaterializedView(db *sql.DB) {
 
+	// Refuse to clean a protected environment
+	requireUnprotected("clean the materialized view")
+
+	// Truncate all partitions of a partitioned view instead of deleting every row
+	if partitionedView {
+		_, err := db.Exec("TRUNCATE materialized_view")
+		// Check on error with handler
+		checkError(err)
+		return
+	}
+
 	// Execute delete statement on database
 	_, err := db.Exec("DELETE FROM materialized_view")
 	// Check on error with handler
 	checkError(err)
 }
 
+// Object structure for the error of a run aborted by strict mode on the first error of a measurement
+type StrictAbortError struct {
+	// Measurement, that caused the error
+	Measurement Measurement
+	// Error of the measurement
+	Err error
+}
+
+/*
+Function to describe the aborted run with the details of the offending measurement
+@return Error message
+*/
+func (err *StrictAbortError) Error() string {
+	measurement := err.Measurement
+	return fmt.Sprintf("strict mode: aborting on error for measurement id=%d sensor_id=%d event_stream=%q temperature=%v humidity=%v created_on=%s processed_on=%s: %v",
+		measurement.id, measurement.sensor_id, measurement.event_stream, measurement.temperature, measurement.humidity,
+		measurement.created_on.Format(time.RFC3339Nano), measurement.processed_on.Format(time.RFC3339Nano), err.Err)
+}
+
+/*
+Function to get the error of the measurement
+@return Wrapped error
+*/
+func (err *StrictAbortError) Unwrap() error {
+	return err.Err
+}
+
+/*
+Handler for errors of a single measurement row while reading, transforming or writing.
+In strict mode the run is aborted with a StrictAbortError naming the offending measurement. Like any failure it unwinds
+through the deferred cleanup of the run (lock release, staging discard, flush of a CSV export), the menu then exits with code 1
+@param measurement Measurement, that caused the error (possibly only partially read)
+@param err Error of the row
+*/
+func handleRowError(measurement Measurement, err error) {
+
+	// Abort the run with the measurement details in strict mode
+	if strict && err != nil {
+		checkError(&StrictAbortError{Measurement: measurement, Err: err})
+	}
+
+	// Handle error with the general handler otherwise
+	checkError(err)
+}
+
+/*
+Function to abort the run in strict mode on a measurement, that a policy would skip, dead-letter or alter instead of writing
+it as read
+@param measurement Tolerated measurement
+@param err Description of the tolerated problem
+*/
+func abortTolerated(measurement Measurement, err error) {
+	if strict {
+		handleRowError(measurement, err)
+	}
+}
+
 /*
 Handler for possibly found errors
 @param err Given error to check
@@ -301,15 +1262,50 @@ type Measurement struct {
 	// Unique identifier of the sensor, that "measured" the measurement
 	sensor_id int64
 	// Measured temperature in Grad Celsius (e.g. -1°C)
-	temperature float32
+	temperature reading
 	// Measured humidity in percentage (e.g. 12%)
-	humidity float32
+	humidity reading
 	// Name of the streaming technology that was used to stream the measurement. For filtering and querying purposes
 	event_stream string
 	// Date and time with milliseconds as a timestamp on when the measurement was created
 	created_on time.Time
 	// Date and time with milliseconds as a timestamp on when the measurement was processed by the event stream and event handler (the consumer)
 	processed_on time.Time
+	// Flags for a NULL temperature or humidity in the source, whose value is then 0
+	null_temperature bool
+	null_humidity    bool
+}
+
+/*
+Function to set the readings of a measurement from nullable values and flag the NULL ones
+@param temperature Temperature or nil for NULL
+@param humidity Humidity or nil for NULL
+*/
+func (measurement *Measurement) setReadings(temperature *reading, humidity *reading) {
+	measurement.null_temperature = temperature == nil
+	if temperature != nil {
+		measurement.temperature = *temperature
+	}
+	measurement.null_humidity = humidity == nil
+	if humidity != nil {
+		measurement.humidity = *humidity
+	}
+}
+
+/*
+Function to check whether a measurement has a NULL temperature or humidity
+@return True, if one of the readings is NULL
+*/
+func (measurement Measurement) hasNullReading() bool {
+	return measurement.null_temperature || measurement.null_humidity
+}
+
+/*
+Function to check whether a measurement is dropped by the NULL policy
+@return True for a NULL reading with NULL_POLICY skip
+*/
+func (measurement Measurement) skippedByNullPolicy() bool {
+	return nullPolicy == NullPolicySkip && measurement.hasNullReading()
 }
 
 // Object structure for a transformed measurement
@@ -318,19 +1314,77 @@ type TransformedMeasurement struct {
 	Measurement
 	// Danger level of a measurement and state of the cold storage. Dependent on measured temperature and humidity
 	danger string
-	// Duration for processing a measurement event between creation timestamp and processing timestamp
+	// Duration for processing a measurement event between creation timestamp and processing timestamp in the latency unit
 	latency float32
+	// Exact duration between creation timestamp and processing timestamp in microseconds
+	latency_us int64
+	// Perceived temperature in Grad Celsius combining temperature and humidity (NaN, if not computable)
+	heat_index reading
+	// Flag for measurements of sensors, that are not listed in the sensor registry
+	unknown_sensor bool
+	// Flag for NaN or infinite readings clamped to finite ones
+	clamped bool
+}
+
+// Object structure for the results of a microbenchmark, which are printed to the console and exported
+type BenchmarkResults struct {
+	// Results and statistics in the format of the JSON export, the durations in execution order
+	BenchmarkExport
+	// Durations of the iterations in seconds sorted ascending
+	sortedDurations []float64
+	// Combined results of each iteration for the table
+	iterations []IterationResult
+	// Idle times of the pipeline reader and writer of each iteration
+	readerIdleDurations, writerIdleDurations []float64
+	// Total duration of the post-run maintenance, not included in the iteration durations
+	maintenanceDuration time.Duration
 }
 
 /*
-Function to execute the materialize process several time to measure the performance
+Function to execute the materialize process several time to measure the performance, and print and export the results
 @param db *sql.DB Database connection to Postgres database
 iterations int Number of iterations
 */
 func microbenchmark(db *sql.DB, iterations int) {
 
+	// Catch interrupts to print the statistics of the completed iterations instead of losing them
+	interrupt := watchBenchmarkInterrupt()
+	defer interrupt.stop()
+
+	// Run the iterations and report their results
+	results := runMicrobenchmark(db, iterations, interrupt)
+	if results == nil {
+		return
+	}
+	results.print()
+	results.export()
+}
+
+/*
+Function to execute the materialize process several times with warmup, convergence check and resume of an interrupted benchmark
+and to calculate the statistics of the iterations
+@param db *sql.DB Database connection to Postgres database
+@param iterations Number of (in convergence mode maximum) iterations
+@param interrupt Handler, that stops the benchmark after or within the in-flight iteration
+@return Pointer to the results or nil, if no iteration completed
+*/
+func runMicrobenchmark(db *sql.DB, iterations int, interrupt *BenchmarkInterrupt) *BenchmarkResults {
+
+	// Generate unique identifier of the benchmark run, shared by all iterations
+	runId := newRunId()
+
 	// Print information about starting the test
-	fmt.Println("Starting microbenchmark...")
+	fmt.Printf("Starting microbenchmark (run %s)...\n", runId)
+
+	// Write on an own connection pool with asynchronous commits, if the unsafe benchmark setting is enabled
+	if benchmarkSynchronousCommitOff {
+		db = openBenchmarkDatabase(dbDriver)
+		defer db.Close()
+		fmt.Println("Warning: UNSAFE benchmark setting synchronous_commit=off, commits don't wait for the WAL flush to disk")
+	}
+
+	// Effective synchronous_commit setting of the benchmark connections, which dominates the write latencies
+	commitSetting := synchronousCommit(db)
 
 	// Number of processed datapoints
 	var numberOfMeasurements int
@@ -338,82 +1392,380 @@ func microbenchmark(db *sql.DB, iterations int) {
 	// Array list for each iteration duration
 	iterationDurations := make([]float64, 0)
 
+	// Read the measurements once before the iterations, if the read phase is excluded from the benchmark
+	var cachedMeasurements []Measurement
+	if cachedRead {
+		fmt.Println("Warning: -cached-read keeps all measurements in memory for the whole benchmark, which can be large for big datasets")
+		if sourceMode == ModeCsv {
+			cachedMeasurements = readCsvMeasurements(sourceCsvPath)
+			if dedupPolicy != "" {
+				cachedMeasurements, _ = deduplicateMeasurements(cachedMeasurements)
+			}
+		} else {
+			cachedMeasurements = readMeasurements(db, "")
+		}
+	}
+
+	// Total duration of the post-run maintenance, not included in the iteration durations
+	var maintenanceDuration time.Duration
+
+	// Durations of the phases of each iteration
+	var cleanDurations, readDurations, writeDurations []float64
+
+	// Idle times of the pipeline reader and writer of each iteration
+	var readerIdleDurations, writerIdleDurations []float64
+
+	// Garbage collection statistics of each iteration, if collected
+	var gcStatistics []GCStatistics
+	var memStatsBefore, memStatsAfter runtime.MemStats
+
+	// Differences of the pg_stat counters of each iteration, if collected
+	var pgStatistics []PgStats
+	var pgStatsBefore PgStats
+
+	// Throughput samples of each iteration, if throughput logging is enabled
+	var throughputSamples [][]ThroughputSample
+
+	// Load the iterations of an interrupted benchmark to continue it, if comparable with the current settings
+	var resumed *BenchmarkExport
+	var ownRunId string
+	if resumePath != "" {
+		resumed = readBenchmarkExport(resumePath)
+		if resumed != nil && (resumed.CleanIncluded != benchmarkIncludeClean || resumed.CachedRead != cachedRead) {
+			fmt.Printf("Warning: the results in %s were recorded with different clean/read settings, starting a new benchmark instead of mixing incomparable runs\n", resumePath)
+			resumed = nil
+		}
+		if resumed != nil {
+			ownRunId = runId
+			runId = resumed.RunId
+			iterationDurations = append(iterationDurations, resumed.Durations...)
+			cleanDurations = append(cleanDurations, resumed.CleanDurations...)
+			readDurations = append(readDurations, resumed.ReadDurations...)
+			writeDurations = append(writeDurations, resumed.WriteDurations...)
+			gcStatistics = append(gcStatistics, resumed.GCStatistics...)
+			pgStatistics = append(pgStatistics, resumed.PgStatistics...)
+			throughputSamples = append(throughputSamples, resumed.ThroughputSamples...)
+			fmt.Printf("Resuming run %s with %d previous iterations from %s\n", runId, len(resumed.Durations), resumePath)
+		}
+	}
+
+	// Run warmup iterations, which are neither recorded nor part of the convergence check
+	for i := 0; i < benchmarkWarmup && !interrupt.requested(); i++ {
+		materialize(interrupt.ctx, db, runId, cachedMeasurements)
+		analyzeMaterializedView(db)
+		fmt.Printf("Warmup iteration %d/%d finished\n", (i + 1), benchmarkWarmup)
+	}
+
+	// Reason, why the benchmark stopped
+	stopReason := "requested iterations completed"
+	if convergenceThreshold > 0 {
+		stopReason = "maximum iterations reached"
+	}
+
+	// Save starting time point of the measured iterations for the time cap
+	benchmarkStart := time.Now()
+
+	// Keep the starting time point of the resumed benchmark for the results
+	timestamp := benchmarkStart
+	if resumed != nil {
+		timestamp = resumed.Timestamp
+	}
+
 	for i := 0; i < iterations; i++ {
+		// Stop before the next iteration, if interrupted
+		if interrupt.requested() {
+			stopReason = "interrupted"
+			break
+		}
+
+		// Snapshot the pg_stat counters before the timed region
+		if benchmarkPgStats {
+			pgStatsBefore = snapshotPgStats(db)
+		}
+
+		// Snapshot memory statistics before the timed region, as reading them stops the world
+		if benchmarkGCStats {
+			runtime.ReadMemStats(&memStatsBefore)
+		}
+
 		// Save starting time point
 		start := time.Now()
 
 		// Call materialize function with opened database connection
-		numberOfMeasurements = materialize(db)
+		summary := materialize(interrupt.ctx, db, runId, cachedMeasurements)
+
+		// Discard the abandoned iteration of an interrupt, it didn't process all measurements
+		if summary.stopped != nil && interrupt.requested() {
+			stopReason = "interrupted"
+			break
+		}
+		numberOfMeasurements = summary.measurements
 
 		// Save end time point and calculate difference between start and end time to calculate the materialize process time
 		end := time.Now()
 		elapsed := end.Sub(start)
 
+		// Snapshot memory statistics after the timed region and add the garbage collections of the iteration
+		if benchmarkGCStats {
+			runtime.ReadMemStats(&memStatsAfter)
+			gcStatistics = append(gcStatistics, gcDelta(&memStatsBefore, &memStatsAfter))
+		}
+
+		// Snapshot the pg_stat counters after the timed region and add the difference of the iteration
+		if benchmarkPgStats {
+			pgStatistics = append(pgStatistics, pgStatsDelta(pgStatsBefore, snapshotPgStats(db)))
+		}
+
+		// Exclude the clean phase at the start of the iteration, so the clock effectively starts with the read
+		if !benchmarkIncludeClean {
+			elapsed -= summary.cleanDuration
+		}
+
+		// Add phase durations to arrays
+		cleanDurations = append(cleanDurations, summary.cleanDuration.Seconds())
+		readDurations = append(readDurations, summary.readDuration.Seconds())
+		writeDurations = append(writeDurations, summary.writeDuration.Seconds())
+		readerIdleDurations = append(readerIdleDurations, summary.readerIdle.Seconds())
+		writerIdleDurations = append(writerIdleDurations, summary.writerIdle.Seconds())
+		if summary.throughputSamples != nil {
+			throughputSamples = append(throughputSamples, summary.throughputSamples)
+		}
+
+		// Discard the resumed iterations, if the dataset changed in the meantime and they aren't comparable anymore
+		if resumed != nil && resumed.Measurements != numberOfMeasurements {
+			fmt.Printf("Warning: the resumed iterations processed %d datapoints, but the dataset now has %d. Discarding them instead of mixing incomparable runs\n", resumed.Measurements, numberOfMeasurements)
+			iterationDurations = iterationDurations[len(resumed.Durations):]
+			cleanDurations = cleanDurations[len(resumed.CleanDurations):]
+			readDurations = readDurations[len(resumed.ReadDurations):]
+			writeDurations = writeDurations[len(resumed.WriteDurations):]
+			gcStatistics = gcStatistics[len(resumed.GCStatistics):]
+			pgStatistics = pgStatistics[len(resumed.PgStatistics):]
+			throughputSamples = throughputSamples[len(resumed.ThroughputSamples):]
+			runId = ownRunId
+			timestamp = benchmarkStart
+			resumed = nil
+		}
+
 		// Add duration to array
 		iterationDurations = append(iterationDurations, elapsed.Seconds())
 
+		// Save the iterations so far, so an interrupted benchmark can be resumed
+		if resumePath != "" {
+			writeBenchmarkExport(resumePath, BenchmarkExport{
+				RunId:              runId,
+				Timestamp:          timestamp,
+				Measurements:       numberOfMeasurements,
+				ExecutedIterations: len(iterationDurations),
+				StopReason:         "interrupted",
+				Durations:          iterationDurations,
+				CleanIncluded:      benchmarkIncludeClean,
+				CachedRead:         cachedRead,
+				LatencyUnit:        latencyUnit.Name,
+				GCPercent:          gcPercent,
+				GCStatistics:       gcStatistics,
+				PgStatistics:       pgStatistics,
+				ThroughputSamples:  throughputSamples,
+				CleanDurations:     cleanDurations,
+				ReadDurations:      readDurations,
+				WriteDurations:     writeDurations,
+			})
+		}
+
+		// Refresh planner statistics of the rebuilt view outside of the timed region
+		maintenanceDuration += analyzeMaterializedView(db)
+
 		// Print needed time for materializing
 		fmt.Printf("Iteration %d/%d finished\n", (i + 1), iterations)
+
+		// Stop in convergence mode, when the results are stable enough or the time cap is hit
+		if convergenceThreshold > 0 {
+			relativeError := relativeStandardError(iterationDurations)
+			if len(iterationDurations) >= minConvergenceIterations && relativeError < convergenceThreshold {
+				stopReason = fmt.Sprintf("converged (relative standard error %.4f < %.4f)", relativeError, convergenceThreshold)
+				break
+			}
+			if benchmarkMaxTime > 0 && time.Since(benchmarkStart) >= benchmarkMaxTime {
+				stopReason = fmt.Sprintf("time cap of %s reached", benchmarkMaxTime)
+				break
+			}
+		}
 	}
 
-	// Make copy of unordered list
-	unorderedIterationDurations := make([]float64, len(iterationDurations))
-	copy(unorderedIterationDurations, iterationDurations)
+	// Capture the query plans once after the measured iterations, so the EXPLAIN ANALYZE executions aren't timed
+	var queryPlans []QueryPlan
+	if benchmarkExplain && !interrupt.requested() {
+		fmt.Println("Capturing query plans with EXPLAIN ANALYZE (not part of the measured iterations)...")
+		queryPlans = captureQueryPlans(db)
+		writeQueryPlans(queryPlans, runId)
+	}
 
-	// Sort iteration durations
-	sort.Slice(iterationDurations, func(i, j int) bool {
-		return iterationDurations[i] < iterationDurations[j]
-	})
+	// Combine the results of each iteration for the table, before dropping invalid durations shifts the iterations
+	results := &BenchmarkResults{
+		iterations:          iterationResults(iterationDurations, numberOfMeasurements, cleanDurations, readDurations, writeDurations, gcStatistics),
+		readerIdleDurations: readerIdleDurations,
+		writerIdleDurations: writerIdleDurations,
+		maintenanceDuration: maintenanceDuration,
+	}
 
-	// Calculate average duration
-	// Get total of all values
-	var sum float64
-	for i := 0; i < len(iterationDurations); i++ {
-		sum += (iterationDurations[i])
+	// Drop NaN or infinite durations (e.g. of a corrupted results file), which would turn every statistic into NaN
+	iterationDurations, nonFiniteDurations := finiteValues(iterationDurations)
+	if nonFiniteDurations > 0 {
+		fmt.Printf("Warning: excluded %d NaN or infinite iteration durations from the statistics\n", nonFiniteDurations)
 	}
-	// Divide total by number of iterations
-	var averageDuration float64 = sum / float64(iterations)
 
-	// Calculate median duration
-	var medianDuration float64
-	// For even iterations
-	if iterations%2 == 0 {
-		medianDuration = (iterationDurations[iterations/2] + iterationDurations[iterations/2-1]) / 2
-		// For odd iterations
-	} else {
-		medianDuration = (iterationDurations[iterations/2])
+	// Without completed iterations there are no statistics
+	if len(iterationDurations) == 0 {
+		fmt.Println("Microbenchmark interrupted before any iteration completed, no statistics")
+		return nil
 	}
 
-	// Calculate variance and standard deviation
-	var temp, standardDeviation, variance float64
+	// Number of iterations taken over from the resumed benchmark
+	var resumedIterations int
+	if resumed != nil {
+		resumedIterations = len(resumed.Durations)
+	}
 
-	// Find sum of square distances to the mean for every duration
-	for i := 0; i < len(iterationDurations); i++ {
-		temp += math.Pow(iterationDurations[i]-averageDuration, 2)
+	// Calculate the statistics from a sorted copy of the durations, keeping the durations in execution order
+	results.sortedDurations = append([]float64(nil), iterationDurations...)
+	sort.Float64s(results.sortedDurations)
+	averageDuration, standardDeviation := meanAndStandardDeviation(iterationDurations)
+	trimmed, trimmedValid := trimmedMean(results.sortedDurations)
+	results.BenchmarkExport = BenchmarkExport{
+		RunId:                   runId,
+		Timestamp:               timestamp,
+		Measurements:            numberOfMeasurements,
+		RequestedIterations:     iterations,
+		ExecutedIterations:      len(iterationDurations),
+		ResumedIterations:       resumedIterations,
+		WarmupIterations:        benchmarkWarmup,
+		StopReason:              stopReason,
+		Partial:                 stopReason == "interrupted",
+		ConvergenceThreshold:    convergenceThreshold,
+		RelativeStandardError:   finiteOrNil(relativeStandardError(iterationDurations)),
+		Min:                     results.sortedDurations[0],
+		Max:                     results.sortedDurations[len(results.sortedDurations)-1],
+		Mean:                    averageDuration,
+		Median:                  median(iterationDurations),
+		StandardDeviation:       standardDeviation,
+		Variance:                standardDeviation * standardDeviation,
+		MedianAbsoluteDeviation: medianAbsoluteDeviation(results.sortedDurations),
+		TrimmedMean:             trimmedOrNil(trimmed, trimmedValid),
+		InterquartileRange:      interquartileRange(results.sortedDurations),
+		Durations:               iterationDurations,
+		CleanIncluded:           benchmarkIncludeClean,
+		CachedRead:              cachedRead,
+		LatencyUnit:             latencyUnit.Name,
+		Connection:              connectionTransport(),
+		Build:                   buildInfo(),
+		SynchronousCommit:       commitSetting,
+		UnsafeSettings:          benchmarkSynchronousCommitOff,
+		GCPercent:               gcPercent,
+		GCStatistics:            gcStatistics,
+		PgStatistics:            pgStatistics,
+		ThroughputSamples:       throughputSamples,
+		CleanDurations:          cleanDurations,
+		ReadDurations:           readDurations,
+		WriteDurations:          writeDurations,
+		QueryPlans:              queryPlans,
 	}
+	return results
+}
 
-	// Divide sum by number of iterations to get variance
-	variance = temp / float64(len(iterationDurations))
-	// Take square root for standard deviation
-	standardDeviation = math.Sqrt(variance)
+/*
+Function to print the statistics and the iteration table of microbenchmark results to the console
+*/
+func (results *BenchmarkResults) print() {
 
-	// Print information about finished test
-	fmt.Print("Microbenchmark finished\n\n")
+	// Print information about finished test, marked as partial, if interrupted
+	if results.Partial {
+		fmt.Printf("Microbenchmark interrupted, PARTIAL results over %d/%d completed iterations\n\n", results.ExecutedIterations, results.RequestedIterations)
+	} else {
+		fmt.Print("Microbenchmark finished\n\n")
+	}
 
 	// Display string with microbenchmark statistics to the console
 	fmt.Println("Go Materializer Microbenchmark")
-	fmt.Printf("Number of Iterations:\t\t%d\n", iterations)
-	fmt.Printf("Datapoints processed each:\t%d\n", numberOfMeasurements)
-	fmt.Printf("Fastest iteration (min):\t%f seconds\n", iterationDurations[0])
-	fmt.Printf("Slowest iteration (max):\t%f seconds\n", iterationDurations[len(iterationDurations)-1])
-	fmt.Printf("Average duration (avg/mean):\t%f seconds\n", averageDuration)
-	fmt.Printf("Median duration (median):\t%f seconds\n", medianDuration)
-	fmt.Printf("Standard deviation:\t\t%f seconds\n", standardDeviation)
-	fmt.Printf("Variance:\t\t\t%f seconds\n\n\n", variance)
-	fmt.Println("All runs:")
-	fmt.Println(iterationDurations)
-	fmt.Println()
-	fmt.Println("All runs (unsorted):")
-	fmt.Println(unorderedIterationDurations)
+	localePrintf("Run id:\t\t\t\t%s\n", results.RunId)
+	localePrintf("Build:\t\t\t\t%s\n", results.Build)
+	if results.Partial {
+		localePrintf("Number of Iterations:\t\t%d of %d requested (PARTIAL)\n", results.ExecutedIterations, results.RequestedIterations)
+	} else {
+		localePrintf("Number of Iterations:\t\t%d\n", results.ExecutedIterations)
+	}
+	if results.ConvergenceThreshold > 0 || results.Partial {
+		localePrintf("Warmup iterations (excl.):\t%d\n", results.WarmupIterations)
+		localePrintf("Stopped because:\t\t%s\n", results.StopReason)
+	}
+	localePrintf("Datapoints processed each:\t%d\n", results.Measurements)
+	localePrintf("Fastest iteration (min):\t%f seconds\n", results.Min)
+	localePrintf("Slowest iteration (max):\t%f seconds\n", results.Max)
+	localePrintf("Average duration (avg/mean):\t%f seconds\n", results.Mean)
+	localePrintf("Median duration (median):\t%f seconds\n", results.Median)
+	localePrintf("Standard deviation:\t\t%f seconds\n", results.StandardDeviation)
+	localePrintf("Variance:\t\t\t%f seconds\n", results.Variance)
+	localePrintf("Median abs. deviation (scaled):\t%f seconds\n", results.MedianAbsoluteDeviation)
+	if results.TrimmedMean != nil {
+		localePrintf("10%% trimmed mean:\t\t%f seconds\n", *results.TrimmedMean)
+	} else {
+		localePrintf("10%% trimmed mean:\t\tn/a (less than %d iterations)\n", minTrimmedMeanValues)
+	}
+	localePrintf("Interquartile range:\t\t%f seconds\n", results.InterquartileRange)
+	localePrintf("Post-run maintenance (excl.):\t%f seconds\n", results.maintenanceDuration.Seconds())
+	localePrintf("Clean included in durations:\t%t\n", results.CleanIncluded)
+	localePrintf("Read included in durations:\t%t\n", !results.CachedRead)
+	if results.UnsafeSettings {
+		localePrintf("Synchronous commit:\t\t%s (UNSAFE benchmark setting)\n", results.SynchronousCommit)
+	} else {
+		localePrintf("Synchronous commit:\t\t%s\n", results.SynchronousCommit)
+	}
+	localePrintf("Average clean phase:\t\t%f seconds\n", mean(results.CleanDurations))
+	localePrintf("Average read phase:\t\t%f seconds\n", mean(results.ReadDurations))
+	localePrintf("Average transform/write phase:\t%f seconds\n", mean(results.WriteDurations))
+	if pipelineMode {
+		localePrintf("Average reader idle:\t\t%f seconds\n", mean(results.readerIdleDurations))
+		localePrintf("Average writer idle:\t\t%f seconds\n", mean(results.writerIdleDurations))
+	}
+	if benchmarkGCStats {
+		// Aggregate garbage collections of all iterations
+		var collections uint32
+		var totalPause, maxPause float64
+		for _, statistics := range results.GCStatistics {
+			collections += statistics.NumGC
+			totalPause += statistics.TotalPause
+			if statistics.MaxPause > maxPause {
+				maxPause = statistics.MaxPause
+			}
+		}
+		localePrintf("GC percent:\t\t\t%d\n", results.GCPercent)
+		localePrintf("Garbage collections:\t\t%d\n", collections)
+		localePrintf("Total GC pause:\t\t\t%f seconds\n", totalPause)
+		localePrintf("Longest GC pause:\t\t%f seconds\n", maxPause)
+	}
+	if benchmarkPgStats {
+		// Aggregate the database counters of all iterations
+		var blocksRead, blocksHit, deadlocks int64
+		for _, statistics := range results.PgStatistics {
+			if statistics.Database != nil {
+				blocksRead += statistics.Database.BlocksRead
+				blocksHit += statistics.Database.BlocksHit
+				deadlocks += statistics.Database.Deadlocks
+			}
+		}
+		localePrintf("Blocks read/hit (pg_stat):\t%d/%d (%.2f%% hit ratio)\n", blocksRead, blocksHit, hitRatio(blocksHit, blocksRead))
+		localePrintf("Deadlocks (pg_stat):\t\t%d\n", deadlocks)
+	}
+	fmt.Print("\n\n")
+	printIterationTable(results.iterations)
 	fmt.Println()
 }
+
+/*
+Function to export microbenchmark results as JSON file, if configured, and complete the results file of a resumable benchmark
+*/
+func (results *BenchmarkResults) export() {
+	for _, path := range []string{benchmarkExport, resumePath} {
+		if path != "" {
+			writeBenchmarkExport(path, results.BenchmarkExport)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+// @author 1Zero64
+// gRPC service exposing the operations of the materializer to remote clients, e.g. an experiment orchestrator.
+// The Go stubs are generated with:
+// protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative materializer.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: materializer.proto
+
+package materializerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	Materializer_Materialize_FullMethodName  = "/materializer.Materializer/Materialize"
+	Materializer_RunBenchmark_FullMethodName = "/materializer.Materializer/RunBenchmark"
+	Materializer_Clean_FullMethodName        = "/materializer.Materializer/Clean"
+	Materializer_GetStats_FullMethodName     = "/materializer.Materializer/GetStats"
+)
+
+// MaterializerClient is the client API for Materializer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Operations of the materializer. Only one mutating operation (Materialize, RunBenchmark, Clean) runs at a time,
+// a second one fails with ABORTED. GetStats can be called concurrently at any time
+type MaterializerClient interface {
+	// Materialize the event store into the materialized view, streaming the progress and finally the result of the run.
+	// Cancelling the call stops the run gracefully
+	Materialize(ctx context.Context, in *MaterializeRequest, opts ...grpc.CallOption) (Materializer_MaterializeClient, error)
+	// Run the materialize process several times and return the duration of each iteration
+	RunBenchmark(ctx context.Context, in *RunBenchmarkRequest, opts ...grpc.CallOption) (*RunBenchmarkResponse, error)
+	// Delete all rows of the materialized view
+	Clean(ctx context.Context, in *CleanRequest, opts ...grpc.CallOption) (*CleanResponse, error)
+	// Row counts of the event store and the materialized view and the result of the last run
+	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
+}
+
+type materializerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMaterializerClient(cc grpc.ClientConnInterface) MaterializerClient {
+	return &materializerClient{cc}
+}
+
+func (c *materializerClient) Materialize(ctx context.Context, in *MaterializeRequest, opts ...grpc.CallOption) (Materializer_MaterializeClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Materializer_ServiceDesc.Streams[0], Materializer_Materialize_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &materializerMaterializeClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Materializer_MaterializeClient interface {
+	Recv() (*MaterializeProgress, error)
+	grpc.ClientStream
+}
+
+type materializerMaterializeClient struct {
+	grpc.ClientStream
+}
+
+func (x *materializerMaterializeClient) Recv() (*MaterializeProgress, error) {
+	m := new(MaterializeProgress)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *materializerClient) RunBenchmark(ctx context.Context, in *RunBenchmarkRequest, opts ...grpc.CallOption) (*RunBenchmarkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RunBenchmarkResponse)
+	err := c.cc.Invoke(ctx, Materializer_RunBenchmark_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *materializerClient) Clean(ctx context.Context, in *CleanRequest, opts ...grpc.CallOption) (*CleanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CleanResponse)
+	err := c.cc.Invoke(ctx, Materializer_Clean_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *materializerClient) GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStatsResponse)
+	err := c.cc.Invoke(ctx, Materializer_GetStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MaterializerServer is the server API for Materializer service.
+// All implementations must embed UnimplementedMaterializerServer
+// for forward compatibility
+//
+// Operations of the materializer. Only one mutating operation (Materialize, RunBenchmark, Clean) runs at a time,
+// a second one fails with ABORTED. GetStats can be called concurrently at any time
+type MaterializerServer interface {
+	// Materialize the event store into the materialized view, streaming the progress and finally the result of the run.
+	// Cancelling the call stops the run gracefully
+	Materialize(*MaterializeRequest, Materializer_MaterializeServer) error
+	// Run the materialize process several times and return the duration of each iteration
+	RunBenchmark(context.Context, *RunBenchmarkRequest) (*RunBenchmarkResponse, error)
+	// Delete all rows of the materialized view
+	Clean(context.Context, *CleanRequest) (*CleanResponse, error)
+	// Row counts of the event store and the materialized view and the result of the last run
+	GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error)
+	mustEmbedUnimplementedMaterializerServer()
+}
+
+// UnimplementedMaterializerServer must be embedded to have forward compatible implementations.
+type UnimplementedMaterializerServer struct {
+}
+
+func (UnimplementedMaterializerServer) Materialize(*MaterializeRequest, Materializer_MaterializeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Materialize not implemented")
+}
+func (UnimplementedMaterializerServer) RunBenchmark(context.Context, *RunBenchmarkRequest) (*RunBenchmarkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunBenchmark not implemented")
+}
+func (UnimplementedMaterializerServer) Clean(context.Context, *CleanRequest) (*CleanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Clean not implemented")
+}
+func (UnimplementedMaterializerServer) GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedMaterializerServer) mustEmbedUnimplementedMaterializerServer() {}
+
+// UnsafeMaterializerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MaterializerServer will
+// result in compilation errors.
+type UnsafeMaterializerServer interface {
+	mustEmbedUnimplementedMaterializerServer()
+}
+
+func RegisterMaterializerServer(s grpc.ServiceRegistrar, srv MaterializerServer) {
+	s.RegisterService(&Materializer_ServiceDesc, srv)
+}
+
+func _Materializer_Materialize_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(MaterializeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MaterializerServer).Materialize(m, &materializerMaterializeServer{ServerStream: stream})
+}
+
+type Materializer_MaterializeServer interface {
+	Send(*MaterializeProgress) error
+	grpc.ServerStream
+}
+
+type materializerMaterializeServer struct {
+	grpc.ServerStream
+}
+
+func (x *materializerMaterializeServer) Send(m *MaterializeProgress) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Materializer_RunBenchmark_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunBenchmarkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MaterializerServer).RunBenchmark(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Materializer_RunBenchmark_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MaterializerServer).RunBenchmark(ctx, req.(*RunBenchmarkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Materializer_Clean_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CleanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MaterializerServer).Clean(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Materializer_Clean_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MaterializerServer).Clean(ctx, req.(*CleanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Materializer_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MaterializerServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Materializer_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MaterializerServer).GetStats(ctx, req.(*GetStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Materializer_ServiceDesc is the grpc.ServiceDesc for Materializer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Materializer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "materializer.Materializer",
+	HandlerType: (*MaterializerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RunBenchmark",
+			Handler:    _Materializer_RunBenchmark_Handler,
+		},
+		{
+			MethodName: "Clean",
+			Handler:    _Materializer_Clean_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _Materializer_GetStats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Materialize",
+			Handler:       _Materializer_Materialize_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "materializer.proto",
+}
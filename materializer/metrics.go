@@ -0,0 +1,134 @@
+package main
+
+/*
+@author 1Zero64
+Export of run metrics in Prometheus exposition format for the node_exporter textfile collector
+*/
+
+// Importing packages
+import (
+	// Package for in-memory byte buffers
+	"bytes"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for manipulating file paths
+	"path/filepath"
+	// Package for string manipulation
+	"strings"
+)
+
+// Replacer of the characters, which have to be escaped in a label value of the exposition format
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+/*
+Function to quote a label value for the exposition format, which only escapes backslash, double quote and newline and
+keeps other characters as UTF-8
+@param value Label value
+@return Quoted label value
+*/
+func prometheusLabel(value string) string {
+	return `"` + prometheusLabelEscaper.Replace(value) + `"`
+}
+
+/*
+Function to write the danger level histogram and last run metrics into a .prom file.
+The file is written into a temporary file first and renamed afterwards, so the collector never reads a partial file
+@param path Path of the .prom file
+@param summary Summary of the finished run
+*/
+func writePrometheusFile(path string, summary *RunSummary) {
+
+	// Build metrics in exposition format
+	var buffer bytes.Buffer
+
+	// Identifier of the last run
+	fmt.Fprintln(&buffer, "# HELP materializer_last_run_info Information about the last run.")
+	fmt.Fprintln(&buffer, "# TYPE materializer_last_run_info gauge")
+	fmt.Fprintf(&buffer, "materializer_last_run_info{run_id=%s} 1\n", prometheusLabel(summary.runId))
+
+	// Danger level histogram
+	fmt.Fprintln(&buffer, "# HELP materializer_danger_level_measurements Number of measurements per danger level in the last run.")
+	fmt.Fprintln(&buffer, "# TYPE materializer_danger_level_measurements gauge")
+	for _, level := range dangerLevels {
+		fmt.Fprintf(&buffer, "materializer_danger_level_measurements{level=%s} %d\n", prometheusLabel(level), summary.dangerLevels[level])
+	}
+
+	// Number of measurements of the last run
+	fmt.Fprintln(&buffer, "# HELP materializer_last_run_measurements Number of measurements materialized in the last run.")
+	fmt.Fprintln(&buffer, "# TYPE materializer_last_run_measurements gauge")
+	fmt.Fprintf(&buffer, "materializer_last_run_measurements %d\n", summary.measurements)
+
+	// Skipped measurements of the last run per reason
+	fmt.Fprintln(&buffer, "# HELP materializer_last_run_skipped_measurements Number of measurements skipped per reason in the last run.")
+	fmt.Fprintln(&buffer, "# TYPE materializer_last_run_skipped_measurements gauge")
+	for _, count := range summary.skipCounts() {
+		fmt.Fprintf(&buffer, "materializer_last_run_skipped_measurements{reason=%s} %d\n", prometheusLabel(count.Reason), count.Count)
+	}
+
+	// Latency SLA breaches of the last run
+	if latencySla > 0 {
+		fmt.Fprintln(&buffer, "# HELP materializer_last_run_sla_breaches Number of measurements exceeding the latency SLA in the last run.")
+		fmt.Fprintln(&buffer, "# TYPE materializer_last_run_sla_breaches gauge")
+		fmt.Fprintf(&buffer, "materializer_last_run_sla_breaches %d\n", summary.slaBreaches)
+	}
+
+	// Backfilled measurements of the last run
+	if backfillThreshold > 0 {
+		fmt.Fprintln(&buffer, "# HELP materializer_last_run_backfilled_measurements Number of measurements processed later than the backfill threshold after their creation in the last run.")
+		fmt.Fprintln(&buffer, "# TYPE materializer_last_run_backfilled_measurements gauge")
+		fmt.Fprintf(&buffer, "materializer_last_run_backfilled_measurements %d\n", summary.backfill.measurements)
+	}
+
+	// Duration of the last run
+	fmt.Fprintln(&buffer, "# HELP materializer_last_run_duration_seconds Duration of the last run in seconds.")
+	fmt.Fprintln(&buffer, "# TYPE materializer_last_run_duration_seconds gauge")
+	fmt.Fprintf(&buffer, "materializer_last_run_duration_seconds %f\n", summary.duration.Seconds())
+
+	// Finish time of the last run
+	fmt.Fprintln(&buffer, "# HELP materializer_last_run_timestamp_seconds Unix time on when the last run finished.")
+	fmt.Fprintln(&buffer, "# TYPE materializer_last_run_timestamp_seconds gauge")
+	fmt.Fprintf(&buffer, "materializer_last_run_timestamp_seconds %d\n", summary.start.Add(summary.duration).Unix())
+
+	// Replace the .prom file with the metrics
+	replacePrometheusFile(path, &buffer)
+}
+
+/*
+Function to atomically replace a .prom file with metrics in exposition format
+@param path Path of the .prom file
+@param buffer Metrics in exposition format
+*/
+func replacePrometheusFile(path string, buffer *bytes.Buffer) {
+
+	// Write metrics into a temporary file in the same directory, so the rename stays on the same file system
+	tempFile, err := os.CreateTemp(filepath.Dir(path), ".materializer-*.prom.tmp")
+	// Check on error with handler
+	checkError(err)
+
+	// Remove the temporary file on every failure, so failed writes of long-running loops don't pile them up
+	renamed := false
+	defer func() {
+		if !renamed {
+			tempFile.Close()
+			os.Remove(tempFile.Name())
+		}
+	}()
+
+	// Write buffer into temporary file and close it
+	_, err = tempFile.Write(buffer.Bytes())
+	checkError(err)
+	err = tempFile.Close()
+	checkError(err)
+
+	// Make file readable for the collector
+	err = os.Chmod(tempFile.Name(), 0644)
+	checkError(err)
+
+	// Atomically replace the .prom file with the temporary file
+	err = os.Rename(tempFile.Name(), path)
+	// Check on error with handler
+	checkError(err)
+	renamed = true
+}
@@ -0,0 +1,102 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the export of run metrics in Prometheus exposition format
+*/
+
+// Importing packages
+import (
+	// Package for in-memory byte buffers
+	"bytes"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for manipulating file paths
+	"path/filepath"
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Test that replacing a .prom file leaves only the complete file readable for the collector and no temporary files behind
+@param t Test state
+*/
+func TestReplacePrometheusFile(t *testing.T) {
+	directory := t.TempDir()
+	path := filepath.Join(directory, "materializer.prom")
+
+	// Replace the file twice, the second write must fully replace the first
+	replacePrometheusFile(path, bytes.NewBufferString("materializer_last_run_measurements 1\n"))
+	replacePrometheusFile(path, bytes.NewBufferString("materializer_last_run_measurements 2\n"))
+
+	// Check content and mode of the file
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "materializer_last_run_measurements 2\n" {
+		t.Errorf("content = %q, want the second write", content)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("mode = %v, want 0644", info.Mode().Perm())
+	}
+
+	// Check that no temporary file is left over
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory contains %d files, want only the .prom file", len(entries))
+	}
+}
+
+/*
+Test that a failed replacement of a .prom file doesn't leave its temporary file behind
+@param t Test state
+*/
+func TestReplacePrometheusFileRemovesTemporaryFileOnFailure(t *testing.T) {
+	directory := t.TempDir()
+
+	// A directory in place of the .prom file fails the rename
+	path := filepath.Join(directory, "materializer.prom")
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	expectFailure(t, func() { replacePrometheusFile(path, bytes.NewBufferString("materializer_last_run_measurements 1\n")) })
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory contains %d entries, want only the directory in place of the .prom file", len(entries))
+	}
+}
+
+/*
+Test that label values are escaped like the exposition format defines it, only backslash, double quote and newline
+@param t Test state
+*/
+func TestPrometheusLabel(t *testing.T) {
+	cases := []struct {
+		value string
+		want  string
+	}{
+		{"materializer", `"materializer"`},
+		{`C:\slot`, `"C:\\slot"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{"two\nlines", `"two\nlines"`},
+		// Non-ASCII characters and tabs stay as they are, unlike with Go quoting
+		{"Kühlhaus\tö", "\"Kühlhaus\tö\""},
+	}
+	for _, testCase := range cases {
+		if got := prometheusLabel(testCase.value); got != testCase.want {
+			t.Errorf("prometheusLabel(%q) = %s, want %s", testCase.value, got, testCase.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the microbenchmark shared by the menu and the gRPC service
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package for math functions
+	"math"
+	// Package for manipulating file paths
+	"path/filepath"
+	// Package for automated tests
+	"testing"
+
+	// Generated messages and stubs of the gRPC service
+	pb "Users/nikokauz/git/ESC-Streaming-Architectures-Thesis-Materializer/materializer/materializerpb"
+)
+
+/*
+Function to configure a microbenchmark of a CSV source with three measurements, one warmup iteration and a JSON export,
+the configuration is restored when the test finished
+@param t Test state
+@return Path of the JSON export
+*/
+func useTestMicrobenchmark(t *testing.T) string {
+	t.Helper()
+	useCsvSourceAndOutput(t, csvSourceHeader+
+		"1,7,4,45,room-1,2024-01-05T10:00:00Z,2024-01-05T10:00:01Z\n"+
+		"2,7,5,46,room-1,2024-01-05T10:01:00Z,2024-01-05T10:01:01Z\n"+
+		"3,8,6,47,room-2,2024-01-05T10:02:00Z,2024-01-05T10:02:01Z\n")
+	savedWarmup, savedConvergence, savedExport, savedResume := benchmarkWarmup, convergenceThreshold, benchmarkExport, resumePath
+	t.Cleanup(func() {
+		benchmarkWarmup, convergenceThreshold, benchmarkExport, resumePath = savedWarmup, savedConvergence, savedExport, savedResume
+	})
+	benchmarkWarmup, convergenceThreshold, resumePath = 1, 0, ""
+	benchmarkExport = filepath.Join(t.TempDir(), "benchmark.json")
+	return benchmarkExport
+}
+
+/*
+Test that the microbenchmark returns the statistics of its recorded iterations and exports them
+@param t Test state
+*/
+func TestRunMicrobenchmark(t *testing.T) {
+	export := useTestMicrobenchmark(t)
+	interrupt := contextBenchmarkInterrupt(context.Background())
+	defer interrupt.stop()
+
+	var results *BenchmarkResults
+	captureStdout(t, func() {
+		results = runMicrobenchmark(openStubDatabase(t), 4, interrupt)
+		results.export()
+	})
+	if results == nil {
+		t.Fatal("no results")
+	}
+
+	// The warmup iteration isn't recorded
+	if results.ExecutedIterations != 4 || len(results.Durations) != 4 || len(results.iterations) != 4 || results.Measurements != 3 {
+		t.Fatalf("%d iterations with %d durations of %d measurements, want 4 of 3", results.ExecutedIterations, len(results.Durations), results.Measurements)
+	}
+	if results.Partial || results.StopReason != "requested iterations completed" || results.SynchronousCommit != "on" {
+		t.Errorf("stop reason %q, partial %t, synchronous commit %q", results.StopReason, results.Partial, results.SynchronousCommit)
+	}
+	average, deviation := meanAndStandardDeviation(results.Durations)
+	if results.Mean != average || results.StandardDeviation != deviation || results.Median != median(results.Durations) {
+		t.Errorf("mean %v, deviation %v, median %v differ from the ones of the durations", results.Mean, results.StandardDeviation, results.Median)
+	}
+	if results.Min > results.Median || results.Median > results.Max || results.Min != results.sortedDurations[0] {
+		t.Errorf("min %v, median %v, max %v aren't ordered", results.Min, results.Median, results.Max)
+	}
+	if results.TrimmedMean != nil || math.IsNaN(results.MedianAbsoluteDeviation) || math.IsNaN(results.InterquartileRange) {
+		t.Errorf("trimmed mean %v of 4 iterations, MAD %v, IQR %v", results.TrimmedMean, results.MedianAbsoluteDeviation, results.InterquartileRange)
+	}
+
+	// The export holds the same results
+	exported := readBenchmarkExport(export)
+	if exported == nil || exported.RunId != results.RunId || exported.Mean != results.Mean || exported.WarmupIterations != 1 {
+		t.Errorf("export %+v doesn't match the results", exported)
+	}
+}
+
+/*
+Test that a benchmark, whose context is cancelled before the first iteration, has no results
+@param t Test state
+*/
+func TestRunMicrobenchmarkCancelled(t *testing.T) {
+	useTestMicrobenchmark(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	interrupt := contextBenchmarkInterrupt(ctx)
+	defer interrupt.stop()
+
+	var results *BenchmarkResults
+	captureStdout(t, func() { results = runMicrobenchmark(openStubDatabase(t), 4, interrupt) })
+	if results != nil {
+		t.Errorf("results of %d iterations, want none", results.ExecutedIterations)
+	}
+}
+
+/*
+Test that RunBenchmark over the API runs the microbenchmark of the menu and returns its durations and mean
+@param t Test state
+*/
+func TestGrpcRunBenchmark(t *testing.T) {
+	useTestMicrobenchmark(t)
+	client := startTestGrpcServer(t, &GrpcServer{db: openStubDatabase(t)})
+
+	var response *pb.RunBenchmarkResponse
+	var err error
+	captureStdout(t, func() {
+		response, err = client.RunBenchmark(context.Background(), &pb.RunBenchmarkRequest{Iterations: 2})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.RunId == "" || len(response.DurationsSeconds) != 2 || response.Measurements != 3 || response.MeanSeconds != mean(response.DurationsSeconds) {
+		t.Errorf("response %v, want 2 iterations of 3 measurements with their mean", response)
+	}
+}
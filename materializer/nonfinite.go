@@ -0,0 +1,72 @@
+package main
+
+/*
+@author 1Zero64
+Detection of NaN and infinite readings and statistics over finite values only
+*/
+
+// Importing packages
+import (
+	// Package for math functions
+	"math"
+)
+
+// SQL condition on an event store row aliased e with a NaN or infinite reading (Postgres compares NaN equal to itself)
+const nonFiniteCondition = "COALESCE(e.temperature IN ('NaN', 'Infinity', '-Infinity') OR e.humidity IN ('NaN', 'Infinity', '-Infinity'), FALSE)"
+
+/*
+Function to check whether a value is neither NaN nor infinite
+@param value Value to check
+@return True for finite values
+*/
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+/*
+Function to check whether a measurement has a NaN or infinite temperature or humidity
+@return True, if one of the readings isn't finite
+*/
+func (measurement Measurement) hasNonFiniteReading() bool {
+	return !isFinite(float64(measurement.temperature)) || !isFinite(float64(measurement.humidity))
+}
+
+/*
+Function to check whether a measurement is dead-lettered by the non-finite policy
+@return True for a NaN or infinite reading with NON_FINITE_POLICY dead-letter
+*/
+func (measurement Measurement) deadLetteredAsNonFinite() bool {
+	return nonFinitePolicy == NonFiniteDeadLetter && measurement.hasNonFiniteReading()
+}
+
+/*
+Function to clamp a reading to a finite value, NaN becomes 0 and infinities the largest finite reading of their sign
+@param value Reading to clamp
+@return Finite reading
+*/
+func clampReading(value reading) reading {
+	switch {
+	case math.IsNaN(float64(value)):
+		return 0
+	case math.IsInf(float64(value), 1):
+		return maxReading
+	case math.IsInf(float64(value), -1):
+		return -maxReading
+	}
+	return value
+}
+
+/*
+Function to filter the finite values, so a single NaN or infinite input doesn't turn a whole statistic into NaN
+@param values Values to filter
+@return Finite values in the same order and the number of dropped non-finite values
+*/
+func finiteValues(values []float64) ([]float64, int) {
+	finite := make([]float64, 0, len(values))
+	for _, value := range values {
+		if isFinite(value) {
+			finite = append(finite, value)
+		}
+	}
+	return finite, len(values) - len(finite)
+}
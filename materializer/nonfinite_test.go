@@ -0,0 +1,114 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the handling of NaN and infinite readings and iteration durations
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package for math functions
+	"math"
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Test the detection of NaN and infinite readings and their clamping to finite ones
+@param t Test state
+*/
+func TestClampNonFiniteReadings(t *testing.T) {
+	nan, inf := reading(math.NaN()), reading(math.Inf(1))
+	cases := []struct {
+		name                  string
+		temperature, humidity reading
+		nonFinite             bool
+		// Clamped temperature and humidity
+		want [2]reading
+	}{
+		{"finite", 4.5, 45, false, [2]reading{4.5, 45}},
+		{"NaN temperature", nan, 45, true, [2]reading{0, 45}},
+		{"NaN humidity", 4.5, nan, true, [2]reading{4.5, 0}},
+		{"+Inf temperature", inf, 45, true, [2]reading{maxReading, 45}},
+		{"-Inf humidity", 4.5, -inf, true, [2]reading{4.5, -maxReading}},
+	}
+	for _, testCase := range cases {
+		measurement := Measurement{temperature: testCase.temperature, humidity: testCase.humidity}
+		if got := measurement.hasNonFiniteReading(); got != testCase.nonFinite {
+			t.Errorf("%s: hasNonFiniteReading %t, want %t", testCase.name, got, testCase.nonFinite)
+		}
+		if got := [2]reading{clampReading(testCase.temperature), clampReading(testCase.humidity)}; got != testCase.want {
+			t.Errorf("%s: clamped to %v, want %v", testCase.name, got, testCase.want)
+		}
+	}
+}
+
+/*
+Test that the non-finite values are dropped in order and counted
+@param t Test state
+*/
+func TestFiniteValues(t *testing.T) {
+	finite, dropped := finiteValues([]float64{1, math.NaN(), 2, math.Inf(1), 3, math.Inf(-1)})
+	if dropped != 3 || len(finite) != 3 || finite[0] != 1 || finite[1] != 2 || finite[2] != 3 {
+		t.Errorf("finite values %v with %d dropped, want [1 2 3] with 3 dropped", finite, dropped)
+	}
+	if finite, dropped = finiteValues(nil); dropped != 0 || len(finite) != 0 {
+		t.Errorf("no values: %v with %d dropped", finite, dropped)
+	}
+}
+
+/*
+Test that the benchmark statistics of durations with NaN and infinite samples stay finite and equal the ones of the finite samples
+@param t Test state
+*/
+func TestBenchmarkStatisticsIgnoreNonFiniteSamples(t *testing.T) {
+	finite := []float64{1, 2, 3, 4}
+	corrupted := []float64{1, math.NaN(), 2, math.Inf(1), 3, 4, math.Inf(-1)}
+
+	statistics := func(durations []float64) [5]float64 {
+		average, deviation := meanAndStandardDeviation(durations)
+		return [5]float64{mean(durations), median(durations), average, deviation, relativeStandardError(durations)}
+	}
+	want, got := statistics(finite), statistics(corrupted)
+	for i, name := range []string{"mean", "median", "mean of meanAndStandardDeviation", "standard deviation", "relative standard error"} {
+		if !isFinite(got[i]) || math.Abs(got[i]-want[i]) > 1e-12 {
+			t.Errorf("%s = %v, want %v", name, got[i], want[i])
+		}
+	}
+
+	// Only non-finite samples give the statistics of no samples instead of NaN
+	if got := mean([]float64{math.NaN(), math.Inf(1)}); got != 0 {
+		t.Errorf("mean of only non-finite samples = %v, want 0", got)
+	}
+}
+
+/*
+Test that the CSV output leaves the undefined heat index of a clamped infinite reading empty instead of writing NaN
+@param t Test state
+*/
+func TestCsvOutputLeavesUndefinedHeatIndexEmpty(t *testing.T) {
+	output := useCsvSourceAndOutput(t, csvSourceHeader+
+		"1,7,4,45,room-1,2024-01-05T10:00:00Z,2024-01-05T10:00:01Z\n"+
+		"2,7,+Inf,45,room-1,2024-01-05T10:01:00Z,2024-01-05T10:01:01Z\n")
+	saved := nonFinitePolicy
+	defer func() { nonFinitePolicy = saved }()
+	nonFinitePolicy = NonFiniteClamp
+
+	captureStdout(t, func() { materialize(context.Background(), nil, "test", nil) })
+	records := readCsvOutput(t, output)
+	if len(records) != 2 {
+		t.Fatalf("records %v, want both measurements", records)
+	}
+	heatIndexColumn := 9
+	if records[0][heatIndexColumn] == "" {
+		t.Error("heat index of the finite measurement is empty")
+	}
+	if got := records[1][heatIndexColumn]; got != "" {
+		t.Errorf("heat index of the clamped measurement %q, want an empty cell", got)
+	}
+	if got := formatNullableReading(reading(math.NaN())); got != "" {
+		t.Errorf("NaN formatted as %q, want an empty cell", got)
+	}
+}
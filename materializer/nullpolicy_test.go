@@ -0,0 +1,82 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the NULL_POLICY on measurements with NULL readings
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package for string manipulation
+	"strings"
+	// Package for automated tests
+	"testing"
+)
+
+// Source with a complete measurement and a measurement with a NULL temperature
+const nullReadingSource = csvSourceHeader +
+	"1,7,4,45,room-1,2024-01-05T10:00:00Z,2024-01-05T10:00:01Z\n" +
+	"2,7,,45,room-1,2024-01-05T10:01:00Z,2024-01-05T10:01:01Z\n"
+
+/*
+Function to materialize the source with NULL readings under a NULL policy
+@param t Test state
+@param policy NULL policy of the run
+@return Summary of the run and the written records without the header
+*/
+func materializeWithNullPolicy(t *testing.T, policy string) (*RunSummary, [][]string) {
+	t.Helper()
+	output := useCsvSourceAndOutput(t, nullReadingSource)
+	saved := nullPolicy
+	t.Cleanup(func() { nullPolicy = saved })
+	nullPolicy = policy
+
+	var summary *RunSummary
+	captureStdout(t, func() { summary = materialize(context.Background(), nil, "test", nil) })
+	return summary, readCsvOutput(t, output)
+}
+
+/*
+Test the default policy error, which aborts the run on the NULL reading
+@param t Test state
+*/
+func TestNullPolicyError(t *testing.T) {
+	message := expectFailure(t, func() { materializeWithNullPolicy(t, NullPolicyError) })
+	if !strings.Contains(message, "NULL reading (temperature null=true, humidity null=false) in measurement id=2") {
+		t.Errorf("failure %q doesn't name the NULL reading", message)
+	}
+}
+
+/*
+Test the policy zero, which writes the NULL reading as 0 and counts it as zeroed
+@param t Test state
+*/
+func TestNullPolicyZero(t *testing.T) {
+	summary, records := materializeWithNullPolicy(t, NullPolicyZero)
+	if summary.measurements != 2 || summary.nullZeroed != 1 || summary.nullSkipped != 0 {
+		t.Errorf("%d measurements, %d zeroed, %d skipped, want 2, 1, 0", summary.measurements, summary.nullZeroed, summary.nullSkipped)
+	}
+	// Columns id and temperature of the output
+	if len(records) != 2 || records[1][0] != "2" || records[1][8] != "0" {
+		t.Errorf("records %v, want measurement 2 written with temperature 0", records)
+	}
+}
+
+/*
+Test the policy skip, which drops the measurement and counts it as skipped
+@param t Test state
+*/
+func TestNullPolicySkip(t *testing.T) {
+	summary, records := materializeWithNullPolicy(t, NullPolicySkip)
+	if summary.measurements != 1 || summary.nullZeroed != 0 || summary.nullSkipped != 1 {
+		t.Errorf("%d measurements, %d zeroed, %d skipped, want 1, 0, 1", summary.measurements, summary.nullZeroed, summary.nullSkipped)
+	}
+	if len(records) != 1 || records[0][0] != "1" {
+		t.Errorf("records %v, want only measurement 1", records)
+	}
+	if summary.lastId != 2 {
+		t.Errorf("last id %d, want the skipped measurement 2", summary.lastId)
+	}
+}
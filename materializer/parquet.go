@@ -0,0 +1,154 @@
+package main
+
+/*
+@author 1Zero64
+Export of the transformed measurements as Parquet file for columnar downstream processing
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for measuring and displaying time values
+	"time"
+
+	// Package for writing Parquet files
+	"github.com/parquet-go/parquet-go"
+)
+
+// Number of rows buffered before they are passed to the Parquet writer
+const parquetBatchSize = 1000
+
+// Maximum number of rows of a row group, which bounds the memory the Parquet writer buffers
+const parquetRowGroupSize = 100000
+
+// Object structure for a row of the Parquet file with the column types
+type ParquetMeasurement struct {
+	// Id of the measurement (INT64)
+	Id int64 `parquet:"id"`
+	// Timestamp of the creation as TIMESTAMP(MICROS) like the microsecond precision of Postgres
+	CreatedOn time.Time `parquet:"created_on,timestamp(microsecond)"`
+	// Danger level as ENUM with dictionary encoding for the five distinct values
+	Danger string `parquet:"danger,enum,dict"`
+	// Name of the streaming technology as dictionary encoded string
+	EventStream string `parquet:"event_stream,dict"`
+	// Measured humidity (FLOAT or DOUBLE depending on the reading precision)
+	Humidity reading `parquet:"humidity"`
+	// Latency in the LATENCY_UNIT (FLOAT)
+	Latency float32 `parquet:"latency"`
+	// Exact latency in microseconds (INT64)
+	LatencyUs int64 `parquet:"latency_us"`
+	// Timestamp of the processing as TIMESTAMP(MICROS)
+	ProcessedOn time.Time `parquet:"processed_on,timestamp(microsecond)"`
+	// Id of the sensor (INT64)
+	SensorId int64 `parquet:"sensor_id"`
+	// Measured temperature (FLOAT or DOUBLE depending on the reading precision)
+	Temperature reading `parquet:"temperature"`
+	// Heat index, NULL if not computable
+	HeatIndex *reading `parquet:"heat_index,optional"`
+}
+
+/*
+Function to stream the measurements of the event store, transform them and write them into a Parquet file with bounded memory
+@param db *sql.DB Database connection to Postgres database
+@param path Path of the Parquet file
+*/
+func exportParquetFile(db *sql.DB, path string) {
+
+	// Create export file and check on error with handler
+	file, err := os.Create(path)
+	checkError(err)
+
+	// Close file later, when surrounding function returns
+	defer file.Close()
+
+	// Initialize Parquet writer, which writes a row group every parquetRowGroupSize rows
+	writer := parquet.NewGenericWriter[ParquetMeasurement](file, parquet.MaxRowsPerRowGroup(parquetRowGroupSize))
+
+	// Stream the measurements from the dedicated read connection, only the ones after the last exported id of a resumed export
+	condition, args := "", []interface{}{}
+	if resumeFromId > 0 {
+		condition, args = resumeCondition(condition, args)
+		fmt.Printf("Resuming the export after id %d into %s, Parquet files can't be appended, so it holds only the remaining rows\n", resumeFromId, path)
+	}
+	reader := startPipelineReader(context.Background(), db, condition, args...)
+	bar := newProgress(-1)
+
+	// Transform and write the measurements in batches
+	batch := make([]ParquetMeasurement, 0, parquetBatchSize)
+	var exported int
+
+	// Complete the file with the written rows and print the last exported id, if the export fails
+	lastId := resumeFromId
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if err := writer.Close(); err != nil {
+				fmt.Printf("Export to %s failed and its footer couldn't be written (%v), rerun it with -resume-from %d\n", path, err, resumeFromId)
+			} else {
+				printResumeHint(path, lastId, recovered)
+				fmt.Println("Write the remaining rows into a new file, the failed one holds the rows up to this id")
+			}
+			panic(recovered)
+		}
+	}()
+	for {
+		measurement, ok := reader.next()
+		// Drop measurements with a NULL reading or a dead-lettered NaN or infinite reading, the export doesn't write the dead letter table
+		if ok && (measurement.skippedByNullPolicy() || measurement.deadLetteredAsNonFinite()) {
+			continue
+		}
+		if ok {
+			transformedMeasurement := transformMeasurement(measurement)
+			batch = append(batch, ParquetMeasurement{
+				Id:          transformedMeasurement.id,
+				CreatedOn:   transformedMeasurement.created_on,
+				Danger:      transformedMeasurement.danger,
+				EventStream: transformedMeasurement.event_stream,
+				Humidity:    transformedMeasurement.humidity,
+				Latency:     transformedMeasurement.latency,
+				LatencyUs:   transformedMeasurement.latency_us,
+				ProcessedOn: transformedMeasurement.processed_on,
+				SensorId:    transformedMeasurement.sensor_id,
+				Temperature: transformedMeasurement.temperature,
+				HeatIndex:   nullableReading(transformedMeasurement.heat_index),
+			})
+			bar.Add(1)
+		}
+
+		// Write a full batch or the remaining rows after the last measurement
+		if len(batch) == parquetBatchSize || (!ok && len(batch) > 0) {
+			_, err = writer.Write(batch)
+			checkError(err)
+			exported += len(batch)
+			lastId = batch[len(batch)-1].Id
+			batch = batch[:0]
+		}
+		if !ok {
+			break
+		}
+	}
+	bar.Finish()
+
+	// Write the last row group and the footer
+	checkError(writer.Close())
+
+	fmt.Printf("Exported %d transformed measurements to %s\n", exported, path)
+}
+
+/*
+Function to convert a reading into an optional Parquet value, mapping NaN and infinite values to NULL
+@param value Reading to convert
+@return Pointer to the reading or nil for NaN and infinite values
+*/
+func nullableReading(value reading) *reading {
+	if nullableFloat(value) == nil {
+		return nil
+	}
+	return &value
+}
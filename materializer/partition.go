@@ -0,0 +1,142 @@
+package main
+
+/*
+@author 1Zero64
+Management of the monthly partitions of a partitioned materialized view
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for synchronization of goroutines
+	"sync"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Names of the partitions known to exist, to avoid a CREATE statement per insert
+var knownPartitions = make(map[string]bool)
+
+// Mutex guarding the known partitions against concurrent writers
+var partitionsMutex sync.Mutex
+
+/*
+Function to get the first instant of the month of a time point
+@param t Time point
+@return Start of the month
+*/
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+/*
+Function to get the name of the partition holding a time point
+@param t Time point
+@return Name of the monthly partition (e.g. materialized_view_y2023m01)
+*/
+func partitionName(t time.Time) string {
+	return fmt.Sprintf("materialized_view_y%04dm%02d", t.Year(), int(t.Month()))
+}
+
+/*
+Function to create the monthly partition for a created_on time point, if it doesn't exist yet
+@param createdOn Creation time point of the measurement to insert
+@param db *sql.DB Database connection to Postgres database
+*/
+func ensurePartition(createdOn time.Time, db *sql.DB) {
+
+	// Lock the known partitions, when surrounding function returns it is unlocked
+	partitionsMutex.Lock()
+	defer partitionsMutex.Unlock()
+
+	// Skip partitions already created or checked
+	name := partitionName(createdOn)
+	if knownPartitions[name] {
+		return
+	}
+
+	// Create partition with the range from the start of the month (inclusive) to the start of the next month (exclusive)
+	start := monthStart(createdOn)
+	end := start.AddDate(0, 1, 0)
+	_, err := db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF materialized_view FOR VALUES FROM ('%s') TO ('%s')",
+		name, start.Format("2006-01-02"), end.Format("2006-01-02")))
+	// Check on error with handler
+	checkError(err)
+
+	// Remember the partition
+	knownPartitions[name] = true
+}
+
+/*
+Function to read the names of all partitions of the materialized view
+@param db *sql.DB Database connection to Postgres database
+@return Names of the partitions
+*/
+func readPartitions(db *sql.DB) []string {
+
+	// Select child tables of the materialized view
+	rows, err := db.Query(`SELECT child.relname FROM pg_inherits
+		JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+		WHERE pg_inherits.inhparent = 'materialized_view'::regclass
+		ORDER BY child.relname`)
+	// Check on error with handler
+	checkError(err)
+
+	// Close rows object later, when surrounding function returns
+	defer rows.Close()
+
+	// Collect partition names
+	partitions := make([]string, 0)
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		checkError(err)
+		partitions = append(partitions, name)
+	}
+	checkError(rows.Err())
+
+	// Return partition names
+	return partitions
+}
+
+/*
+Function to drop all partitions, that only hold rows created before a cutoff
+@param cutoff Time point before which rows are purged
+@param db *sql.DB Database connection to Postgres database
+@return Number of rows in the dropped partitions
+*/
+func dropPartitionsBefore(cutoff time.Time, db *sql.DB) int64 {
+
+	// Initialize counter for the rows of dropped partitions
+	var removed int64
+
+	// Iterate through all partitions and drop those ending before the cutoff
+	for _, name := range readPartitions(db) {
+		// Parse month of the partition from its name and skip foreign partitions
+		var year, month int
+		if _, err := fmt.Sscanf(name, "materialized_view_y%04dm%02d", &year, &month); err != nil {
+			continue
+		}
+		end := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, cutoff.Location()).AddDate(0, 1, 0)
+		if end.After(cutoff) {
+			continue
+		}
+
+		// Count rows of the partition
+		var count int64
+		err := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", name)).Scan(&count)
+		checkError(err)
+		removed += count
+
+		// Drop partition
+		_, err = db.Exec(fmt.Sprintf("DROP TABLE %s", name))
+		checkError(err)
+		delete(knownPartitions, name)
+	}
+
+	// Return number of removed rows
+	return removed
+}
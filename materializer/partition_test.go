@@ -0,0 +1,95 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the monthly partitions of a partitioned materialized view
+*/
+
+// Importing packages
+import (
+	// Package for automated tests
+	"testing"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Time zones east and west of UTC, whose created_on values lie in another month than in UTC
+var (
+	eastOfUtc = time.FixedZone("UTC+2", 2*60*60)
+	westOfUtc = time.FixedZone("UTC-5", -5*60*60)
+)
+
+// Creation time points at the month boundaries with the partition and the month start, that hold them
+var partitionCases = []struct {
+	createdOn time.Time
+	partition string
+	start     time.Time
+}{
+	// Last instant of a month and first instant of the next one
+	{time.Date(2024, 1, 31, 23, 59, 59, 999000000, time.UTC), "materialized_view_y2024m01", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	{time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), "materialized_view_y2024m02", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+	// Turn of the year from December into January
+	{time.Date(2023, 12, 31, 23, 59, 59, 999000000, time.UTC), "materialized_view_y2023m12", time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)},
+	{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "materialized_view_y2024m01", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	// Non-UTC values are partitioned by their wall clock like the TIMESTAMP column stores them, although in UTC they are
+	// still in February or already in April
+	{time.Date(2024, 3, 1, 0, 30, 0, 0, eastOfUtc), "materialized_view_y2024m03", time.Date(2024, 3, 1, 0, 0, 0, 0, eastOfUtc)},
+	{time.Date(2024, 3, 31, 22, 0, 0, 0, westOfUtc), "materialized_view_y2024m03", time.Date(2024, 3, 1, 0, 0, 0, 0, westOfUtc)},
+}
+
+/*
+Test the month start and the partition name of creation time points at month and year boundaries
+@param t Test state
+*/
+func TestMonthStartAndPartitionName(t *testing.T) {
+	for _, testCase := range partitionCases {
+		if got := partitionName(testCase.createdOn); got != testCase.partition {
+			t.Errorf("partitionName(%s) = %s, want %s", testCase.createdOn, got, testCase.partition)
+		}
+		if got := monthStart(testCase.createdOn); !got.Equal(testCase.start) || got.Location() != testCase.createdOn.Location() {
+			t.Errorf("monthStart(%s) = %s, want %s", testCase.createdOn, got, testCase.start)
+		}
+	}
+}
+
+/*
+Test that measurements at month and year boundaries and with non-UTC creation time points are written into the partition
+named for them, whose range ends at the start of the next month
+@param t Test state
+*/
+func TestEnsurePartitionHoldsBoundaryMeasurements(t *testing.T) {
+	db := openTestDatabase(t)
+	useDatabaseRun(t)
+	savedPartitioned, savedKnown := partitionedView, knownPartitions
+	t.Cleanup(func() { partitionedView, knownPartitions = savedPartitioned, savedKnown })
+	partitionedView, knownPartitions = true, make(map[string]bool)
+	if _, err := db.Exec("DROP TABLE IF EXISTS materialized_view"); err != nil {
+		t.Fatal(err)
+	}
+	captureStdout(t, func() { createSchema(db) })
+
+	for i, testCase := range partitionCases {
+		measurement := TransformedMeasurement{Measurement: Measurement{id: int64(i + 1), created_on: testCase.createdOn, processed_on: testCase.createdOn}, danger: No}
+		if err := writeTransformedMeasurement(measurement, "materialized_view", db); err != nil {
+			t.Fatalf("write of %s: %v", testCase.createdOn, err)
+		}
+	}
+	for i, testCase := range partitionCases {
+		var partition string
+		if err := db.QueryRow("SELECT tableoid::regclass::text FROM materialized_view WHERE id = $1", i+1).Scan(&partition); err != nil {
+			t.Fatal(err)
+		}
+		if partition != testCase.partition {
+			t.Errorf("%s written into %s, want %s", testCase.createdOn, partition, testCase.partition)
+		}
+	}
+
+	// The December partition ends at the start of January of the next year
+	var bound string
+	if err := db.QueryRow("SELECT pg_get_expr(relpartbound, oid) FROM pg_class WHERE relname = 'materialized_view_y2023m12'").Scan(&bound); err != nil {
+		t.Fatal(err)
+	}
+	if want := "FOR VALUES FROM ('2023-12-01 00:00:00') TO ('2024-01-01 00:00:00')"; bound != want {
+		t.Errorf("bound of the December partition %s, want %s", bound, want)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+/*
+@author 1Zero64
+Quick look at the most recent rows of the materialized view
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for converting strings to numbers
+	"strconv"
+	// Package for string manipulation
+	"strings"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Maximum width of the event stream column, longer names are truncated
+const maxEventStreamWidth = 20
+
+// ANSI color codes to highlight danger levels on the console
+var dangerColors = map[string]string{
+	No:       "\033[32m",
+	Low:      "\033[36m",
+	Medium:   "\033[33m",
+	High:     "\033[35m",
+	Critical: "\033[1;31m",
+}
+
+/*
+Function to print the most recent rows of the materialized view as a formatted table, the most severe first with -sort-severity
+@param db *sql.DB Database connection to Postgres database
+@param limit Number of rows to print
+@param filter Sensor id (if numeric) or event stream to filter by (empty for no filter)
+*/
+func peek(db *sql.DB, limit int, filter string) {
+
+	// Build query with the optional filter on sensor id or event stream
+	query := "SELECT id, created_on, processed_on, event_stream, sensor_id, temperature, humidity, " + latencyExpression(viewLatencyUnit(db)) + ", heat_index, danger FROM materialized_view"
+	args := make([]interface{}, 0)
+	if filter != "" {
+		if sensorId, err := strconv.ParseInt(filter, 10, 64); err == nil {
+			query += " WHERE sensor_id = $1"
+			args = append(args, sensorId)
+		} else {
+			query += " WHERE event_stream = $1"
+			args = append(args, filter)
+		}
+	}
+	// Take the most severe rows first, if ordered by severity, the most recent within a level
+	order := "created_on DESC"
+	if sortSeverity {
+		order = severityRankExpression("danger") + " DESC, created_on DESC"
+	}
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %d", order, limit)
+
+	// Execute query and check on error with handler
+	rows, err := db.Query(query, args...)
+	checkError(err)
+
+	// Close rows object later, when surrounding function returns
+	defer rows.Close()
+
+	// Format all rows into table cells
+	header := []string{"id", "created_on", "processed_on", "event_stream", "sensor_id", "temperature", "humidity", "latency", "heat_index", "danger"}
+	table := [][]string{header}
+	for rows.Next() {
+		var id, sensorId int64
+		var createdOn, processedOn time.Time
+		var eventStream, danger string
+		var temperature, humidity, latency float32
+		var heatIndex sql.NullFloat64
+		err = rows.Scan(&id, &createdOn, &processedOn, &eventStream, &sensorId, &temperature, &humidity, &latency, &heatIndex, &danger)
+		checkError(err)
+
+		// Truncate long event stream names instead of wrapping the table
+		if len(eventStream) > maxEventStreamWidth {
+			eventStream = eventStream[:maxEventStreamWidth-3] + "..."
+		}
+
+		// Format heat index, which is NULL for not computable values
+		heatIndexCell := "-"
+		if heatIndex.Valid {
+			heatIndexCell = fmt.Sprintf("%.2f", heatIndex.Float64)
+		}
+
+		// Add formatted row with timestamps in local time
+		table = append(table, []string{
+			strconv.FormatInt(id, 10),
+			createdOn.Local().Format("2006-01-02 15:04:05.000"),
+			processedOn.Local().Format("2006-01-02 15:04:05.000"),
+			eventStream,
+			strconv.FormatInt(sensorId, 10),
+			fmt.Sprintf("%.2f", temperature),
+			fmt.Sprintf("%.2f", humidity),
+			fmt.Sprintf("%.3f", latency),
+			heatIndexCell,
+			danger,
+		})
+	}
+	checkError(rows.Err())
+
+	// Print message for an empty result
+	if len(table) == 1 {
+		fmt.Println("No rows found")
+		return
+	}
+
+	// Calculate column widths adapted to the data
+	widths := make([]int, len(header))
+	for _, row := range table {
+		for column, cell := range row {
+			if len(cell) > widths[column] {
+				widths[column] = len(cell)
+			}
+		}
+	}
+
+	// Print rows with padded cells and highlighted danger levels
+	fmt.Println()
+	for _, row := range table {
+		cells := make([]string, len(row))
+		for column, cell := range row {
+			cells[column] = fmt.Sprintf("%-*s", widths[column], cell)
+			if color, found := dangerColors[cell]; found && column == len(row)-1 {
+				cells[column] = color + cells[column] + "\033[0m"
+			}
+		}
+		fmt.Println(strings.Join(cells, "  "))
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+/*
+@author 1Zero64
+Snapshots of the pg_stat_database and pg_statio_user_tables counters before and after a run, to tell IO-bound from lock-bound runs
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+)
+
+// Tables, whose block IO counters are snapshotted
+var pgStatsTables = []string{"event_store", "materialized_view"}
+
+// Object structure for the counters of the target database
+type PgDatabaseStats struct {
+	// Blocks read from disk (or the OS cache)
+	BlocksRead int64 `json:"blks_read"`
+	// Blocks found in the shared buffers
+	BlocksHit int64 `json:"blks_hit"`
+	// Time spent reading blocks in milliseconds (omitted, if track_io_timing is off)
+	BlockReadTime *float64 `json:"blk_read_time,omitempty"`
+	// Time spent writing blocks in milliseconds (omitted, if track_io_timing is off)
+	BlockWriteTime *float64 `json:"blk_write_time,omitempty"`
+	// Inserted rows
+	TuplesInserted int64 `json:"tup_inserted"`
+	// Detected deadlocks
+	Deadlocks int64 `json:"deadlocks"`
+}
+
+// Object structure for the block IO counters of a table
+type PgTableStats struct {
+	// Name of the table
+	Table string `json:"table"`
+	// Heap blocks read from disk
+	HeapBlocksRead int64 `json:"heap_blks_read"`
+	// Heap blocks found in the shared buffers
+	HeapBlocksHit int64 `json:"heap_blks_hit"`
+	// Index blocks read from disk
+	IndexBlocksRead int64 `json:"idx_blks_read"`
+	// Index blocks found in the shared buffers
+	IndexBlocksHit int64 `json:"idx_blks_hit"`
+}
+
+// Object structure for a snapshot of the counters or the difference of two snapshots
+type PgStats struct {
+	// Counters of the target database (omitted, if not readable)
+	Database *PgDatabaseStats `json:"database,omitempty"`
+	// Counters of the event store and the view (omitted, if not readable)
+	Tables []PgTableStats `json:"tables,omitempty"`
+	// Notes on omitted or reset counters
+	Notes []string `json:"notes,omitempty"`
+}
+
+/*
+Function to add a note to the counters, unless it's already noted
+@param note Note to add
+*/
+func (stats *PgStats) note(note string) {
+	for _, existing := range stats.Notes {
+		if existing == note {
+			return
+		}
+	}
+	stats.Notes = append(stats.Notes, note)
+}
+
+/*
+Function to read the current counters of the target database and the event store and view. Counters, that can't be read
+(e.g. by missing permissions), are omitted with a note instead of failing the run. The IO times are omitted, if
+track_io_timing is off. Backends report their counters with a short delay, so the latest changes can be missing
+@param db *sql.DB Database connection to Postgres database
+@return Snapshot of the counters
+*/
+func snapshotPgStats(db *sql.DB) PgStats {
+	var stats PgStats
+
+	// Read the counters of the target database including the IO times
+	var database PgDatabaseStats
+	var readTime, writeTime float64
+	err := db.QueryRow("SELECT blks_read, blks_hit, blk_read_time, blk_write_time, tup_inserted, deadlocks FROM pg_stat_database WHERE datname = current_database()").
+		Scan(&database.BlocksRead, &database.BlocksHit, &readTime, &writeTime, &database.TuplesInserted, &database.Deadlocks)
+	if err != nil {
+		stats.note(fmt.Sprintf("pg_stat_database not readable: %v", err))
+	} else {
+		// Keep the IO times only, if they are tracked, otherwise they are always 0
+		var ioTiming string
+		if err := db.QueryRow("SELECT current_setting('track_io_timing')").Scan(&ioTiming); err == nil && ioTiming == "on" {
+			database.BlockReadTime, database.BlockWriteTime = &readTime, &writeTime
+		} else {
+			stats.note("track_io_timing is off, blk_read_time and blk_write_time omitted")
+		}
+		stats.Database = &database
+	}
+
+	// Read the block IO counters of the event store and the view
+	rows, err := db.Query("SELECT relname, COALESCE(heap_blks_read, 0), COALESCE(heap_blks_hit, 0), COALESCE(idx_blks_read, 0), COALESCE(idx_blks_hit, 0) FROM pg_statio_user_tables WHERE relname IN ($1, $2) ORDER BY relname", pgStatsTables[0], pgStatsTables[1])
+	if err != nil {
+		stats.note(fmt.Sprintf("pg_statio_user_tables not readable: %v", err))
+		return stats
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table PgTableStats
+		checkError(rows.Scan(&table.Table, &table.HeapBlocksRead, &table.HeapBlocksHit, &table.IndexBlocksRead, &table.IndexBlocksHit))
+		stats.Tables = append(stats.Tables, table)
+	}
+	checkError(rows.Err())
+	return stats
+}
+
+/*
+Function to calculate the difference of a counter. A counter lower than before was reset, e.g. by a table swapped into place,
+so the difference is counted from 0
+@param before Counter before the run
+@param after Counter after the run
+@param reset Flag set, if the counter was reset
+@return Difference of the counter
+*/
+func counterDelta(before int64, after int64, reset *bool) int64 {
+	if after < before {
+		*reset = true
+		return after
+	}
+	return after - before
+}
+
+/*
+Function to calculate the difference of the counters of two snapshots
+@param before Snapshot before the run
+@param after Snapshot after the run
+@return Difference of the counters readable in both snapshots
+*/
+func pgStatsDelta(before PgStats, after PgStats) PgStats {
+	var delta PgStats
+	for _, notes := range [][]string{before.Notes, after.Notes} {
+		for _, note := range notes {
+			delta.note(note)
+		}
+	}
+
+	// Calculate the difference of the database counters and the tracked IO times
+	if before.Database != nil && after.Database != nil {
+		var reset bool
+		database := PgDatabaseStats{
+			BlocksRead:     counterDelta(before.Database.BlocksRead, after.Database.BlocksRead, &reset),
+			BlocksHit:      counterDelta(before.Database.BlocksHit, after.Database.BlocksHit, &reset),
+			TuplesInserted: counterDelta(before.Database.TuplesInserted, after.Database.TuplesInserted, &reset),
+			Deadlocks:      counterDelta(before.Database.Deadlocks, after.Database.Deadlocks, &reset),
+		}
+		if before.Database.BlockReadTime != nil && after.Database.BlockReadTime != nil {
+			readTime := *after.Database.BlockReadTime - *before.Database.BlockReadTime
+			writeTime := *after.Database.BlockWriteTime - *before.Database.BlockWriteTime
+			database.BlockReadTime, database.BlockWriteTime = &readTime, &writeTime
+		}
+		if reset {
+			delta.note("database statistics were reset during the run, counted from the reset")
+		}
+		delta.Database = &database
+	}
+
+	// Calculate the difference of the table counters by name
+	for _, table := range after.Tables {
+		previous := PgTableStats{Table: table.Table}
+		for _, candidate := range before.Tables {
+			if candidate.Table == table.Table {
+				previous = candidate
+			}
+		}
+		var reset bool
+		delta.Tables = append(delta.Tables, PgTableStats{
+			Table:           table.Table,
+			HeapBlocksRead:  counterDelta(previous.HeapBlocksRead, table.HeapBlocksRead, &reset),
+			HeapBlocksHit:   counterDelta(previous.HeapBlocksHit, table.HeapBlocksHit, &reset),
+			IndexBlocksRead: counterDelta(previous.IndexBlocksRead, table.IndexBlocksRead, &reset),
+			IndexBlocksHit:  counterDelta(previous.IndexBlocksHit, table.IndexBlocksHit, &reset),
+		})
+		if reset {
+			delta.note(fmt.Sprintf("counters of %s were reset during the run (e.g. by the staging table swap), counted from the reset", table.Table))
+		}
+	}
+	return delta
+}
+
+/*
+Function to calculate the share of blocks found in the shared buffers
+@param hit Blocks found in the shared buffers
+@param read Blocks read from disk
+@return Hit ratio in percent (0 without any block access)
+*/
+func hitRatio(hit int64, read int64) float64 {
+	if hit+read == 0 {
+		return 0
+	}
+	return float64(hit) / float64(hit+read) * 100
+}
+
+/*
+Function to print the difference of the counters of a run
+*/
+func (stats PgStats) print() {
+
+	// Print the database counters and IO times
+	if stats.Database != nil {
+		database := stats.Database
+		localePrintf("pg_stat_database: %d blocks read, %d hit (%.2f%% hit ratio), %d tuples inserted, %d deadlocks\n", database.BlocksRead, database.BlocksHit, hitRatio(database.BlocksHit, database.BlocksRead), database.TuplesInserted, database.Deadlocks)
+		if database.BlockReadTime != nil {
+			localePrintf("pg_stat_database IO time: %.3f ms reading, %.3f ms writing blocks\n", *database.BlockReadTime, *database.BlockWriteTime)
+		}
+	}
+
+	// Print the block IO per table
+	for _, table := range stats.Tables {
+		localePrintf("pg_statio %s: heap %d read, %d hit; index %d read, %d hit\n", table.Table, table.HeapBlocksRead, table.HeapBlocksHit, table.IndexBlocksRead, table.IndexBlocksHit)
+	}
+
+	// Print why counters are missing
+	for _, note := range stats.Notes {
+		localePrintf("pg_stat note: %s\n", note)
+	}
+}
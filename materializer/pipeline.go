@@ -0,0 +1,149 @@
+package main
+
+/*
+@author 1Zero64
+Pipeline mode streaming the measurements from a dedicated read connection to the writes while they are read
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for pseudo-random numbers
+	"math/rand"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Connection pool of the pipeline reader, separate from the write pool (nil, if the pipeline mode is disabled)
+var readerDb *sql.DB
+
+// Object structure for the reader of the pipeline mode
+type PipelineReader struct {
+	// Buffered channel of the read measurements in id order, closed after the last one
+	measurements chan Measurement
+	// Function to stop the reader early
+	cancel context.CancelFunc
+	// Time the reader waited for the writer, because the buffer was full
+	readerIdle time.Duration
+	// Time the writer waited for the reader, because the buffer was empty
+	writerIdle time.Duration
+	// Failure of the reader, raised again by the writer after the last measurement
+	failure GoroutineFailure
+}
+
+/*
+Function to start streaming the measurements of the event store over a dedicated connection of the reader pool
+@param ctx Context of the run, the reader stops when it's done
+@param db *sql.DB Database connection to the primary Postgres database
+@param condition Optional condition of the WHERE clause to filter measurements (empty to read all)
+@param args Arguments for the placeholders of the condition
+@return Pointer to the started pipeline reader
+*/
+func startPipelineReader(ctx context.Context, db *sql.DB, condition string, args ...interface{}) *PipelineReader {
+
+	// Wait for a lagging read replica before reading
+	checkReplicaLag(db)
+
+	// Initialize reader with a cancelable context
+	readerCtx, cancel := context.WithCancel(ctx)
+	reader := &PipelineReader{
+		measurements: make(chan Measurement, pipelineBuffer),
+		cancel:       cancel,
+	}
+
+	// Reserve a dedicated connection for the long-running cursor, so it never waits behind a write
+	conn, err := readerDb.Conn(readerCtx)
+	// Check on error with handler
+	checkError(err)
+
+	// Execute select query on event store over the dedicated connection
+	query, args := measurementsQuery(condition, args...)
+	rows, err := conn.QueryContext(readerCtx, query, args...)
+	checkError(err)
+
+	// Stream the rows into the buffer in the background
+	go func() {
+		// Close channel, rows and connection, when the reader is finished
+		defer close(reader.measurements)
+		defer conn.Close()
+		defer rows.Close()
+
+		// Capture a failed read before closing the channel, so the writer raises it
+		defer reader.failure.capture()
+
+		// Initialize seeded random number generator for reproducible sampling
+		random := rand.New(rand.NewSource(sampleSeed))
+
+		for rows.Next() {
+			// Skip records probabilistically, if only a sample is materialized
+			if sampleRate < 1 && random.Float64() >= sampleRate {
+				continue
+			}
+			measurement := scanMeasurement(rows)
+
+			// Pass measurement to the writer and account the time waiting for a free slot in the buffer
+			select {
+			case reader.measurements <- measurement:
+				continue
+			default:
+			}
+			wait := time.Now()
+			select {
+			case reader.measurements <- measurement:
+				reader.readerIdle += time.Since(wait)
+			case <-readerCtx.Done():
+				reader.readerIdle += time.Since(wait)
+				return
+			}
+		}
+
+		// Check on a read error, unless the reader was stopped
+		if readerCtx.Err() == nil {
+			checkError(rows.Err())
+		}
+	}()
+
+	// Return started reader
+	return reader
+}
+
+/*
+Function to take the next measurement of the pipeline reader and account the time waiting for it.
+A failure of the reader is raised after the last measurement it read
+@return Next measurement and false, if all measurements were read
+*/
+func (reader *PipelineReader) next() (Measurement, bool) {
+
+	// Take a buffered measurement without waiting, if available
+	select {
+	case measurement, ok := <-reader.measurements:
+		if !ok {
+			reader.failure.raise()
+		}
+		return measurement, ok
+	default:
+	}
+
+	// Wait for the reader otherwise
+	wait := time.Now()
+	measurement, ok := <-reader.measurements
+	reader.writerIdle += time.Since(wait)
+	if !ok {
+		reader.failure.raise()
+	}
+	return measurement, ok
+}
+
+/*
+Function to stop the pipeline reader and wait until its connection is released
+*/
+func (reader *PipelineReader) stop() {
+
+	// Cancel the reader and drain the buffer until the reader closed it
+	reader.cancel()
+	for range reader.measurements {
+	}
+}
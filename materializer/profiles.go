@@ -0,0 +1,106 @@
+package main
+
+/*
+@author 1Zero64
+Threshold profiles per event stream for storage rooms with different safe ranges
+*/
+
+// Importing packages
+import (
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for string manipulation
+	"strings"
+)
+
+// Name of the profile of event streams without a mapping, which uses the active thresholds
+const defaultProfile = "default"
+
+// Thresholds of the named profiles
+var thresholdProfiles = make(map[string]Thresholds)
+
+// Profile names of the mapped event streams
+var streamProfiles = make(map[string]string)
+
+// Thresholds of the mapped event streams, resolved once at the start of a run
+var streamThresholds = make(map[string]Thresholds)
+
+/*
+Function to read the threshold profiles from THRESHOLD_PROFILES with their THRESHOLDS_TEMPERATURE_<PROFILE> and
+THRESHOLDS_HUMIDITY_<PROFILE> variables (falling back to the configured thresholds) and the STREAM_PROFILES mapping
+*/
+func loadThresholdProfiles() {
+
+	// Read the thresholds of every named profile
+	for _, name := range strings.Split(os.Getenv("THRESHOLD_PROFILES"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if name == defaultProfile {
+			checkError(fmt.Errorf("invalid THRESHOLD_PROFILES: %q is reserved for streams without a profile", defaultProfile))
+		}
+		suffix := "_" + strings.ToUpper(name)
+		thresholdProfiles[name] = Thresholds{
+			temperature: getThresholdsEnv("THRESHOLDS_TEMPERATURE"+suffix, configuredThresholds.temperature),
+			humidity:    getThresholdsEnv("THRESHOLDS_HUMIDITY"+suffix, configuredThresholds.humidity),
+		}
+	}
+
+	// Read the mapping of event streams to profiles and check the profiles exist
+	for _, mapping := range strings.Split(os.Getenv("STREAM_PROFILES"), ",") {
+		if strings.TrimSpace(mapping) == "" {
+			continue
+		}
+		stream, profile, found := strings.Cut(mapping, "=")
+		stream, profile = strings.TrimSpace(stream), strings.TrimSpace(profile)
+		if !found || stream == "" {
+			checkError(fmt.Errorf("invalid STREAM_PROFILES entry %q, expected <event_stream>=<profile>", mapping))
+		}
+		if _, exists := thresholdProfiles[profile]; !exists && profile != defaultProfile {
+			checkError(fmt.Errorf("invalid STREAM_PROFILES entry %q: profile %q is not defined in THRESHOLD_PROFILES", mapping, profile))
+		}
+		streamProfiles[stream] = profile
+	}
+
+	// Resolve the thresholds of the mapped streams
+	resolveStreamThresholds()
+}
+
+/*
+Function to resolve the thresholds of all mapped event streams into a map, so the transformation doesn't look up profiles per row
+*/
+func resolveStreamThresholds() {
+	streamThresholds = make(map[string]Thresholds, len(streamProfiles))
+	for stream, profile := range streamProfiles {
+		if profile != defaultProfile {
+			streamThresholds[stream] = thresholdProfiles[profile]
+		}
+	}
+}
+
+/*
+Function to get the thresholds of an event stream
+@param stream Name of the event stream
+@return Thresholds of the profile of the stream or the active thresholds for streams of the default profile
+*/
+func thresholdsFor(stream string) Thresholds {
+	if streamThreshold, found := streamThresholds[stream]; found {
+		return streamThreshold
+	}
+	return thresholds
+}
+
+/*
+Function to get the profile name of an event stream
+@param stream Name of the event stream
+@return Name of the profile or default
+*/
+func profileFor(stream string) string {
+	if profile, found := streamProfiles[stream]; found {
+		return profile
+	}
+	return defaultProfile
+}
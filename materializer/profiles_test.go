@@ -0,0 +1,67 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the threshold profiles per event stream
+*/
+
+// Importing packages
+import (
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Test that the same reading is classified by the profile of its event stream, and by the active thresholds for a stream
+without a profile
+@param t Test state
+*/
+func TestThresholdProfilesPerStream(t *testing.T) {
+	savedProfiles, savedStreams, savedConfigured, savedThresholds := thresholdProfiles, streamProfiles, configuredThresholds, thresholds
+	savedTolerance, savedScoring := tolerance, scoringMode
+	defer func() {
+		thresholdProfiles, streamProfiles, configuredThresholds, thresholds = savedProfiles, savedStreams, savedConfigured, savedThresholds
+		tolerance, scoringMode = savedTolerance, savedScoring
+		resolveStreamThresholds()
+	}()
+	thresholdProfiles, streamProfiles = make(map[string]Thresholds), make(map[string]string)
+	configuredThresholds, thresholds, tolerance, scoringMode = defaultThresholds, defaultThresholds, 1e-4, ScoringThreshold
+
+	// A freezer, whose readings are critical above -15°C, and a cooler room with the default humidities
+	t.Setenv("THRESHOLD_PROFILES", "freezer, cooler")
+	t.Setenv("THRESHOLDS_TEMPERATURE_FREEZER", "-22,-20,-18,-15")
+	t.Setenv("THRESHOLDS_HUMIDITY_FREEZER", "")
+	t.Setenv("THRESHOLDS_TEMPERATURE_COOLER", "8,10,12,14")
+	t.Setenv("THRESHOLDS_HUMIDITY_COOLER", "")
+	t.Setenv("STREAM_PROFILES", "freezer-1=freezer, cooler-1=cooler, room-1=default")
+	loadThresholdProfiles()
+
+	cases := []struct {
+		stream  string
+		profile string
+		want    string
+	}{
+		{"freezer-1", "freezer", Critical},
+		{"cooler-1", "cooler", No},
+		{"room-1", defaultProfile, Medium},
+		// Streams without a mapping fall back to the default profile
+		{"unmapped", defaultProfile, Medium},
+	}
+	for _, testCase := range cases {
+		if got := profileFor(testCase.stream); got != testCase.profile {
+			t.Errorf("%s: profile %s, want %s", testCase.stream, got, testCase.profile)
+		}
+		if got := classify(thresholdsFor(testCase.stream), 5.5, 10); got != testCase.want {
+			t.Errorf("5.5°C in %s: %s, want %s", testCase.stream, got, testCase.want)
+		}
+	}
+
+	// Profiles without own humidities take the configured ones
+	if got := thresholdProfiles["freezer"].humidity; got != defaultThresholds.humidity {
+		t.Errorf("humidities of the freezer profile %v, want the configured %v", got, defaultThresholds.humidity)
+	}
+
+	// A mapping to an undefined profile is refused
+	t.Setenv("STREAM_PROFILES", "freezer-2=deep-freezer")
+	expectFailure(t, loadThresholdProfiles)
+}
@@ -0,0 +1,166 @@
+package main
+
+/*
+@author 1Zero64
+Progress output as progress bar on terminals and as periodic log lines otherwise
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for measuring and displaying time values
+	"time"
+
+	// Package for progress bar
+	"github.com/schollz/progressbar/v3"
+
+	// Package for terminal detection
+	"golang.org/x/term"
+)
+
+// Enumerations for the progress output modes
+const (
+	ProgressAuto = "auto"
+	ProgressOn   = "on"
+	ProgressOff  = "off"
+)
+
+// Interface of a progress output, implemented by the progress bar and the log lines
+type Progress interface {
+	// Add processed items
+	Add(num int) error
+	// Finish the progress output
+	Finish() error
+}
+
+// Object structure for progress output as periodic plain log lines
+type LogProgress struct {
+	// Total number of items (-1, if unknown)
+	total int64
+	// Number of processed items
+	processed int64
+	// Time point of the last printed line
+	lastPrint time.Time
+}
+
+/*
+Function to create the progress output for the given number of items.
+A progress bar is used on a terminal and periodic log lines otherwise, unless PROGRESS overrides the detection
+@param total Total number of items (-1, if unknown)
+@return Progress output
+*/
+func newProgress(total int64) Progress {
+
+	// Use the progress bar, if forced or stdout is a terminal
+	if progressMode == ProgressOn || (progressMode == ProgressAuto && term.IsTerminal(int(os.Stdout.Fd()))) {
+		return progressbar.Default(total)
+	}
+	return &LogProgress{total: total, lastPrint: time.Now()}
+}
+
+/*
+Function to add processed items and print a log line, if the progress interval passed
+@param num Number of processed items
+@return Always nil, for compatibility with the progress bar
+*/
+func (progress *LogProgress) Add(num int) error {
+	progress.processed += int64(num)
+	if time.Since(progress.lastPrint) >= progressInterval {
+		progress.print()
+	}
+	return nil
+}
+
+/*
+Function to print the final log line
+@return Always nil, for compatibility with the progress bar
+*/
+func (progress *LogProgress) Finish() error {
+	progress.print()
+	return nil
+}
+
+/*
+Function to print a log line with the processed items and the percentage, if the total is known
+*/
+func (progress *LogProgress) print() {
+	progress.lastPrint = time.Now()
+	if progress.total > 0 {
+		fmt.Printf("processed %d/%d (%d%%)\n", progress.processed, progress.total, progress.processed*100/progress.total)
+	} else {
+		fmt.Printf("processed %d\n", progress.processed)
+	}
+}
+
+// Function notified about the progress of a run, e.g. to stream it to a gRPC client
+type ProgressListener func(processed int64, total int64)
+
+// Key of the progress listener in the context of a run
+type progressListenerKey struct{}
+
+// Object structure for a progress output, which also notifies the listener of the run
+type ListenedProgress struct {
+	// Progress output on the console
+	Progress
+	// Listener of the run
+	listener ProgressListener
+	// Total number of items (-1, if unknown)
+	total int64
+	// Number of processed items
+	processed int64
+	// Time point of the last notification
+	lastNotify time.Time
+}
+
+/*
+Function to attach a progress listener to the context of a run
+@param ctx Context of the run
+@param listener Listener notified about the progress
+@return Context with the listener
+*/
+func withProgressListener(ctx context.Context, listener ProgressListener) context.Context {
+	return context.WithValue(ctx, progressListenerKey{}, listener)
+}
+
+/*
+Function to create the progress output of a run, which also notifies the listener of its context, if one is attached
+@param ctx Context of the run
+@param total Total number of items (-1, if unknown)
+@return Progress output
+*/
+func newRunProgress(ctx context.Context, total int64) Progress {
+	progress := newProgress(total)
+	listener, found := ctx.Value(progressListenerKey{}).(ProgressListener)
+	if !found {
+		return progress
+	}
+	return &ListenedProgress{Progress: progress, listener: listener, total: total, lastNotify: time.Now()}
+}
+
+/*
+Function to add processed items and notify the listener, if the progress interval passed
+@param num Number of processed items
+@return Error of the progress output on the console
+*/
+func (progress *ListenedProgress) Add(num int) error {
+	progress.processed += int64(num)
+	if time.Since(progress.lastNotify) >= progressInterval {
+		progress.lastNotify = time.Now()
+		progress.listener(progress.processed, progress.total)
+	}
+	return progress.Progress.Add(num)
+}
+
+/*
+Function to notify the listener about the final progress and finish the progress output on the console
+@return Error of the progress output on the console
+*/
+func (progress *ListenedProgress) Finish() error {
+	progress.listener(progress.processed, progress.total)
+	return progress.Progress.Finish()
+}
@@ -0,0 +1,124 @@
+package main
+
+/*
+@author 1Zero64
+Line based prompts of the interactive menu with validation of the input
+*/
+
+// Importing packages
+import (
+	// Package for buffered reading of lines
+	"bufio"
+	// Package for formatted printing
+	"fmt"
+	// Package for input and output interfaces
+	"io"
+	// Package for math functions
+	"math"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for converting strings into numbers
+	"strconv"
+	// Package for string manipulation
+	"strings"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Largest value accepted by prompts without an upper bound
+const maxInput = math.MaxInt64
+
+// Object structure for prompts reading whole lines of user input
+type Prompt struct {
+	// Scanner reading the input line by line
+	scanner *bufio.Scanner
+	// Output for the prompt labels and hints
+	output io.Writer
+	// Function called, when the input is closed (Ctrl+D or closed stdin)
+	closed func()
+}
+
+// Prompt of the interactive menu on the console, exiting cleanly on closed input
+var consolePrompt = newPrompt(os.Stdin, os.Stdout, func() {
+	fmt.Println("\nInput closed, exiting")
+	os.Exit(0)
+})
+
+/*
+Function to create a prompt
+@param input Input to read the lines from
+@param output Output for the prompt labels and hints
+@param closed Function called, when the input is closed
+@return Pointer to the prompt
+*/
+func newPrompt(input io.Reader, output io.Writer, closed func()) *Prompt {
+	return &Prompt{scanner: bufio.NewScanner(input), output: output, closed: closed}
+}
+
+/*
+Function to print a label and read a non-empty line, re-prompting on empty lines
+@param label Label printed before the input
+@return Line without surrounding whitespace
+*/
+func (prompt *Prompt) text(label string) string {
+	fmt.Fprint(prompt.output, label)
+	for {
+		// Leave on closed input
+		if !prompt.scanner.Scan() {
+			prompt.closed()
+			return ""
+		}
+
+		// Return the first non-empty line
+		if line := strings.TrimSpace(prompt.scanner.Text()); line != "" {
+			return line
+		}
+		fmt.Fprint(prompt.output, label)
+	}
+}
+
+/*
+Function to print a label and read an integer within a range, re-prompting with a hint on invalid input
+@param label Label printed before the input
+@param min Smallest accepted value
+@param max Largest accepted value
+@return Accepted integer
+*/
+func (prompt *Prompt) integer(label string, min int64, max int64) int64 {
+	line := prompt.text(label)
+	for {
+		// Check the input is a number within the range
+		value, err := strconv.ParseInt(line, 10, 64)
+		if err == nil && value >= min && value <= max {
+			return value
+		}
+
+		// Re-prompt with a hint on the expected input
+		if max == maxInput {
+			line = prompt.text(fmt.Sprintf("Please input a whole number of at least %d: ", min))
+		} else {
+			line = prompt.text(fmt.Sprintf("Please input a whole number between %d and %d: ", min, max))
+		}
+	}
+}
+
+/*
+Function to print a label and read an optional date in the form YYYY-MM-DD, re-prompting with a hint on invalid input
+@param label Label printed before the input
+@return Accepted date or the zero time for - (no date)
+*/
+func (prompt *Prompt) date(label string) time.Time {
+	line := prompt.text(label)
+	for {
+		// Accept - for no date and a valid date
+		if line == "-" {
+			return time.Time{}
+		}
+		if value, err := time.Parse("2006-01-02", line); err == nil {
+			return value
+		}
+
+		// Re-prompt with a hint on the expected input
+		line = prompt.text("Please input a date like 2024-01-05 or - for none: ")
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the line based prompts with malformed input
+*/
+
+// Importing packages
+import (
+	// Package for in-memory byte buffers
+	"bytes"
+	// Package for string manipulation
+	"strings"
+	// Package for automated tests
+	"testing"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+/*
+Function to create a prompt reading the given input, which records whether the input was closed
+@param input Lines of the user input
+@return Prompt, its output and the flag set on closed input
+*/
+func testPrompt(input string) (*Prompt, *bytes.Buffer, *bool) {
+	output := &bytes.Buffer{}
+	closed := false
+	return newPrompt(strings.NewReader(input), output, func() { closed = true }), output, &closed
+}
+
+/*
+Test that malformed and out of range integers are re-prompted until a valid one is given
+@param t Test state
+*/
+func TestPromptIntegerRepromptsMalformedInput(t *testing.T) {
+	prompt, output, closed := testPrompt("abc\n\n  \n12x\n99\n-1\n  7 \n")
+	if got := prompt.integer("Select a function: ", 0, 17); got != 7 {
+		t.Errorf("integer = %d, want 7", got)
+	}
+	if *closed {
+		t.Error("input reported as closed")
+	}
+	// The hint is printed for abc, 12x, 99 and -1 and repeated for the two empty lines
+	if hints := strings.Count(output.String(), "Please input a whole number between 0 and 17"); hints != 6 {
+		t.Errorf("%d hints, want 6:\n%s", hints, output)
+	}
+}
+
+/*
+Test that the hint of an integer without upper bound names only the lower one
+@param t Test state
+*/
+func TestPromptIntegerWithoutUpperBound(t *testing.T) {
+	prompt, output, _ := testPrompt("0\n3\n")
+	if got := prompt.integer("How many iterations?: ", 1, maxInput); got != 3 {
+		t.Errorf("integer = %d, want 3", got)
+	}
+	if !strings.Contains(output.String(), "Please input a whole number of at least 1") {
+		t.Errorf("missing hint:\n%s", output)
+	}
+}
+
+/*
+Test that closed input ends the prompt instead of spinning on the last token
+@param t Test state
+*/
+func TestPromptClosedInput(t *testing.T) {
+	prompt, _, closed := testPrompt("abc")
+	prompt.closed = func() {
+		*closed = true
+		panic("closed")
+	}
+	defer func() {
+		if recover() == nil || !*closed {
+			t.Error("closed input not reported")
+		}
+	}()
+	prompt.integer("Select a function: ", 0, 17)
+}
+
+/*
+Test that malformed dates are re-prompted and - is accepted for no date
+@param t Test state
+*/
+func TestPromptDate(t *testing.T) {
+	prompt, output, _ := testPrompt("2024-1-5\n05.01.2024\n2024-02-30\n2024-01-05\n-\n")
+	if got := prompt.date("Created on from: "); !got.Equal(time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("date = %v, want 2024-01-05", got)
+	}
+	if hints := strings.Count(output.String(), "Please input a date like 2024-01-05"); hints != 3 {
+		t.Errorf("%d hints, want 3:\n%s", hints, output)
+	}
+	if got := prompt.date("Created on to: "); !got.IsZero() {
+		t.Errorf("date = %v, want none for -", got)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+/*
+@author 1Zero64
+Retention purge of old rows in the materialized view
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for atomic counters shared by the concurrent writes
+	"sync/atomic"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+/*
+Function to delete materialized view rows with a created_on older than the retention period.
+Rows are deleted in batches of PURGE_BATCH_SIZE rows per statement, so the purge doesn't hold long locks.
+For a partitioned view whole monthly partitions before the cutoff are dropped first
+@param db *sql.DB Database connection to Postgres database
+@param dryRun Only count the rows, that would be removed
+@param held Locks held by the calling operation, like the one of a run purging afterwards
+*/
+func purgeMaterializedView(db *sql.DB, dryRun bool, held ...*MaterializeLock) {
+
+	// Check if a retention period is configured
+	if retention <= 0 {
+		fmt.Println("No RETENTION configured, nothing to purge")
+		return
+	}
+
+	// Refuse to purge a protected environment, counting is fine
+	if !dryRun {
+		requireUnprotected("purge the materialized view")
+
+		// Lock the view against concurrent instances
+		lock := acquireMaterializeLock(db, "materialized_view", held...)
+		defer lock.release()
+		held = append(held, lock)
+	}
+
+	// Calculate cutoff time point, rows created before are purged
+	cutoff := nowFunc().Add(-retention)
+
+	// Save starting time point
+	start := time.Now()
+
+	// Drop partitions entirely before the cutoff, only the partition containing the cutoff needs row deletes
+	var removed int64
+	if partitionedView && !dryRun {
+		removed = dropPartitionsBefore(cutoff, db)
+	}
+
+	// Only count the rows to be removed for a dry-run
+	if dryRun {
+		var count int64
+		err := db.QueryRow("SELECT COUNT(*) FROM materialized_view WHERE created_on < $1", cutoff).Scan(&count)
+		// Check on error with handler
+		checkError(err)
+
+		// Print information about the rows, that would be removed
+		fmt.Printf("Dry-run: %d rows created before %s would be purged (%f seconds)\n", count, cutoff.Format(time.RFC3339), time.Since(start).Seconds())
+
+		// Count the hourly buckets as well, if they are purged too
+		if purgeAggregate {
+			purgeAggregatedView(db, cutoff, true, held...)
+		}
+		return
+	}
+
+	// Delete old rows batch by batch until a batch isn't full anymore, retrying batches deadlocked by concurrent writers
+	var retried atomic.Int64
+	for {
+		var result sql.Result
+		err := retryOnDeadlock(func() (err error) {
+			result, err = db.Exec("DELETE FROM materialized_view WHERE id IN (SELECT id FROM materialized_view WHERE created_on < $1 LIMIT $2)", cutoff, purgeBatchSize)
+			return err
+		}, &retried)
+		// Check on error with handler
+		checkError(err)
+
+		// Count deleted rows of the batch
+		affected, err := result.RowsAffected()
+		checkError(err)
+		removed += affected
+
+		// Stop after the last batch
+		if affected < int64(purgeBatchSize) {
+			break
+		}
+	}
+
+	// Print information about the purge
+	fmt.Printf("Purged %d rows created before %s in %f seconds\n", removed, cutoff.Format(time.RFC3339), time.Since(start).Seconds())
+	if retried.Load() > 0 {
+		fmt.Printf("Deadlocks: %d batches retried\n", retried.Load())
+	}
+
+	// Purge the hourly buckets by the same cutoff, if enabled
+	if purgeAggregate {
+		purgeAggregatedView(db, cutoff, false, held...)
+	}
+}
+
+/*
+Function to delete the hourly buckets of the aggregated view, whose whole hour lies before the cutoff. A bucket holding
+the cutoff is kept, as it still aggregates rows within the retention period
+@param db *sql.DB Database connection to Postgres database
+@param cutoff Time point, before which rows are purged
+@param dryRun Only count the buckets, that would be removed
+@param held Locks held by the calling operations
+*/
+func purgeAggregatedView(db *sql.DB, cutoff time.Time, dryRun bool, held ...*MaterializeLock) {
+	table := quoteTableName(aggregateTable)
+
+	// Buckets start at the full hour, so the ones before the hour of the cutoff have ended before it
+	bucketCutoff := cutoff.Truncate(time.Hour)
+
+	// Save starting time point
+	start := time.Now()
+
+	// Only count the buckets to be removed for a dry-run
+	if dryRun {
+		var count int64
+		err := db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE bucket < $1", bucketCutoff).Scan(&count)
+		// Check on error with handler
+		checkError(err)
+
+		// Print information about the buckets, that would be removed
+		fmt.Printf("Dry-run: %d hourly buckets of %s before %s would be purged (%f seconds)\n", count, aggregateTable, bucketCutoff.Format(time.RFC3339), time.Since(start).Seconds())
+		return
+	}
+
+	// Lock the aggregated view against concurrent instances
+	lock := acquireMaterializeLock(db, aggregateTable, held...)
+	defer lock.release()
+
+	// Delete old buckets batch by batch by their primary key, retrying batches deadlocked by concurrent writers
+	var removed int64
+	var retried atomic.Int64
+	for {
+		var result sql.Result
+		err := retryOnDeadlock(func() (err error) {
+			result, err = db.Exec("DELETE FROM "+table+" WHERE (sensor_id, bucket) IN (SELECT sensor_id, bucket FROM "+table+" WHERE bucket < $1 LIMIT $2)", bucketCutoff, purgeBatchSize)
+			return err
+		}, &retried)
+		// Check on error with handler
+		checkError(err)
+
+		// Count deleted buckets of the batch
+		affected, err := result.RowsAffected()
+		checkError(err)
+		removed += affected
+
+		// Stop after the last batch
+		if affected < int64(purgeBatchSize) {
+			break
+		}
+	}
+
+	// Print information about the purge
+	fmt.Printf("Purged %d hourly buckets of %s before %s in %f seconds\n", removed, aggregateTable, bucketCutoff.Format(time.RFC3339), time.Since(start).Seconds())
+	if retried.Load() > 0 {
+		fmt.Printf("Deadlocks: %d batches retried\n", retried.Load())
+	}
+}
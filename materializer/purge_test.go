@@ -0,0 +1,87 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the retention purge on a Postgres database given by TEST_DATABASE_URL
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for string manipulation
+	"strings"
+	// Package for automated tests
+	"testing"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+/*
+Function to count the hourly buckets of the aggregated view
+@param t Test state
+@param db Database handle
+@return Number of buckets
+*/
+func countHourlyBuckets(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + quoteTableName(aggregateTable)).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+/*
+Test that the retention purge only deletes the hourly buckets of a table name needing quotes with PURGE_AGGREGATE, and only
+the buckets, whose whole hour lies before the cutoff
+@param t Test state
+*/
+func TestPurgeAggregatedView(t *testing.T) {
+	db := openTestDatabase(t)
+	useDatabaseRun(t)
+	savedAggregate, savedTable, savedPurge, savedRetention, savedBatch, savedNow := aggregateMode, aggregateTable, purgeAggregate, retention, purgeBatchSize, nowFunc
+	t.Cleanup(func() {
+		db.Exec("DROP TABLE IF EXISTS " + quoteTableName(aggregateTable))
+		aggregateMode, aggregateTable, purgeAggregate, retention, purgeBatchSize, nowFunc = savedAggregate, savedTable, savedPurge, savedRetention, savedBatch, savedNow
+	})
+
+	// A cutoff at 10:30 a day before now, with batches of a single bucket
+	aggregateMode, aggregateTable, retention, purgeBatchSize = true, "Hourly Buckets", 24*time.Hour, 1
+	nowFunc = func() time.Time { return time.Date(2024, 1, 5, 10, 30, 0, 0, time.UTC) }
+	if _, err := db.Exec("DROP TABLE IF EXISTS " + quoteTableName(aggregateTable)); err != nil {
+		t.Fatal(err)
+	}
+	createTestEventStore(t, db, pushdownFixture)
+	for _, bucket := range []string{"2024-01-04 08:00", "2024-01-04 09:00", "2024-01-04 10:00", "2024-01-05 10:00"} {
+		if _, err := db.Exec("INSERT INTO "+quoteTableName(aggregateTable)+" (sensor_id, bucket, measurements, danger) VALUES (7, $1, 1, $2)", bucket, No); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The buckets are kept by default
+	purgeAggregate = false
+	captureStdout(t, func() { purgeMaterializedView(db, false) })
+	if got := countHourlyBuckets(t, db); got != 4 {
+		t.Fatalf("%d buckets without PURGE_AGGREGATE, want all 4", got)
+	}
+
+	// The dry-run counts the buckets before the hour of the cutoff without removing them
+	purgeAggregate = true
+	output := captureStdout(t, func() { purgeMaterializedView(db, true) })
+	if !strings.Contains(output, "Dry-run: 2 hourly buckets of Hourly Buckets") {
+		t.Errorf("dry-run output %q, want 2 hourly buckets", output)
+	}
+	if got := countHourlyBuckets(t, db); got != 4 {
+		t.Fatalf("%d buckets after the dry-run, want all 4", got)
+	}
+
+	// The bucket of 10:00 holds the cutoff and is kept
+	output = captureStdout(t, func() { purgeMaterializedView(db, false) })
+	if !strings.Contains(output, "Purged 2 hourly buckets") {
+		t.Errorf("purge output %q, want 2 purged hourly buckets", output)
+	}
+	if got := countHourlyBuckets(t, db); got != 2 {
+		t.Errorf("%d buckets after the purge, want the 2 of 10:00", got)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+/*
+@author 1Zero64
+Database-side materialization with a single INSERT ... SELECT to compare against the transformation in Go
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for converting numbers into strings
+	"strconv"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Heat index in Grad Celsius computed like calculateHeatIndex from the Fahrenheit temperature t, the humidity rh and the simple heat index hi
+const heatIndexExpression = `(CASE WHEN (hi + t) / 2 >= 80 THEN
+		-42.379 + 2.04901523*t + 10.14333127*rh - 0.22475541*t*rh - 0.00683783*t*t - 0.05481717*rh*rh
+		+ 0.00122874*t*t*rh + 0.00085282*t*rh*rh - 0.00000199*t*t*rh*rh
+		- CASE WHEN rh < 13 AND t >= 80 AND t <= 112 THEN ((13 - rh) / 4) * sqrt((17 - abs(t - 95)) / 17) ELSE 0 END
+		+ CASE WHEN rh > 85 AND t >= 80 AND t <= 87 THEN ((rh - 85) / 10) * ((87 - t) / 5) ELSE 0 END
+	ELSE hi END - 32) * 5 / 9`
+
+/*
+Function to build the SQL CASE expression of the danger level from the configured thresholds, equivalent to classifyWithThresholds
+@return CASE expression over the temperature and humidity columns
+*/
+func dangerCaseExpression() string {
+
+	// Compare the double precision values against the thresholds plus tolerance like exceeds()
+	levels := []string{Low, Medium, High, Critical}
+	expression := "CASE"
+	for i := len(levels) - 1; i >= 0; i-- {
+		expression += fmt.Sprintf(" WHEN temperature::float8 > %s OR humidity::float8 > %s THEN '%s'",
+			strconv.FormatFloat(thresholds.temperature[i]+tolerance, 'g', -1, 64),
+			strconv.FormatFloat(thresholds.humidity[i]+tolerance, 'g', -1, 64),
+			levels[i])
+	}
+	return expression + " ELSE '" + No + "' END"
+}
+
+/*
+Function to materialize the measurements of the read query with a single INSERT ... SELECT, which computes latency,
+danger level and heat index in the database instead of in Go
+@param db *sql.DB Database connection to Postgres database
+@param table Name of the table to write into
+@param query Select query on the event store
+@param args Arguments for the placeholders of the query
+@return Number of inserted measurements and of measurements dead-lettered for a NaN or infinite reading
+*/
+func materializePushdown(db *sql.DB, table string, query string, args ...interface{}) (int, int) {
+
+	// Create the monthly partitions of all measurements up front, if the view is partitioned
+	if partitionedView && table == "materialized_view" {
+		var first, last sql.NullTime
+		err := db.QueryRow("SELECT MIN(created_on), MAX(created_on) FROM ("+query+") e", args...).Scan(&first, &last)
+		checkError(err)
+		if first.Valid {
+			month := time.Date(first.Time.Year(), first.Time.Month(), 1, 0, 0, 0, 0, time.UTC)
+			for ; !month.After(last.Time); month = month.AddDate(0, 1, 0) {
+				ensurePartition(month, db)
+			}
+		}
+	}
+
+	// Dead-letter the measurements with a NaN or infinite reading, which would exceed every threshold in SQL
+	result, err := db.Exec(`INSERT INTO dead_letter (id, created_on, event_stream, humidity, processed_on, sensor_id, temperature, reason)
+	SELECT id, created_on, event_stream, humidity, processed_on, sensor_id, temperature, 'non-finite reading'
+	FROM (`+query+`) e WHERE `+nonFiniteCondition, args...)
+	checkError(err)
+	deadLettered, err := result.RowsAffected()
+	checkError(err)
+
+	// The float latency is divided into the latency unit in single precision like in transformMeasurement, so both paths round identically
+	floatLatency := fmt.Sprintf("(EXTRACT(EPOCH FROM processed_on - created_on) * 1000000000)::real / %d::real", int64(latencyUnit.nanoseconds))
+	if !latencyFloatColumn {
+		floatLatency = "NULL::real"
+	}
+
+	// Insert the transformed measurements, with the Fahrenheit temperature and the simple heat index computed once per row
+	statement := fmt.Sprintf(`INSERT INTO %s (id, created_on, danger, event_stream, humidity, latency, processed_on, sensor_id, temperature, heat_index, unknown_sensor, latency_us)
+	SELECT id, created_on, %s, event_stream, humidity, %s, processed_on, sensor_id, temperature, %s, FALSE, %s
+	FROM (
+		SELECT e.*, t, rh, 0.5 * (t + 61.0 + ((t - 68.0) * 1.2) + (rh * 0.094)) AS hi
+		FROM (%s) e, LATERAL (SELECT e.temperature::float8 * 9 / 5 + 32 AS t, e.humidity::float8 AS rh) converted
+		WHERE NOT %s
+	) measurements`, table, dangerCaseExpression(), floatLatency, heatIndexExpression, latencyMicrosecondsExpression, query, nonFiniteCondition)
+
+	// Ignore already materialized measurements in append-only mode, because the view isn't cleaned before
+	if appendOnly {
+		statement += " ON CONFLICT DO NOTHING"
+	}
+
+	// Execute insert and check on error with handler
+	result, err = db.Exec(statement, args...)
+	checkError(err)
+
+	// Return number of inserted and dead-lettered measurements
+	inserted, err := result.RowsAffected()
+	checkError(err)
+	return int(inserted), int(deadLettered)
+}
+
+/*
+Function to materialize the view once in Go and once with the SQL pushdown and print durations and whether the contents are identical
+@param db *sql.DB Database connection to Postgres database
+*/
+func pushdownComparison(db *sql.DB) {
+
+	// Generate unique identifier of the comparison, shared by both runs
+	runId := newRunId()
+
+	// Print information about starting the comparison
+	fmt.Printf("Starting comparison of Go and SQL pushdown materialization (run %s)...\n", runId)
+
+	// Restore the configured mode afterwards
+	configured := pushdownMode
+	defer func() { pushdownMode = configured }()
+
+	// Materialize with both modes and remember duration and view contents
+	var durations [2]float64
+	var checksums [2]string
+	for i, pushdown := range []bool{false, true} {
+		pushdownMode = pushdown
+		start := time.Now()
+		summary := materialize(context.Background(), db, runId, nil)
+		durations[i] = time.Since(start).Seconds()
+		checksums[i] = materializedViewChecksum(db)
+		fmt.Printf("%d measurements materialized\n", summary.measurements)
+	}
+
+	// Print comparison
+	fmt.Printf("%-10s %18s %34s\n", "Mode", "Duration (seconds)", "View checksum")
+	fmt.Printf("%-10s %18f %34s\n", "Go", durations[0], checksums[0])
+	fmt.Printf("%-10s %18f %34s\n", "Pushdown", durations[1], checksums[1])
+	if checksums[0] == checksums[1] {
+		fmt.Println("Both modes produced identical materialized view contents")
+	} else {
+		fmt.Println("Warning: the modes produced different materialized view contents")
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the SQL pushdown against the transformation in Go on a Postgres database given by TEST_DATABASE_URL
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for automated tests
+	"testing"
+)
+
+// Columns of the materialized view as text, in the order of the dump
+var materializedViewDumpColumns = []string{"id", "created_on", "danger", "event_stream", "humidity", "latency", "latency_us", "processed_on", "sensor_id", "temperature", "heat_index", "unknown_sensor"}
+
+// Fixture rows of the event store covering every danger level, both heat index formulas with their adjustments and sub-millisecond latencies
+const pushdownFixture = `
+	(1, '2024-01-05 10:00:00', 'kafka', 10, '2024-01-05 10:00:00.0004', 7, 1.5),
+	(2, '2024-01-05 10:00:01', 'kafka', 35.5, '2024-01-05 10:00:01.25', 7, 4.2),
+	(3, '2024-01-05 10:00:02', 'pulsar', 45.3, '2024-01-05 10:00:03.123456', 8, 6.1),
+	(4, '2024-01-05 10:00:03', 'pulsar', 55.7, '2024-01-05 10:00:03.5', 8, 7.3),
+	(5, '2024-01-05 10:00:04', 'kafka', 61, '2024-01-05 10:00:04.000001', 9, 10.1),
+	(6, '2024-01-05 10:00:05', 'kafka', 70, '2024-01-05 10:00:06', 9, 32.2),
+	(7, '2024-01-05 10:00:06', 'pulsar', 5, '2024-01-05 10:00:07.777', 10, 35),
+	(8, '2024-01-05 10:00:07', 'pulsar', 95, '2024-01-05 10:00:07.1', 10, 27.8),
+	(9, '2024-01-05 10:00:08', 'kafka', 0, '2024-01-05 10:00:07.9', 11, -12.4)`
+
+/*
+Function to configure a run from the event store into the materialized view with the default settings, the configuration is
+restored when the test finished
+@param t Test state
+*/
+func useDatabaseRun(t *testing.T) {
+	t.Helper()
+	savedSource, savedOutput, savedRate, savedOrder := sourceMode, outputMode, sampleRate, readOrder
+	savedThresholds, savedTolerance, savedNull, savedNonFinite := configuredThresholds, tolerance, nullPolicy, nonFinitePolicy
+	savedConcurrency, savedLatencyFloat, savedAppend, savedPushdown := writeConcurrency, latencyFloatColumn, appendOnly, pushdownMode
+	t.Cleanup(func() {
+		sourceMode, outputMode, sampleRate, readOrder = savedSource, savedOutput, savedRate, savedOrder
+		configuredThresholds, tolerance, nullPolicy, nonFinitePolicy = savedThresholds, savedTolerance, savedNull, savedNonFinite
+		writeConcurrency, latencyFloatColumn, appendOnly, pushdownMode = savedConcurrency, savedLatencyFloat, savedAppend, savedPushdown
+	})
+	sourceMode, outputMode, sampleRate, readOrder = ModeDb, ModeDb, 1, "id ASC"
+	configuredThresholds, tolerance, nullPolicy, nonFinitePolicy = defaultThresholds, 1e-4, NullPolicyError, NonFiniteDeadLetter
+	writeConcurrency, latencyFloatColumn, appendOnly, pushdownMode = 1, true, false, false
+}
+
+/*
+Function to recreate the event store with the given rows and an empty materialized view and dead letter table
+@param t Test state
+@param db Database handle
+@param values SQL tuples of the rows (id, created_on, event_stream, humidity, processed_on, sensor_id, temperature)
+*/
+func createTestEventStore(t *testing.T, db *sql.DB, values string) {
+	t.Helper()
+	for _, statement := range []string{
+		"DROP TABLE IF EXISTS event_store, materialized_view, dead_letter",
+		`CREATE TABLE event_store (
+			id BIGINT PRIMARY KEY,
+			created_on TIMESTAMP,
+			event_stream VARCHAR(255),
+			humidity ` + readingColumnType + `,
+			processed_on TIMESTAMP,
+			sensor_id BIGINT,
+			temperature ` + readingColumnType + `
+		)`,
+		"INSERT INTO event_store VALUES " + values,
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	createSchema(db)
+	createDeadLetterTable(db)
+}
+
+/*
+Function to dump the materialized view as text in id order, NULL as the text NULL
+@param t Test state
+@param db Database handle
+@return Rows with the columns of materializedViewDumpColumns
+*/
+func dumpMaterializedView(t *testing.T, db *sql.DB) [][]string {
+	t.Helper()
+	rows, err := db.Query(`SELECT id::text, created_on::text, danger, event_stream, humidity::text, COALESCE(latency::text, 'NULL'),
+		latency_us::text, processed_on::text, sensor_id::text, temperature::text, COALESCE(heat_index::text, 'NULL'), unknown_sensor::text
+		FROM materialized_view ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var dump [][]string
+	for rows.Next() {
+		row := make([]string, len(materializedViewDumpColumns))
+		pointers := make([]interface{}, len(row))
+		for i := range row {
+			pointers[i] = &row[i]
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			t.Fatal(err)
+		}
+		dump = append(dump, row)
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return dump
+}
+
+/*
+Function to compare two dumps of the materialized view column by column
+@param t Test state
+@param names Names of the dumps for the messages
+@param dumps Both dumps
+*/
+func compareDumps(t *testing.T, names [2]string, dumps [2][][]string) {
+	t.Helper()
+	if len(dumps[0]) != len(dumps[1]) {
+		t.Fatalf("%s wrote %d rows, %s %d", names[0], len(dumps[0]), names[1], len(dumps[1]))
+	}
+	for i := range dumps[0] {
+		for column, name := range materializedViewDumpColumns {
+			if dumps[0][i][column] != dumps[1][i][column] {
+				t.Errorf("row id=%s column %s: %s %q, %s %q", dumps[0][i][0], name, names[0], dumps[0][i][column], names[1], dumps[1][i][column])
+			}
+		}
+	}
+}
+
+/*
+Test that the SQL pushdown writes the same rows as the transformation in Go, as the danger level, heat index and
+latency expressions reimplement the Go logic
+@param t Test state
+*/
+func TestPushdownMatchesGoTransformation(t *testing.T) {
+	db := openTestDatabase(t)
+	useDatabaseRun(t)
+
+	var dumps [2][][]string
+	for i, pushdown := range []bool{false, true} {
+		createTestEventStore(t, db, pushdownFixture)
+		pushdownMode = pushdown
+		captureStdout(t, func() { materialize(context.Background(), db, "test", nil) })
+		dumps[i] = dumpMaterializedView(t, db)
+	}
+	if len(dumps[0]) != 9 {
+		t.Fatalf("Go wrote %d rows, want all 9 of the fixture", len(dumps[0]))
+	}
+	compareDumps(t, [2]string{"Go", "pushdown"}, dumps)
+}
@@ -0,0 +1,27 @@
+//go:build !float64
+
+package main
+
+/*
+@author 1Zero64
+Single precision storage of temperature and humidity readings (default build)
+*/
+
+// Importing packages
+import (
+	// Package for math functions
+	"math"
+)
+
+// Type of temperature and humidity readings. Single precision halves the memory of the measurements,
+// but a value like 10.1 can't be represented exactly and has to be compared with a tolerance
+type reading = float32
+
+// Number of bits of a reading for parsing and formatting
+const readingBits = 32
+
+// Postgres column type of readings in newly created tables
+const readingColumnType = "REAL"
+
+// Largest finite reading, which infinite readings are clamped to
+const maxReading = math.MaxFloat32
@@ -0,0 +1,61 @@
+//go:build !float64
+
+package main
+
+/*
+@author 1Zero64
+Tests of the single precision readings of the default build
+*/
+
+// Importing packages
+import (
+	// Package for math functions
+	"math"
+	// Package for conversions from and to strings
+	"strconv"
+	// Package for string manipulation
+	"strings"
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Test the constants of single precision readings
+@param t Test state
+*/
+func TestSinglePrecisionReading(t *testing.T) {
+	if readingBits != 32 || maxReading != math.MaxFloat32 || readingColumnType != "REAL" {
+		t.Errorf("%d bits, max %v, column %s, want 32 bits, max float32 and REAL", readingBits, maxReading, readingColumnType)
+	}
+}
+
+/*
+Test that a precise temperature is rounded to the nearest single precision value by the transformation and write into a CSV file
+@param t Test state
+*/
+func TestSinglePrecisionCsvRoundTrip(t *testing.T) {
+	written, err := strconv.ParseFloat(materializeCsvTemperature(t, preciseTemperature), 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, _ := strconv.ParseFloat(preciseTemperature, 64)
+	if written != float64(float32(source)) || written == source {
+		t.Errorf("written %v, want the single precision %v of %v", written, float32(source), source)
+	}
+}
+
+/*
+Test that a precise temperature is stored in a REAL column as single precision value, which is expected by this build
+without a warning
+@param t Test state
+*/
+func TestSinglePrecisionDatabaseRoundTrip(t *testing.T) {
+	db := openTestDatabase(t)
+	source, _ := strconv.ParseFloat(preciseTemperature, 64)
+	if written := materializeDatabaseTemperature(t, db, preciseTemperature); written != float64(float32(source)) {
+		t.Errorf("written %v, want the single precision %v", written, float32(source))
+	}
+	if output := createSchemaOnReadingColumns(t, db, "REAL"); strings.Contains(output, "stores REAL") {
+		t.Errorf("warning on REAL columns of a single precision build: %q", output)
+	}
+}
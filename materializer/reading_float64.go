@@ -0,0 +1,27 @@
+//go:build float64
+
+package main
+
+/*
+@author 1Zero64
+Double precision storage of temperature and humidity readings (build with -tags float64)
+*/
+
+// Importing packages
+import (
+	// Package for math functions
+	"math"
+)
+
+// Type of temperature and humidity readings. Double precision keeps sensor readings exact for downstream tools
+// expecting doubles at the cost of twice the memory per measurement
+type reading = float64
+
+// Number of bits of a reading for parsing and formatting
+const readingBits = 64
+
+// Postgres column type of readings in newly created tables
+const readingColumnType = "DOUBLE PRECISION"
+
+// Largest finite reading, which infinite readings are clamped to
+const maxReading = math.MaxFloat64
@@ -0,0 +1,65 @@
+//go:build float64
+
+package main
+
+/*
+@author 1Zero64
+Tests of the double precision readings of the build with -tags float64
+*/
+
+// Importing packages
+import (
+	// Package for math functions
+	"math"
+	// Package for conversions from and to strings
+	"strconv"
+	// Package for string manipulation
+	"strings"
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Test the constants of double precision readings
+@param t Test state
+*/
+func TestDoublePrecisionReading(t *testing.T) {
+	if readingBits != 64 || maxReading != math.MaxFloat64 || readingColumnType != "DOUBLE PRECISION" {
+		t.Errorf("%d bits, max %v, column %s, want 64 bits, max float64 and DOUBLE PRECISION", readingBits, maxReading, readingColumnType)
+	}
+}
+
+/*
+Test that a temperature needing double precision survives the transformation and write into a CSV file without losing digits
+@param t Test state
+*/
+func TestDoublePrecisionCsvRoundTrip(t *testing.T) {
+	written, err := strconv.ParseFloat(materializeCsvTemperature(t, preciseTemperature), 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source, _ := strconv.ParseFloat(preciseTemperature, 64); written != source {
+		t.Errorf("written %v, want %v", written, source)
+	}
+}
+
+/*
+Test that a temperature needing double precision survives the write into the materialized view, and the warning on an
+existing materialized view with REAL reading columns, which would round it
+@param t Test state
+*/
+func TestDoublePrecisionDatabaseRoundTrip(t *testing.T) {
+	db := openTestDatabase(t)
+	source, _ := strconv.ParseFloat(preciseTemperature, 64)
+	if written := materializeDatabaseTemperature(t, db, preciseTemperature); written != source {
+		t.Errorf("written %v, want %v", written, source)
+	}
+
+	warning := "built with double precision readings, but materialized_view stores REAL"
+	if output := createSchemaOnReadingColumns(t, db, "REAL"); !strings.Contains(output, warning) {
+		t.Errorf("no warning on REAL columns, output %q", output)
+	}
+	if output := createSchemaOnReadingColumns(t, db, "DOUBLE PRECISION"); strings.Contains(output, warning) {
+		t.Errorf("warning on DOUBLE PRECISION columns: %q", output)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+/*
+@author 1Zero64
+Helpers of the tests of the reading precision of the float32 and float64 builds
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for string manipulation
+	"strings"
+	// Package for automated tests
+	"testing"
+)
+
+// Temperature with more significant digits than a single precision reading holds
+const preciseTemperature = "20.123456789012345"
+
+/*
+Function to materialize a measurement with the given temperature from a CSV source into a CSV output
+@param t Test state
+@param temperature Temperature field of the source
+@return Temperature field of the output
+*/
+func materializeCsvTemperature(t *testing.T, temperature string) string {
+	t.Helper()
+	output := useCsvSourceAndOutput(t, csvSourceHeader+"1,7,"+temperature+",45,room-1,2024-01-05T10:00:00Z,2024-01-05T10:00:01Z\n")
+	captureStdout(t, func() { materialize(context.Background(), nil, "test", nil) })
+	records := readCsvOutput(t, output)
+	if len(records) != 1 {
+		t.Fatalf("records %v, want one", records)
+	}
+	return records[0][8]
+}
+
+/*
+Function to materialize a measurement with the given temperature from the event store into the materialized view
+@param t Test state
+@param db Database handle
+@param temperature Temperature of the event store row
+@return Temperature read back from the materialized view
+*/
+func materializeDatabaseTemperature(t *testing.T, db *sql.DB, temperature string) float64 {
+	t.Helper()
+	useDatabaseRun(t)
+	createTestEventStore(t, db, "(1, '2024-01-05 10:00:00', 'room-1', 45, '2024-01-05 10:00:01', 7, "+temperature+")")
+	captureStdout(t, func() { materialize(context.Background(), db, "test", nil) })
+	var written float64
+	if err := db.QueryRow("SELECT temperature FROM materialized_view WHERE id = 1").Scan(&written); err != nil {
+		t.Fatal(err)
+	}
+	return written
+}
+
+/*
+Function to create the schema on an existing materialized view, whose reading columns have the given type
+@param t Test state
+@param db Database handle
+@param columnType Postgres type of the reading columns
+@return Printed output of the schema creation
+*/
+func createSchemaOnReadingColumns(t *testing.T, db *sql.DB, columnType string) string {
+	t.Helper()
+	columns := strings.ReplaceAll(materializedViewColumns, readingColumnType, columnType)
+	for _, statement := range []string{
+		"DROP TABLE IF EXISTS materialized_view",
+		"CREATE TABLE materialized_view (" + columns + ",\n\t\tPRIMARY KEY (id)\n\t)",
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return captureStdout(t, func() { createSchema(db) })
+}
@@ -0,0 +1,57 @@
+package main
+
+/*
+@author 1Zero64
+Choice between reading the measurements into a slice and streaming them by the MAX_IN_MEMORY threshold
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+)
+
+// Enumerations for the paths reading the measurements of the event store
+const (
+	ReadPathSlice     = "slice"
+	ReadPathStreaming = "streaming"
+)
+
+/*
+Function to choose the read path of the measurements. The pipeline mode always streams, otherwise the measurements
+are counted and streamed, if there are more than MAX_IN_MEMORY, to avoid holding all of them in memory
+@param db *sql.DB Database connection to Postgres database
+@param condition Optional condition of the WHERE clause to filter measurements (empty to read all)
+@param args Arguments for the placeholders of the condition
+@return ReadPathSlice or ReadPathStreaming
+*/
+func chooseReadPath(db *sql.DB, condition string, args ...interface{}) string {
+
+	// Stream always in the pipeline mode and read into a slice without a threshold
+	if pipelineMode {
+		return ReadPathStreaming
+	}
+	if maxInMemory <= 0 {
+		return ReadPathSlice
+	}
+
+	// Count the measurements in scope of the read
+	condition, args = eventStoreCondition(condition, args...)
+	query := "SELECT COUNT(*) FROM event_store"
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	var count int64
+	err := readHandle(db).QueryRow(query, args...).Scan(&count)
+	checkError(err)
+
+	// Stream above the threshold and report the chosen path
+	if count > maxInMemory {
+		fmt.Printf("Read path: streaming, %d measurements exceed MAX_IN_MEMORY %d\n", count, maxInMemory)
+		return ReadPathStreaming
+	}
+	fmt.Printf("Read path: slice, %d measurements within MAX_IN_MEMORY %d\n", count, maxInMemory)
+	return ReadPathSlice
+}
@@ -0,0 +1,100 @@
+package main
+
+/*
+@author 1Zero64
+Recovery of the interactive menu after a failed function, so a run can be retried without restarting
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for inspecting errors
+	"errors"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for synchronization of goroutines
+	"sync"
+	// Package for atomic operations
+	"sync/atomic"
+)
+
+// Object structure for the first failure of background goroutines, which is raised again on the goroutine waiting for them,
+// because a panic can only be recovered on its own goroutine and would terminate the program otherwise
+type GoroutineFailure struct {
+	// Guard to keep only the first failure
+	once sync.Once
+	// Flag whether a goroutine failed, to stop starting further ones
+	failed atomic.Bool
+	// Value of the first panic
+	recovered interface{}
+}
+
+/*
+Function to capture the panic of a background goroutine, deferred at its start
+*/
+func (failure *GoroutineFailure) capture() {
+	if recovered := recover(); recovered != nil {
+		failure.once.Do(func() {
+			failure.recovered = recovered
+			failure.failed.Store(true)
+		})
+	}
+}
+
+/*
+Function to raise the captured failure again on the calling goroutine, after the background goroutines finished
+*/
+func (failure *GoroutineFailure) raise() {
+	if failure.failed.Load() {
+		panic(failure.recovered)
+	}
+}
+
+/*
+Function to recover from the failure of a menu function, deferred around each selected function. The error is summarized
+and the connection re-validated, waiting for the database up to STARTUP_TIMEOUT, if it became unreachable.
+Open transactions of the function are rolled back by their deferred rollbacks while unwinding. Failures of writer and pipeline
+goroutines are raised again on the goroutine of the function, so they are recovered as well.
+Without MENU_RECOVER the failure isn't recovered and terminates the program as before. A run aborted by strict mode
+terminates the program with exit code 1 in either case, after the function cleaned up
+@param db *sql.DB Database connection to Postgres database
+*/
+func recoverMenuFunction(db *sql.DB) {
+
+	// Let the failure terminate the program, if the recovery is disabled and strict mode can't abort a run
+	if !menuRecover && !strict {
+		return
+	}
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	// Exit with the details of the offending measurement on a strict mode abort
+	var strictAbort *StrictAbortError
+	if failure, ok := recovered.(error); ok && errors.As(failure, &strictAbort) {
+		fmt.Fprintln(os.Stderr, strictAbort)
+		os.Exit(1)
+	}
+	if !menuRecover {
+		panic(recovered)
+	}
+
+	// Summarize the error
+	fmt.Fprintf(os.Stderr, "\nFunction failed: %v\n", recovered)
+	fmt.Fprintln(os.Stderr, "Open transactions were rolled back. A failed materialize run may have left the view incomplete (unless STAGING_REBUILD is enabled), rerun it after fixing the issue")
+
+	// Re-validate the connection before returning to the menu
+	ctx, cancel := context.WithTimeout(context.Background(), healthcheckTimeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Database unreachable (%v), waiting for it...\n", err)
+		waitForDatabase(db)
+	}
+	fmt.Println("Connection is healthy, returning to the menu")
+}
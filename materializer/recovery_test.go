@@ -0,0 +1,156 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the recovery of the interactive menu from failures of background goroutines
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package with the interfaces of the database drivers
+	"database/sql/driver"
+	// Package for inspecting errors
+	"errors"
+	// Package for reading input
+	"io"
+	// Package for atomic operations
+	"sync/atomic"
+	// Package for automated tests
+	"testing"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Driver, whose connections open, ping and answer SHOW synchronous_commit of the benchmark and the ids of the sensor
+// registry stub_sensors, but can't execute other statements
+type stubDriver struct{}
+
+// Sensor ids of the registry stub_sensors of the stub driver
+var stubSensorIds []string
+
+// Connection of the stub driver
+type stubConn struct{}
+
+// Rows of the stub driver with a single text column
+type stubRows struct {
+	values []string
+}
+
+func (stubDriver) Open(name string) (driver.Conn, error) { return stubConn{}, nil }
+func (stubConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("stub driver can't prepare")
+}
+func (stubConn) Close() error              { return nil }
+func (stubConn) Begin() (driver.Tx, error) { return nil, errors.New("stub driver can't begin") }
+func (stubConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	switch query {
+	case "SHOW synchronous_commit":
+		return &stubRows{values: []string{"on"}}, nil
+	case `SELECT id FROM "stub_sensors"`:
+		return &stubRows{values: append([]string(nil), stubSensorIds...)}, nil
+	}
+	return nil, errors.New("stub driver can't query")
+}
+func (*stubRows) Columns() []string { return []string{"value"} }
+func (*stubRows) Close() error      { return nil }
+func (rows *stubRows) Next(dest []driver.Value) error {
+	if len(rows.values) == 0 {
+		return io.EOF
+	}
+	dest[0], rows.values = rows.values[0], rows.values[1:]
+	return nil
+}
+
+/*
+Function to register the stub driver once for all tests
+*/
+func init() {
+	sql.Register("stub", stubDriver{})
+}
+
+/*
+Function to open a database handle of the stub driver, which answers the ping of the menu recovery
+@param t Test state
+@return Database handle
+*/
+func openStubDatabase(t *testing.T) *sql.DB {
+	db, err := sql.Open("stub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+/*
+Test that a failed background write is raised on the goroutine of the menu function and recovered there,
+and that no further writes are started after it
+@param t Test state
+*/
+func TestMenuRecoversFromFailedBackgroundWrite(t *testing.T) {
+	menuRecover = true
+	defer func() { menuRecover = false }()
+	db := openStubDatabase(t)
+
+	// Run a menu function with concurrent writes, of which the third fails mid-run
+	var started atomic.Int32
+	returned := false
+	func() {
+		defer recoverMenuFunction(db)
+		writes := newBackgroundWrites(4)
+		for i := 0; i < 100 && !writes.failed(); i++ {
+			i := i
+			writes.start(func() {
+				started.Add(1)
+				if i == 2 {
+					checkError(errors.New("insert failed"))
+				}
+				time.Sleep(time.Millisecond)
+			})
+			if i == 2 {
+				// Give the failing write time to fail before starting the next ones
+				time.Sleep(20 * time.Millisecond)
+			}
+		}
+		writes.wait()
+		writes.failure.raise()
+		returned = true
+	}()
+
+	// The failure has to be recovered by the menu instead of terminating the test binary
+	if returned {
+		t.Fatal("failed write wasn't raised on the menu goroutine")
+	}
+	if started.Load() >= 100 {
+		t.Errorf("%d writes started, want the run to stop after the failure", started.Load())
+	}
+}
+
+/*
+Test that a failed pipeline read is raised by the writer after the last read measurement
+@param t Test state
+*/
+func TestPipelineReaderRaisesReadFailure(t *testing.T) {
+	reader := &PipelineReader{measurements: make(chan Measurement, 1)}
+	go func() {
+		defer close(reader.measurements)
+		defer reader.failure.capture()
+		reader.measurements <- Measurement{id: 1}
+		checkError(errors.New("connection reset"))
+	}()
+
+	// The read measurement is taken, the failure raised instead of ending the measurements
+	if measurement, ok := reader.next(); !ok || measurement.id != 1 {
+		t.Fatalf("next() = %v, %v, want measurement 1", measurement.id, ok)
+	}
+	defer func() {
+		if recovered := recover(); recovered == nil {
+			t.Error("read failure wasn't raised")
+		}
+	}()
+	reader.next()
+}
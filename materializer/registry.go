@@ -0,0 +1,135 @@
+package main
+
+/*
+@author 1Zero64
+Validation of sensor ids against a sensor registry
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for string manipulation
+	"strings"
+	// Package for synchronization of goroutines
+	"sync"
+	// Package for measuring and displaying time values
+	"time"
+
+	// Postgres driver, used to quote identifiers
+	"github.com/lib/pq"
+)
+
+/*
+Function to quote a table name, which may be qualified with a schema, so it can't inject SQL into a statement
+@param table Table name like sensors or registry.sensors
+@return Quoted table name like "sensors" or "registry"."sensors"
+*/
+func quoteTableName(table string) string {
+
+	// Quote the schema and the table separately
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		parts[i] = pq.QuoteIdentifier(part)
+	}
+
+	// Return quoted table name
+	return strings.Join(parts, ".")
+}
+
+/*
+Function to load the set of valid sensor ids from the sensor registry table
+@param db *sql.DB Database connection to Postgres database
+@return Set of registered sensor ids
+*/
+func loadSensorRegistry(db *sql.DB) map[int64]bool {
+
+	// Select all sensor ids of the registry
+	rows, err := db.Query("SELECT id FROM " + quoteTableName(sensorTable))
+	// Check on error with handler
+	checkError(err)
+
+	// Close rows object later, when surrounding function returns
+	defer rows.Close()
+
+	// Collect sensor ids into a set
+	registry := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		checkError(err)
+		registry[id] = true
+	}
+	checkError(rows.Err())
+
+	// Print information about the loaded registry
+	fmt.Printf("Loaded %d registered sensors from %s\n", len(registry), sensorTable)
+
+	// Return set of sensor ids
+	return registry
+}
+
+// Object structure for the loaded sensor registry, which is reused until SENSOR_REGISTRY_REFRESH elapsed
+type SensorRegistry struct {
+	// Set of registered sensor ids (nil before the first load)
+	ids map[int64]bool
+	// Time point on when the ids were loaded
+	loaded time.Time
+}
+
+// Sensor registry of the process, shared by the materialize runs, scheduled jobs and CDC batches
+var sensorRegistry SensorRegistry
+
+// Mutex guarding the sensor registry against concurrent runs
+var sensorRegistryMutex sync.Mutex
+
+/*
+Function to get the registered sensor ids, reloading them, if they weren't loaded yet or are older than SENSOR_REGISTRY_REFRESH.
+Without a refresh interval they are reloaded on every call, so each run and CDC batch sees the current registry
+@param db *sql.DB Database connection to Postgres database
+@return Set of registered sensor ids (nil, if the validation is disabled)
+*/
+func currentSensorRegistry(db *sql.DB) map[int64]bool {
+
+	// Skip the validation without a sensor registry
+	if sensorTable == "" {
+		return nil
+	}
+
+	// Lock the registry, when surrounding function returns it is unlocked
+	sensorRegistryMutex.Lock()
+	defer sensorRegistryMutex.Unlock()
+
+	// Reload the registry, if it is missing or outdated
+	if sensorRegistry.ids == nil || time.Since(sensorRegistry.loaded) >= sensorRegistryRefresh {
+		sensorRegistry = SensorRegistry{ids: loadSensorRegistry(db), loaded: time.Now()}
+	}
+	return sensorRegistry.ids
+}
+
+/*
+Function to write a rejected measurement into the dead letter table
+@param measurement Rejected measurement
+@param reason Reason for rejecting the measurement
+@param executor Database connection or transaction to write on
+*/
+func writeDeadLetter(measurement Measurement, reason string, executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}) {
+
+	// Execute insert statement with the attribute data of the measurement and the reason
+	_, err := executor.Exec("INSERT INTO dead_letter (id, created_on, event_stream, humidity, processed_on, sensor_id, temperature, reason) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		measurement.id,
+		measurement.created_on,
+		measurement.event_stream,
+		measurement.humidity,
+		measurement.processed_on,
+		measurement.sensor_id,
+		measurement.temperature,
+		reason)
+
+	// Check on error with handler
+	checkError(err)
+}
@@ -0,0 +1,114 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the sensor registry
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package for automated tests
+	"testing"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+/*
+Function to validate the sensor ids against the registry stub_sensors of the stub driver, the configuration and the loaded
+registry are restored when the test finished
+@param t Test state
+@param refresh Refresh interval of the registry
+@param ids Registered sensor ids
+*/
+func useStubSensorRegistry(t *testing.T, refresh time.Duration, ids ...string) {
+	t.Helper()
+	savedTable, savedRefresh, savedPolicy, savedRegistry, savedIds := sensorTable, sensorRegistryRefresh, unknownSensorPolicy, sensorRegistry, stubSensorIds
+	t.Cleanup(func() {
+		sensorTable, sensorRegistryRefresh, unknownSensorPolicy, sensorRegistry, stubSensorIds = savedTable, savedRefresh, savedPolicy, savedRegistry, savedIds
+	})
+	sensorTable, sensorRegistryRefresh, unknownSensorPolicy, sensorRegistry, stubSensorIds = "stub_sensors", refresh, UnknownSensorSkip, SensorRegistry{}, ids
+}
+
+/*
+Test that table names of the sensor registry are quoted, so they can't inject SQL
+@param t Test state
+*/
+func TestQuoteTableName(t *testing.T) {
+	cases := []struct {
+		table string
+		want  string
+	}{
+		{"sensors", `"sensors"`},
+		{"registry.sensors", `"registry"."sensors"`},
+		{"Sensors", `"Sensors"`},
+		{`sensors; DROP TABLE materialized_view; --`, `"sensors; DROP TABLE materialized_view; --"`},
+		{`sensors" WHERE false; --`, `"sensors"" WHERE false; --"`},
+	}
+	for _, testCase := range cases {
+		if got := quoteTableName(testCase.table); got != testCase.want {
+			t.Errorf("quoteTableName(%q) = %s, want %s", testCase.table, got, testCase.want)
+		}
+	}
+}
+
+/*
+Test that the loaded sensor registry is reused until the refresh interval elapsed, and reloaded for every call without one
+@param t Test state
+*/
+func TestCurrentSensorRegistryRefresh(t *testing.T) {
+	db := openStubDatabase(t)
+	useStubSensorRegistry(t, time.Hour, "7")
+
+	var registry map[int64]bool
+	captureStdout(t, func() { registry = currentSensorRegistry(db) })
+	if len(registry) != 1 || !registry[7] {
+		t.Fatalf("registry %v, want sensor 7", registry)
+	}
+
+	// A sensor added to the table isn't seen before the interval elapsed
+	stubSensorIds = []string{"7", "8"}
+	if registry = currentSensorRegistry(db); registry[8] {
+		t.Error("registry reloaded before the refresh interval elapsed")
+	}
+	sensorRegistry.loaded = sensorRegistry.loaded.Add(-time.Hour)
+	captureStdout(t, func() { registry = currentSensorRegistry(db) })
+	if !registry[8] {
+		t.Error("registry not reloaded after the refresh interval")
+	}
+
+	// Without an interval every call reloads the registry
+	sensorRegistryRefresh = 0
+	stubSensorIds = []string{"9"}
+	captureStdout(t, func() { registry = currentSensorRegistry(db) })
+	if len(registry) != 1 || !registry[9] {
+		t.Errorf("registry %v, want it reloaded with sensor 9", registry)
+	}
+
+	// Without a sensor table nothing is validated
+	sensorTable = ""
+	if registry = currentSensorRegistry(db); registry != nil {
+		t.Errorf("registry %v without SENSOR_TABLE, want nil", registry)
+	}
+}
+
+/*
+Test that a run validates the sensor ids against the current registry and skips the measurements of unknown sensors
+@param t Test state
+*/
+func TestMaterializeSkipsUnknownSensors(t *testing.T) {
+	output := useCsvSourceAndOutput(t, csvSourceHeader+
+		"1,7,4,45,room-1,2024-01-05T10:00:00Z,2024-01-05T10:00:01Z\n"+
+		"2,8,5,46,room-1,2024-01-05T10:01:00Z,2024-01-05T10:01:01Z\n")
+	useStubSensorRegistry(t, 0, "7")
+
+	var summary *RunSummary
+	captureStdout(t, func() { summary = materialize(context.Background(), openStubDatabase(t), "test", nil) })
+	if summary.unknownSensorMeasurements != 1 || len(summary.unknownSensorIds) != 1 || summary.unknownSensorIds[0] != 8 || summary.measurements != 1 {
+		t.Errorf("%d measurements of unknown sensors %v and %d materialized, want 1 of sensor 8 and 1", summary.unknownSensorMeasurements, summary.unknownSensorIds, summary.measurements)
+	}
+	if records := readCsvOutput(t, output); len(records) != 1 || records[0][0] != "1" {
+		t.Errorf("records %v, want only measurement 1 of the registered sensor", records)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+/*
+@author 1Zero64
+Reading the event store from a streaming replica while writing to the primary
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Database handle of the read replica (nil, if the event store is read from the primary)
+var replicaDb *sql.DB
+
+// Interval between two replica lag checks while waiting for the replica to catch up
+const replicaLagPollInterval = time.Second
+
+/*
+Function to get the database handle to read the event store from
+@param db *sql.DB Database connection to the primary Postgres database
+@return Handle of the read replica, if configured, otherwise the primary handle
+*/
+func readHandle(db *sql.DB) *sql.DB {
+	if replicaDb != nil {
+		return replicaDb
+	}
+	return db
+}
+
+/*
+Function to get the newest event id of the event store
+@param db *sql.DB Database connection to Postgres database
+@return Maximum id (0 for an empty event store)
+*/
+func maxEventId(db *sql.DB) int64 {
+	var id int64
+	err := db.QueryRow("SELECT COALESCE(MAX(id), 0) FROM event_store").Scan(&id)
+	// Check on error with handler
+	checkError(err)
+	return id
+}
+
+/*
+Function to compare the newest event id of the replica with the primary before reading.
+Waits up to REPLICA_LAG_WAIT for the replica to catch up and warns, if it is still behind by more than REPLICA_MAX_LAG events
+@param db *sql.DB Database connection to the primary Postgres database
+*/
+func checkReplicaLag(db *sql.DB) {
+
+	// Nothing to check without a replica
+	if replicaDb == nil {
+		return
+	}
+
+	// Poll the lag until it is tolerable or the waiting time is exceeded
+	deadline := time.Now().Add(replicaLagWait)
+	for {
+		lag := maxEventId(db) - maxEventId(replicaDb)
+		if lag <= replicaMaxLag {
+			return
+		}
+		if !time.Now().Before(deadline) {
+			fmt.Printf("Warning: the read replica is %d events behind the primary (REPLICA_MAX_LAG %d), reading anyway\n", lag, replicaMaxLag)
+			return
+		}
+		fmt.Printf("Read replica is %d events behind the primary, waiting...\n", lag)
+		time.Sleep(replicaLagPollInterval)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+/*
+@author 1Zero64
+Robust statistics of the benchmark durations, which single slow iterations don't dominate
+*/
+
+// Importing packages
+import (
+	// Package for math functions
+	"math"
+)
+
+// Scale factor of the median absolute deviation to estimate the standard deviation of normally distributed values
+const madScale = 1.4826
+
+// Fraction of the values trimmed from each end for the trimmed mean
+const trimFraction = 0.10
+
+// Minimum number of values for a meaningful trimmed mean, the smallest number, of which 10% trims a value at each end
+const minTrimmedMeanValues = 10
+
+/*
+Function to calculate a quantile of sorted values with linear interpolation between the closest ranks
+@param sorted Ascending sorted values
+@param q Quantile between 0 and 1
+@return Quantile (0 for no values)
+*/
+func quantile(sorted []float64, q float64) float64 {
+
+	// Return zero for no values
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	// Interpolate between the values around the fractional rank
+	rank := q * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
+/*
+Function to calculate the interquartile range of sorted values
+@param sorted Ascending sorted values
+@return Difference between the third and the first quartile
+*/
+func interquartileRange(sorted []float64) float64 {
+	return quantile(sorted, 0.75) - quantile(sorted, 0.25)
+}
+
+/*
+Function to calculate the median absolute deviation of values, scaled to be comparable with the standard deviation
+@param values Values to calculate the deviation for
+@return Scaled median of the absolute deviations from the median (0 for no values)
+*/
+func medianAbsoluteDeviation(values []float64) float64 {
+
+	// Collect absolute deviations from the median
+	center := median(values)
+	deviations := make([]float64, 0, len(values))
+	for _, value := range values {
+		deviations = append(deviations, math.Abs(value-center))
+	}
+	return madScale * median(deviations)
+}
+
+/*
+Function to calculate the mean of sorted values without the lowest and highest 10%
+@param sorted Ascending sorted values
+@return Trimmed mean and false for less than ten values, where nothing would be trimmed and it would be the plain mean
+*/
+func trimmedMean(sorted []float64) (float64, bool) {
+
+	// Refuse samples too small to trim
+	if len(sorted) < minTrimmedMeanValues {
+		return 0, false
+	}
+
+	// Drop the same number of values at both ends and average the rest
+	trimmed := int(float64(len(sorted)) * trimFraction)
+	if trimmed == 0 {
+		return 0, false
+	}
+	return mean(sorted[trimmed : len(sorted)-trimmed]), true
+}
@@ -0,0 +1,78 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the robust statistics against datasets with known values
+*/
+
+// Importing packages
+import (
+	// Package for math functions
+	"math"
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Function to check a calculated statistic against its known value
+@param t Test state
+@param name Name of the statistic
+@param got Calculated value
+@param want Known value
+*/
+func expectClose(t *testing.T, name string, got float64, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+/*
+Test the interquartile range with linear interpolation between the closest ranks (R's default type 7)
+@param t Test state
+*/
+func TestInterquartileRange(t *testing.T) {
+	expectClose(t, "IQR(1..4)", interquartileRange([]float64{1, 2, 3, 4}), 1.5)
+	expectClose(t, "IQR(1..9)", interquartileRange([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9}), 4)
+	expectClose(t, "IQR(single)", interquartileRange([]float64{5}), 0)
+	expectClose(t, "IQR(none)", interquartileRange(nil), 0)
+}
+
+/*
+Test the scaled median absolute deviation, which a single outlier doesn't change
+@param t Test state
+*/
+func TestMedianAbsoluteDeviation(t *testing.T) {
+	expectClose(t, "MAD(1,1,2,2,4,6,9)", medianAbsoluteDeviation([]float64{1, 1, 2, 2, 4, 6, 9}), 1.4826)
+	expectClose(t, "MAD with outlier", medianAbsoluteDeviation([]float64{1, 1, 2, 2, 4, 6, 900}), 1.4826)
+	expectClose(t, "MAD(constant)", medianAbsoluteDeviation([]float64{3, 3, 3}), 0)
+}
+
+/*
+Test the 10% trimmed mean and that it's n/a, where nothing would be trimmed
+@param t Test state
+*/
+func TestTrimmedMean(t *testing.T) {
+
+	// Ten values with an outlier: one value is trimmed at each end
+	got, valid := trimmedMean([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 100})
+	if !valid {
+		t.Fatal("trimmed mean of ten values not valid")
+	}
+	expectClose(t, "trimmed mean of 1..9,100", got, 5.5)
+
+	// Twenty values: two values are trimmed at each end
+	values := make([]float64, 0, 20)
+	for value := 1.0; value <= 19; value++ {
+		values = append(values, value)
+	}
+	got, _ = trimmedMean(append(values, 1000))
+	expectClose(t, "trimmed mean of 1..19,1000", got, 10.5)
+
+	// Five to nine values would trim nothing and give the plain mean
+	for n := 1; n < 10; n++ {
+		if _, valid := trimmedMean(values[:n]); valid {
+			t.Errorf("trimmed mean of %d values valid, want n/a", n)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+/*
+@author 1Zero64
+Unique identifiers for materialize and benchmark runs
+*/
+
+// Importing packages
+import (
+	// Package for cryptographically secure random numbers
+	"crypto/rand"
+	// Package for formatted printing
+	"fmt"
+)
+
+/*
+Function to generate a random (version 4) UUID identifying a run, to correlate its output across logs, metrics and files
+@return UUID in its canonical string representation
+*/
+func newRunId() string {
+
+	// Read 16 random bytes and check on error with handler
+	uuid := make([]byte, 16)
+	_, err := rand.Read(uuid)
+	checkError(err)
+
+	// Set version 4 and RFC 4122 variant bits
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+
+	// Return UUID formatted as hex groups
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+}
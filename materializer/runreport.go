@@ -0,0 +1,302 @@
+package main
+
+/*
+@author 1Zero64
+Structured JSON report of a materialize run written to disk
+*/
+
+// Importing packages
+import (
+	// Package for encoding JSON
+	"encoding/json"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for manipulating file paths
+	"path/filepath"
+	// Package for string manipulation
+	"strings"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Enumerations for how run reports are written
+const (
+	RunReportPerRun = "per-run"
+	RunReportAppend = "append"
+)
+
+// Object structure for the structured report of a materialize run
+type RunReport struct {
+	// Unique identifier of the run
+	RunId string `json:"run_id"`
+	// Time point on when the run was started
+	Timestamp time.Time `json:"timestamp"`
+	// Version and build information of the binary
+	Build BuildInfo `json:"build"`
+	// Number of transformed measurements
+	Measurements int `json:"measurements"`
+	// Reason, why the run was stopped early (omitted for a complete run)
+	Stopped string `json:"stopped,omitempty"`
+	// Durations of the run and its phases
+	Phases RunReportPhases `json:"phases"`
+	// Number of measurements per danger level
+	DangerLevels map[string]int `json:"danger_levels"`
+	// Breakdown per event stream
+	Streams []RunReportStream `json:"streams"`
+	// Distribution of the latencies
+	Latency RunReportLatency `json:"latency"`
+	// Latency SLA in the latency unit (0, if disabled)
+	LatencySla float64 `json:"latency_sla"`
+	// Number of measurements exceeding the latency SLA
+	SlaBreaches int `json:"sla_breaches"`
+	// Measurements processed later than BACKFILL_THRESHOLD after their creation (omitted, if disabled)
+	Backfill *RunReportBackfill `json:"backfill,omitempty"`
+	// Difference of the pg_stat counters of the run (omitted, if PG_STATS is disabled)
+	PgStats *PgStats `json:"pg_stats,omitempty"`
+	// Sizes of the involved tables before and after the run in bytes (omitted, if TABLE_SIZES is disabled)
+	TableSizes []TableSizeChange `json:"table_sizes,omitempty"`
+	// Counts of rejected, corrected and retried measurements
+	Errors RunReportErrors `json:"errors"`
+	// Number of measurements not written into the output
+	Skipped int `json:"skipped"`
+	// Skipped measurements per reason, the most frequent first
+	SkippedReasons []SkipCount `json:"skipped_reasons"`
+	// Flag whether the skipped measurements exceed SKIP_THRESHOLD
+	SkipThresholdExceeded bool `json:"skip_threshold_exceeded"`
+	// Read, transform and write time per chunk of measurements (omitted, if VERBOSE_CHUNKS is disabled)
+	Chunks []ChunkTiming `json:"chunks,omitempty"`
+}
+
+// Object structure for the backfilled measurements of a run
+type RunReportBackfill struct {
+	// Minimum gap between created_on and processed_on
+	Threshold string `json:"threshold"`
+	// Number of backfilled measurements
+	Measurements int `json:"measurements"`
+	// Oldest created_on of the backfilled measurements (omitted without any)
+	Oldest *time.Time `json:"oldest,omitempty"`
+	// Newest created_on of the backfilled measurements (omitted without any)
+	Newest *time.Time `json:"newest,omitempty"`
+	// Spread between the oldest and newest created_on in seconds
+	Spread float64 `json:"spread"`
+}
+
+// Object structure for the durations of a run and its phases in seconds
+type RunReportPhases struct {
+	// Duration of the whole run
+	Total float64 `json:"total"`
+	// Duration of the clean phase
+	Clean float64 `json:"clean"`
+	// Duration of the read phase
+	Read float64 `json:"read"`
+	// Duration of the transform and write phase
+	Write float64 `json:"write"`
+	// Duration of swapping the staging table into place
+	Swap float64 `json:"swap"`
+	// Time the pipeline reader waited for the writer
+	ReaderIdle float64 `json:"reader_idle"`
+	// Time the writer waited for the pipeline reader
+	WriterIdle float64 `json:"writer_idle"`
+}
+
+// Object structure for the breakdown of a single event stream
+type RunReportStream struct {
+	// Name of the event stream
+	Name string `json:"name"`
+	// Threshold profile of the event stream
+	Profile string `json:"profile"`
+	// Number of transformed measurements
+	Measurements int `json:"measurements"`
+	// Average latency in the latency unit
+	AvgLatency float64 `json:"avg_latency"`
+	// Distribution of the latencies of the stream
+	Latency RunReportLatency `json:"latency"`
+}
+
+// Object structure for the latency distribution of a run
+type RunReportLatency struct {
+	// Unit of the latencies
+	Unit string `json:"unit"`
+	// Number of valid latencies
+	Count int `json:"count"`
+	// Number of excluded negative latencies
+	Negative int `json:"negative"`
+	// Number of excluded NaN or infinite latencies
+	NonFinite int `json:"non_finite"`
+	// Minimum latency
+	Min float64 `json:"min"`
+	// Mean latency
+	Mean float64 `json:"mean"`
+	// Median latency
+	P50 float64 `json:"p50"`
+	// 95th percentile of the latency
+	P95 float64 `json:"p95"`
+	// 99th percentile of the latency
+	P99 float64 `json:"p99"`
+	// Maximum latency
+	Max float64 `json:"max"`
+}
+
+// Object structure for the error counts of a run
+type RunReportErrors struct {
+	// Number of collapsed duplicates (-1, if not counted)
+	Duplicates int `json:"duplicates"`
+	// Number of measurements created in the future beyond the skew tolerance
+	FutureMeasurements int `json:"future_measurements"`
+	// Number of NULL readings transformed as 0
+	NullZeroed int `json:"null_zeroed"`
+	// Number of measurements skipped for a NULL reading
+	NullSkipped int `json:"null_skipped"`
+	// Number of measurements dead-lettered for a NaN or infinite reading
+	NonFiniteDeadLettered int `json:"non_finite_dead_lettered"`
+	// Number of measurements with clamped NaN or infinite readings
+	NonFiniteClamped int `json:"non_finite_clamped"`
+	// Number of writes retried after a deadlock
+	DeadlockRetried int64 `json:"deadlock_retried"`
+	// Number of measurements of unknown sensors
+	UnknownSensorMeasurements int `json:"unknown_sensor_measurements"`
+}
+
+/*
+Function to build the report of a finished run from its summary
+@param summary Summary of the finished run
+@return Run report
+*/
+func newRunReport(summary *RunSummary) RunReport {
+
+	// Take over identity, phases and counts
+	report := RunReport{
+		RunId:        summary.runId,
+		Timestamp:    summary.start,
+		Build:        buildInfo(),
+		Measurements: summary.measurements,
+		Phases: RunReportPhases{
+			Total:      summary.duration.Seconds(),
+			Clean:      summary.cleanDuration.Seconds(),
+			Read:       summary.readDuration.Seconds(),
+			Write:      summary.writeDuration.Seconds(),
+			Swap:       summary.swapDuration.Seconds(),
+			ReaderIdle: summary.readerIdle.Seconds(),
+			WriterIdle: summary.writerIdle.Seconds(),
+		},
+		DangerLevels: summary.dangerLevels,
+		Streams:      make([]RunReportStream, 0, len(summary.streams)),
+		LatencySla:   latencySla,
+		Chunks:       summary.chunks,
+		SlaBreaches:  summary.slaBreaches,
+		PgStats:      summary.pgStats,
+		TableSizes:   summary.tableSizes,
+		Errors: RunReportErrors{
+			Duplicates:                summary.duplicates,
+			FutureMeasurements:        summary.futureMeasurements,
+			NullZeroed:                summary.nullZeroed,
+			NullSkipped:               summary.nullSkipped,
+			NonFiniteDeadLettered:     summary.nonFiniteDeadLettered,
+			NonFiniteClamped:          summary.nonFiniteClamped,
+			DeadlockRetried:           summary.deadlockRetried.Load(),
+			UnknownSensorMeasurements: summary.unknownSensorMeasurements,
+		},
+	}
+	if summary.stopped != nil {
+		report.Stopped = summary.stopped.Error()
+	}
+
+	// Add the backfilled measurements, if the detector is enabled
+	if backfillThreshold > 0 {
+		backfill := summary.backfill
+		report.Backfill = &RunReportBackfill{Threshold: backfillThreshold.String(), Measurements: backfill.measurements}
+		if backfill.measurements > 0 {
+			report.Backfill.Oldest, report.Backfill.Newest = &backfill.oldest, &backfill.newest
+			report.Backfill.Spread = backfill.newest.Sub(backfill.oldest).Seconds()
+		}
+	}
+
+	// Add the skipped measurements
+	report.Skipped, _ = summary.skipTotals()
+	report.SkippedReasons = summary.skipCounts()
+	report.SkipThresholdExceeded = summary.skipThresholdExceeded()
+
+	// Add the event streams in a stable order
+	for _, name := range summary.streamNames() {
+		stream := summary.streams[name]
+		report.Streams = append(report.Streams, RunReportStream{Name: name, Profile: stream.profile, Measurements: stream.measurements,
+			AvgLatency: stream.latencySum / float64(stream.measurements), Latency: newRunReportLatency(stream.latency)})
+	}
+
+	// Add the latency distribution of all event streams
+	report.Latency = newRunReportLatency(summary.latency)
+	return report
+}
+
+/*
+Function to build the latency distribution of the report from the streaming statistics
+@param accumulator Latency statistics of the run or an event stream
+@return Latency distribution
+*/
+func newRunReportLatency(accumulator *LatencyAccumulator) RunReportLatency {
+	minimum, maximum := accumulator.extremes()
+	percentiles := accumulator.quantiles(0.5, 0.95, 0.99)
+	return RunReportLatency{
+		Unit:      latencyUnit.Name,
+		Count:     accumulator.count,
+		Negative:  accumulator.negative,
+		NonFinite: accumulator.invalid,
+		Min:       minimum,
+		Mean:      accumulator.mean(),
+		P50:       percentiles[0],
+		P95:       percentiles[1],
+		P99:       percentiles[2],
+		Max:       maximum,
+	}
+}
+
+/*
+Function to write the report of a finished run to RUN_REPORT, either into its own file per run or appended to a JSON array
+@param summary Summary of the finished run
+*/
+func writeRunReport(summary *RunSummary) {
+
+	// Build the document of the report or of all reports of the file
+	report := newRunReport(summary)
+	var document interface{} = report
+	path := runReportPath
+	switch runReportMode {
+	case RunReportPerRun:
+		// Insert the run id before the extension, e.g. report.json becomes report-<run id>.json
+		extension := filepath.Ext(path)
+		path = strings.TrimSuffix(path, extension) + "-" + summary.runId + extension
+	case RunReportAppend:
+		// Read the reports of the earlier runs, a missing file starts a new array
+		reports := make([]json.RawMessage, 0)
+		if content, err := os.ReadFile(path); err == nil {
+			if err := json.Unmarshal(content, &reports); err != nil {
+				checkError(fmt.Errorf("invalid run report array %s: %w", path, err))
+			}
+		} else if !os.IsNotExist(err) {
+			checkError(err)
+		}
+		encoded, err := json.Marshal(report)
+		checkError(err)
+		document = append(reports, encoded)
+	}
+
+	// Encode the document
+	content, err := json.MarshalIndent(document, "", "  ")
+	checkError(err)
+
+	// Write atomically into a temporary file next to the report and rename it, so readers never see a partial report
+	temporary, err := os.CreateTemp(filepath.Dir(path), ".run-report-*.tmp")
+	checkError(err)
+	_, err = temporary.Write(append(content, '\n'))
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(temporary.Name())
+		checkError(err)
+	}
+	checkError(os.Rename(temporary.Name(), path))
+	fmt.Printf("Run report written to %s\n", path)
+}
@@ -0,0 +1,122 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the sampled fraction of SAMPLE_RATE and the table sample
+*/
+
+// Importing packages
+import (
+	// Package for formatted printing
+	"fmt"
+	// Package for math functions
+	"math"
+	// Package for string manipulation
+	"strings"
+	// Package for automated tests
+	"testing"
+)
+
+// Number of fixture measurements to sample from
+const sampleFixtureRows = 2000
+
+// Fraction of the fixture measurements to sample
+const sampleFixtureFraction = 0.25
+
+/*
+Function to check that a sample size is within four standard deviations of the binomial distribution around the expected size
+@param t Test state
+@param name Name of the sample for the message
+@param kept Number of sampled measurements
+*/
+func expectSampleSize(t *testing.T, name string, kept int) {
+	t.Helper()
+	expected := sampleFixtureFraction * sampleFixtureRows
+	tolerance := 4 * math.Sqrt(sampleFixtureRows*sampleFixtureFraction*(1-sampleFixtureFraction))
+	if math.Abs(float64(kept)-expected) > tolerance {
+		t.Errorf("%s kept %d of %d measurements, want %.0f±%.0f", name, kept, sampleFixtureRows, expected, tolerance)
+	}
+}
+
+/*
+Function to get the ids of sampled measurements
+@param measurements Sampled measurements
+@return Ids in the order of the measurements
+*/
+func sampledIds(measurements []Measurement) []int64 {
+	ids := make([]int64, len(measurements))
+	for i, measurement := range measurements {
+		ids[i] = measurement.id
+	}
+	return ids
+}
+
+/*
+Test that SAMPLE_RATE keeps the fraction of the measurements of a CSV source and that the same seed keeps the same ones
+@param t Test state
+*/
+func TestSampleRateKeepsFraction(t *testing.T) {
+	var source strings.Builder
+	source.WriteString(csvSourceHeader)
+	for id := 1; id <= sampleFixtureRows; id++ {
+		fmt.Fprintf(&source, "%d,7,4,45,room-1,2024-01-05T10:00:00Z,2024-01-05T10:00:01Z\n", id)
+	}
+	useCsvSourceAndOutput(t, source.String())
+	savedSeed := sampleSeed
+	t.Cleanup(func() { sampleSeed = savedSeed })
+	sampleRate, sampleSeed = sampleFixtureFraction, 42
+
+	first := sampledIds(readCsvMeasurements(sourceCsvPath))
+	expectSampleSize(t, "SAMPLE_RATE", len(first))
+	if second := sampledIds(readCsvMeasurements(sourceCsvPath)); fmt.Sprint(second) != fmt.Sprint(first) {
+		t.Errorf("second sample with the same seed kept %d other measurements than the first", len(second))
+	}
+
+	// Another seed draws another sample of about the same size
+	sampleSeed = 43
+	other := sampledIds(readCsvMeasurements(sourceCsvPath))
+	expectSampleSize(t, "SAMPLE_RATE with another seed", len(other))
+	if fmt.Sprint(other) == fmt.Sprint(first) {
+		t.Error("another seed kept the same measurements")
+	}
+}
+
+/*
+Test that the table sample with every method keeps the fraction of the event store and the same rows with the same seed
+@param t Test state
+*/
+func TestTableSampleKeepsFraction(t *testing.T) {
+	db := openTestDatabase(t)
+	useDatabaseRun(t)
+	savedFraction, savedRows, savedMethod, savedSeed, savedSeedSet := sampleFraction, sampleRows, sampleMethod, sampleSeed, sampleSeedSet
+	t.Cleanup(func() {
+		sampleFraction, sampleRows, sampleMethod, sampleSeed, sampleSeedSet = savedFraction, savedRows, savedMethod, savedSeed, savedSeedSet
+	})
+	sampleFraction, sampleRows, sampleSeed, sampleSeedSet = sampleFixtureFraction, 0, 42, true
+
+	values := make([]string, sampleFixtureRows)
+	for id := range values {
+		values[id] = fmt.Sprintf("(%d, '2024-01-05 10:00:00', 'kafka', 45, '2024-01-05 10:00:01', 7, 4)", id+1)
+	}
+	createTestEventStore(t, db, strings.Join(values, ", "))
+	if _, err := db.Exec("ANALYZE event_store"); err != nil {
+		t.Fatal(err)
+	}
+
+	// SYSTEM samples whole pages and is too coarse for the tolerance on this small table
+	for _, method := range []string{SampleBernoulli, SampleRandom} {
+		sampleMethod = method
+		var samples [2]string
+		for i := range samples {
+			captureStdout(t, func() { materializeTableSample(db) })
+			var kept int
+			if err := db.QueryRow("SELECT COUNT(*), COALESCE(string_agg(id::text, ',' ORDER BY id), '') FROM "+sampleTable).Scan(&kept, &samples[i]); err != nil {
+				t.Fatal(err)
+			}
+			expectSampleSize(t, method, kept)
+		}
+		if samples[0] != samples[1] {
+			t.Errorf("%s kept other rows in the second run with the same seed", method)
+		}
+	}
+}
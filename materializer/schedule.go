@@ -0,0 +1,362 @@
+package main
+
+/*
+@author 1Zero64
+Scheduler running full and incremental materialize jobs periodically by cron expressions instead of the interactive menu
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for encoding JSON
+	"encoding/json"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for receiving operating system signals
+	"os/signal"
+	// Package for converting strings to numbers
+	"strconv"
+	// Package for string manipulation
+	"strings"
+	// Package for synchronizing goroutines
+	"sync"
+	// Package for system calls like the termination signal
+	"syscall"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Enumerations for the modes of a scheduled job
+const (
+	JobFull        = "full"
+	JobIncremental = "incremental"
+)
+
+// Object structure for the allowed values of a field of a cron expression as bit set
+type CronField struct {
+	// Bit per allowed value
+	values uint64
+	// Flag whether the field is the wildcard *
+	any bool
+}
+
+// Object structure for a parsed cron expression with the fields minute, hour, day of month, month and day of week
+type CronSchedule struct {
+	minute, hour, dayOfMonth, month, dayOfWeek CronField
+}
+
+// Object structure for a job of the schedule file
+type ScheduledJob struct {
+	// Unique name of the job
+	Name string `json:"name"`
+	// Cron expression of the job in local time, e.g. 0 2 * * * for every night at 02:00
+	Cron string `json:"cron"`
+	// Mode of the job, full rebuild or incremental catch-up of measurements processed since the last run
+	Mode string `json:"mode"`
+	// Event stream to materialize only (empty for all)
+	EventStream string `json:"event_stream"`
+	// Parsed cron expression
+	schedule CronSchedule
+}
+
+// Object structure for the status of the last run of a job
+type JobStatus struct {
+	// Time point on when the last run started
+	started time.Time
+	// Time point on when the last run finished
+	finished time.Time
+	// Result of the last run: succeeded, failed or skipped
+	status string
+	// Number of materialized measurements or the error of the last run
+	detail string
+}
+
+/*
+Function to parse a field of a cron expression with values, ranges, steps and lists, e.g. 1-5, 0-59/15 or 0,30
+@param field Field of the cron expression
+@param minimum Smallest allowed value
+@param maximum Largest allowed value
+@return Parsed field
+*/
+func parseCronField(field string, minimum int, maximum int) (CronField, error) {
+	parsed := CronField{any: field == "*"}
+	for _, part := range strings.Split(field, ",") {
+		// Split an optional step
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return parsed, fmt.Errorf("invalid step %q", part)
+			}
+		}
+
+		// Read the range of the wildcard, a single value or a range
+		low, high := minimum, maximum
+		if rangePart != "*" {
+			lowPart, highPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = strconv.Atoi(lowPart); err != nil {
+				return parsed, fmt.Errorf("invalid value %q", part)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(highPart); err != nil {
+					return parsed, fmt.Errorf("invalid value %q", part)
+				}
+			} else if hasStep {
+				high = maximum
+			}
+		}
+		if low < minimum || high > maximum || low > high {
+			return parsed, fmt.Errorf("value %q out of range %d-%d", part, minimum, maximum)
+		}
+
+		// Set the bits of the allowed values
+		for value := low; value <= high; value += step {
+			parsed.values |= 1 << uint(value)
+		}
+	}
+	return parsed, nil
+}
+
+/*
+Function to parse a cron expression with the five fields minute, hour, day of month, month and day of week (0 or 7 for Sunday)
+@param expression Cron expression
+@return Parsed cron expression
+*/
+func parseCronSchedule(expression string) (CronSchedule, error) {
+
+	// Require exactly five fields
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return CronSchedule{}, fmt.Errorf("invalid cron expression %q, expected 5 fields", expression)
+	}
+
+	// Parse each field with its range
+	var schedule CronSchedule
+	var err error
+	ranges := [][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	targets := []*CronField{&schedule.minute, &schedule.hour, &schedule.dayOfMonth, &schedule.month, &schedule.dayOfWeek}
+	for i, field := range fields {
+		if *targets[i], err = parseCronField(field, ranges[i][0], ranges[i][1]); err != nil {
+			return schedule, fmt.Errorf("invalid cron expression %q: %w", expression, err)
+		}
+	}
+
+	// Treat 7 as Sunday like 0
+	if schedule.dayOfWeek.values&(1<<7) != 0 {
+		schedule.dayOfWeek.values |= 1
+	}
+	return schedule, nil
+}
+
+/*
+Function to check whether a cron expression is due at a minute
+@param t Minute to check
+@return True, if all fields match. Restricted day of month and day of week match, if either one does like in cron
+*/
+func (schedule CronSchedule) matches(t time.Time) bool {
+	has := func(field CronField, value int) bool { return field.values&(1<<uint(value)) != 0 }
+	day := has(schedule.dayOfMonth, t.Day()) && has(schedule.dayOfWeek, int(t.Weekday()))
+	if !schedule.dayOfMonth.any && !schedule.dayOfWeek.any {
+		day = has(schedule.dayOfMonth, t.Day()) || has(schedule.dayOfWeek, int(t.Weekday()))
+	}
+	return day && has(schedule.minute, t.Minute()) && has(schedule.hour, t.Hour()) && has(schedule.month, int(t.Month()))
+}
+
+/*
+Function to read and validate the jobs of the schedule file, rejecting invalid cron expressions and modes
+@param path Path of the JSON schedule file
+@return Validated jobs
+*/
+func readSchedule(path string) []ScheduledJob {
+
+	// Decode the jobs
+	content, err := os.ReadFile(path)
+	checkError(err)
+	var jobs []ScheduledJob
+	if err := json.Unmarshal(content, &jobs); err != nil {
+		checkError(fmt.Errorf("invalid SCHEDULE_FILE %s: %w", path, err))
+	}
+	if len(jobs) == 0 {
+		checkError(fmt.Errorf("SCHEDULE_FILE %s contains no jobs", path))
+	}
+
+	// Validate names, modes and cron expressions
+	names := make(map[string]bool)
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Name == "" || names[job.Name] {
+			checkError(fmt.Errorf("job %d of SCHEDULE_FILE needs a unique name", i+1))
+		}
+		names[job.Name] = true
+		if job.Mode != JobFull && job.Mode != JobIncremental {
+			checkError(fmt.Errorf("invalid mode %q of job %s, expected %q or %q", job.Mode, job.Name, JobFull, JobIncremental))
+		}
+		if job.schedule, err = parseCronSchedule(job.Cron); err != nil {
+			checkError(fmt.Errorf("job %s: %w", job.Name, err))
+		}
+	}
+	return jobs
+}
+
+/*
+Function to record the run of a job in the audit table
+@param db *sql.DB Database connection to Postgres database
+@param job Job of the run
+@param status Status of the run
+*/
+func recordJobRun(db *sql.DB, job ScheduledJob, status JobStatus) {
+	_, err := db.Exec("INSERT INTO materializer_job_runs (job, mode, started_on, finished_on, status, detail) VALUES ($1, $2, $3, $4, $5, $6)",
+		job.Name, job.Mode, status.started, status.finished, status.status, status.detail)
+	// Only warn, the audit table must not stop the scheduler
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: couldn't record the run of job %s: %v\n", job.Name, err)
+	}
+}
+
+/*
+Function to run a job with its mode and event stream, recording a failure instead of terminating the scheduler
+@param db *sql.DB Database connection to Postgres database
+@param job Job to run
+@return Status of the run
+*/
+func runScheduledJob(db *sql.DB, job ScheduledJob) (status JobStatus) {
+
+	// Apply the mode and event stream of the job and restore the configuration afterwards
+	savedSinceLastRun, savedAppendOnly, savedStreamFilter := sinceLastRun, appendOnly, streamFilter
+	sinceLastRun = job.Mode == JobIncremental
+	appendOnly = job.Mode == JobIncremental
+	if job.EventStream != "" {
+		streamFilter = job.EventStream
+	}
+	status = JobStatus{started: time.Now(), status: "failed"}
+	defer func() {
+		sinceLastRun, appendOnly, streamFilter = savedSinceLastRun, savedAppendOnly, savedStreamFilter
+		if recovered := recover(); recovered != nil {
+			status.detail = fmt.Sprint(recovered)
+		}
+		status.finished = time.Now()
+	}()
+
+	// Run the materialize process
+	fmt.Printf("Starting job %s (%s)\n", job.Name, job.Mode)
+	summary := materializeView(context.Background(), db)
+	status.status = "succeeded"
+	if summary == nil {
+		status.detail = "event store unchanged"
+	} else {
+		skipped, _ := summary.skipTotals()
+		status.detail = fmt.Sprintf("%d measurements, %d skipped", summary.measurements, skipped)
+		for _, count := range summary.skipCounts() {
+			status.detail += fmt.Sprintf(", %s %d", count.Reason, count.Count)
+		}
+		if summary.skipThresholdExceeded() {
+			status.status = "skip threshold exceeded"
+		}
+	}
+	return status
+}
+
+/*
+Function to run the jobs of SCHEDULE_FILE at their due minutes until SIGTERM or an interrupt.
+Only one job runs at a time, a job due while another one is still running is skipped and logged
+@param db *sql.DB Database connection to Postgres database
+*/
+func runScheduler(db *sql.DB) {
+
+	// Create the audit table of the job runs
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS materializer_job_runs (
+		id BIGSERIAL PRIMARY KEY,
+		job VARCHAR(255),
+		mode VARCHAR(20),
+		started_on TIMESTAMP,
+		finished_on TIMESTAMP,
+		status TEXT,
+		detail TEXT
+	)`)
+	checkError(err)
+
+	// Widen the status of tables created by older versions, which is too short for "skip threshold exceeded"
+	_, err = db.Exec("ALTER TABLE materializer_job_runs ALTER COLUMN status TYPE TEXT")
+	checkError(err)
+
+	// Stop scheduling on SIGTERM or an interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	// Status of the last run per job and the running job, shared with the job goroutine
+	var mutex sync.Mutex
+	statuses := make(map[string]JobStatus)
+	running := ""
+	var jobs sync.WaitGroup
+
+	fmt.Printf("Scheduler started with %d jobs from %s (SIGTERM or interrupt to stop)\n", len(scheduledJobs), scheduleFile)
+	for _, job := range scheduledJobs {
+		fmt.Printf("  %-20s %-15s %-12s %s\n", job.Name, job.Cron, job.Mode, job.EventStream)
+	}
+
+	for {
+		// Wait for the start of the next minute
+		next := time.Now().Truncate(time.Minute).Add(time.Minute)
+		select {
+		case <-ctx.Done():
+			// Let the running job finish before exiting
+			mutex.Lock()
+			if running != "" {
+				fmt.Printf("Stopping, waiting for job %s to finish...\n", running)
+			}
+			mutex.Unlock()
+			jobs.Wait()
+
+			// Print the last run of each job
+			fmt.Println("Scheduler stopped, last runs:")
+			for _, job := range scheduledJobs {
+				if status, found := statuses[job.Name]; found {
+					fmt.Printf("  %-20s %-10s %s (%s)\n", job.Name, status.status, status.started.Format(time.RFC3339), status.detail)
+				} else {
+					fmt.Printf("  %-20s never run\n", job.Name)
+				}
+			}
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		// Start the due jobs in the order of the file, skipping them while another job runs
+		for _, job := range scheduledJobs {
+			if !job.schedule.matches(next) {
+				continue
+			}
+			mutex.Lock()
+			if running != "" {
+				fmt.Printf("Skipping job %s, job %s is still running\n", job.Name, running)
+				skipped := JobStatus{started: next, finished: next, status: "skipped", detail: "job " + running + " still running"}
+				statuses[job.Name] = skipped
+				mutex.Unlock()
+				recordJobRun(db, job, skipped)
+				continue
+			}
+			running = job.Name
+			mutex.Unlock()
+
+			// Run the job in the background, so the due minutes are still checked
+			jobs.Add(1)
+			go func(job ScheduledJob) {
+				defer jobs.Done()
+				status := runScheduledJob(db, job)
+				mutex.Lock()
+				statuses[job.Name] = status
+				running = ""
+				mutex.Unlock()
+				fmt.Printf("Job %s %s after %f seconds: %s\n", job.Name, status.status, status.finished.Sub(status.started).Seconds(), status.detail)
+				recordJobRun(db, job, status)
+			}(job)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+/*
+@author 1Zero64
+Schema bootstrap for the materialized view
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+)
+
+// Column definitions of the materialized view with the reading type of the build
+var materializedViewColumns = fmt.Sprintf(`
+		id BIGINT,
+		created_on TIMESTAMP,
+		danger VARCHAR(10),
+		event_stream VARCHAR(255),
+		humidity %[1]s,
+		latency REAL,
+		latency_us BIGINT,
+		processed_on TIMESTAMP,
+		sensor_id BIGINT,
+		temperature %[1]s,
+		heat_index %[1]s,
+		unknown_sensor BOOLEAN NOT NULL DEFAULT FALSE`, readingColumnType)
+
+/*
+Function to create the materialized view and add missing columns of newer materializer versions
+@param db *sql.DB Database connection to Postgres database
+*/
+func createSchema(db *sql.DB) {
+
+	// Create materialized view table, if it doesn't exist yet
+	var err error
+	if partitionedView {
+		// Partitioned table by monthly ranges of created_on, the partition key has to be part of the primary key
+		_, err = db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS materialized_view (%s,\n\t\tPRIMARY KEY (id, created_on)\n\t) PARTITION BY RANGE (created_on)", materializedViewColumns))
+	} else {
+		// Plain table
+		_, err = db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS materialized_view (%s,\n\t\tPRIMARY KEY (id)\n\t)", materializedViewColumns))
+	}
+	// Check on error with handler
+	checkError(err)
+
+	// Check if the existing table is actually partitioned, which might differ from the option for tables created before
+	var relkind string
+	err = db.QueryRow("SELECT relkind FROM pg_class WHERE oid = 'materialized_view'::regclass").Scan(&relkind)
+	checkError(err)
+	if partitionedView && relkind != "p" {
+		fmt.Println("Warning: PARTITIONED_VIEW is enabled, but materialized_view is an existing plain table. Continuing without partitions")
+	}
+	partitionedView = relkind == "p"
+
+	// Warn, if double precision readings are written into single precision columns of an existing table
+	var temperatureType string
+	err = db.QueryRow("SELECT data_type FROM information_schema.columns WHERE table_name = 'materialized_view' AND column_name = 'temperature'").Scan(&temperatureType)
+	checkError(err)
+	if readingBits == 64 && temperatureType == "real" {
+		fmt.Println("Warning: built with double precision readings, but materialized_view stores REAL. Alter the reading columns to DOUBLE PRECISION to keep the precision")
+	}
+
+	// Add heat index column to materialized views created by older versions
+	_, err = db.Exec("ALTER TABLE materialized_view ADD COLUMN IF NOT EXISTS heat_index " + readingColumnType)
+	// Check on error with handler
+	checkError(err)
+
+	// Add exact latency column to materialized views created by older versions, backfilled by -migrate-latency-us
+	_, err = db.Exec("ALTER TABLE materialized_view ADD COLUMN IF NOT EXISTS latency_us BIGINT")
+	// Check on error with handler
+	checkError(err)
+
+	// Add unknown sensor flag column to materialized views created by older versions
+	_, err = db.Exec("ALTER TABLE materialized_view ADD COLUMN IF NOT EXISTS unknown_sensor BOOLEAN NOT NULL DEFAULT FALSE")
+	// Check on error with handler
+	checkError(err)
+
+	// Create table of statistics persisted for later runs, like the per-row cost for the duration estimate
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS materializer_stats (
+		name VARCHAR(255) PRIMARY KEY,
+		value DOUBLE PRECISION,
+		updated_on TIMESTAMP DEFAULT NOW()
+	)`)
+	// Check on error with handler
+	checkError(err)
+
+	// Create aggregated view of hourly buckets per sensor, if the aggregation mode is enabled
+	if aggregateMode {
+		_, err = db.Exec(`CREATE TABLE IF NOT EXISTS ` + quoteTableName(aggregateTable) + ` (
+			sensor_id BIGINT,
+			bucket TIMESTAMP,
+			measurements BIGINT,
+			min_temperature ` + readingColumnType + `,
+			max_temperature ` + readingColumnType + `,
+			avg_temperature DOUBLE PRECISION,
+			min_humidity ` + readingColumnType + `,
+			max_humidity ` + readingColumnType + `,
+			avg_humidity DOUBLE PRECISION,
+			danger VARCHAR(10),
+			max_heat_index ` + readingColumnType + `,
+			avg_heat_index DOUBLE PRECISION,
+			PRIMARY KEY (sensor_id, bucket)
+		)`)
+		// Check on error with handler
+		checkError(err)
+
+		// Add heat index columns to aggregated views created by older versions
+		_, err = db.Exec("ALTER TABLE " + quoteTableName(aggregateTable) + " ADD COLUMN IF NOT EXISTS max_heat_index " + readingColumnType + ", ADD COLUMN IF NOT EXISTS avg_heat_index DOUBLE PRECISION")
+		// Check on error with handler
+		checkError(err)
+	}
+
+	// Create dead letter table for rejected measurements, if they should be dead-lettered
+	if (sensorTable != "" && unknownSensorPolicy == UnknownSensorDeadLetter) || nonFinitePolicy == NonFiniteDeadLetter {
+		createDeadLetterTable(db)
+	}
+}
+
+/*
+Function to create the dead letter table for rejected measurements, if it doesn't exist yet
+@param db *sql.DB Database connection to Postgres database
+*/
+func createDeadLetterTable(db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS dead_letter (
+		id BIGINT,
+		created_on TIMESTAMP,
+		event_stream VARCHAR(255),
+		humidity ` + readingColumnType + `,
+		processed_on TIMESTAMP,
+		sensor_id BIGINT,
+		temperature ` + readingColumnType + `,
+		reason TEXT,
+		rejected_on TIMESTAMP DEFAULT NOW()
+	)`)
+	// Check on error with handler
+	checkError(err)
+}
@@ -0,0 +1,112 @@
+package main
+
+/*
+@author 1Zero64
+Weighted danger scoring combining normalized temperature and humidity into a single risk score
+*/
+
+// Importing packages
+import (
+	// Package for formatted printing
+	"fmt"
+	// Package for converting strings to numbers
+	"strconv"
+	// Package for string manipulation
+	"strings"
+)
+
+// Enumerations for the scoring modes
+const (
+	ScoringThreshold = "threshold"
+	ScoringWeighted  = "weighted"
+)
+
+// Default score thresholds of the danger levels, a single reading at a threshold of its own scale scores the level
+var defaultScoreThresholds = [4]float64{1, 2, 3, 4}
+
+/*
+Function to normalize a reading on the scale of its thresholds, so a value at the threshold of the n-th danger level scores n.
+Values between two thresholds are interpolated linearly, values above the last threshold are extrapolated with the last step
+@param value Reading to normalize
+@param levels Thresholds of the reading for the levels Low, Medium, High and Critical
+@return Normalized reading (0 for values at or below 0)
+*/
+func normalizeReading(value reading, levels [4]float64) float64 {
+
+	// Shift value by the tolerance, so it scores like it is compared against the thresholds
+	shifted := float64(value) - tolerance
+
+	// Interpolate below the first threshold
+	if shifted <= levels[0] {
+		if shifted <= 0 || levels[0] <= 0 {
+			return 0
+		}
+		return shifted / levels[0]
+	}
+
+	// Interpolate between two thresholds
+	for i := 0; i < len(levels)-1; i++ {
+		if shifted <= levels[i+1] {
+			return float64(i+1) + (shifted-levels[i])/(levels[i+1]-levels[i])
+		}
+	}
+
+	// Extrapolate above the last threshold
+	last := len(levels) - 1
+	return float64(last+1) + (shifted-levels[last])/(levels[last]-levels[last-1])
+}
+
+/*
+Function to calculate the weighted risk score of a measurement
+@param thresholds Thresholds of the danger levels to normalize the readings with
+@param temperature Measured temperature in Grad Celsius
+@param humidity Measured humidity in percentage
+@return Weighted sum of the normalized temperature and humidity
+*/
+func riskScore(thresholds Thresholds, temperature reading, humidity reading) float64 {
+	return scoringWeights[0]*normalizeReading(temperature, thresholds.temperature) + scoringWeights[1]*normalizeReading(humidity, thresholds.humidity)
+}
+
+/*
+Function to classify the danger level of a measurement by its weighted risk score, so borderline temperature and humidity together escalate
+@param thresholds Thresholds of the danger levels
+@param temperature Measured temperature in Grad Celsius
+@param humidity Measured humidity in percentage
+@return Danger level of the measurement
+*/
+func classifyWeighted(thresholds Thresholds, temperature reading, humidity reading) string {
+
+	// Map the score to the highest level with an exceeded score threshold
+	score := riskScore(thresholds, temperature, humidity)
+	for i := len(scoreThresholds) - 1; i >= 0; i-- {
+		if score > scoreThresholds[i] {
+			return dangerLevels[i+1]
+		}
+	}
+	return No
+}
+
+/*
+Function to parse the comma-separated weights of temperature and humidity
+@param value Comma-separated weights (e.g. "1,1")
+@return Weights of temperature and humidity
+*/
+func parseScoringWeights(value string) [2]float64 {
+
+	// Split list and check number of weights
+	var weights [2]float64
+	parts := strings.Split(value, ",")
+	if len(parts) != len(weights) {
+		checkError(fmt.Errorf("invalid SCORING_WEIGHTS %q, expected the weights of temperature and humidity", value))
+	}
+
+	// Parse weights and check they aren't negative
+	for i, part := range parts {
+		weight, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || weight < 0 {
+			checkError(fmt.Errorf("invalid SCORING_WEIGHTS %q, expected weights >= 0", value))
+		}
+		weights[i] = weight
+	}
+	return weights
+}
@@ -0,0 +1,77 @@
+package main
+
+/*
+@author 1Zero64
+Materialization of a single sensor into a dedicated sub-view
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+/*
+Function to materialize only the measurements of a single sensor into the table materialized_view_sensor_<id>,
+which is created like the materialized view, if needed, and cleaned before. The main view isn't touched
+@param db *sql.DB Database connection to Postgres database
+@param sensorId Id of the sensor
+*/
+func materializeSensorView(db *sql.DB, sensorId int64) {
+
+	// Name of the sub-view of the sensor
+	table := fmt.Sprintf("materialized_view_sensor_%d", sensorId)
+
+	// Print information about starting the transformation process
+	fmt.Printf("Materializing sensor %d into %s...\n", sensorId, table)
+
+	// Lock the sub-view against concurrent instances
+	lock := acquireMaterializeLock(db, table)
+	defer lock.release()
+
+	// Save starting time point
+	start := time.Now()
+
+	// Create the sub-view like the materialized view, if it doesn't exist yet
+	_, err := db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (LIKE materialized_view INCLUDING ALL)", table))
+	// Check on error with handler
+	checkError(err)
+
+	// Record the latency unit on the sub-view
+	recordLatencyUnit(db, table)
+
+	// Clean the sub-view before rebuilding it
+	_, err = db.Exec("TRUNCATE TABLE " + table)
+	checkError(err)
+
+	// Read the measurements of the sensor
+	measurements := readMeasurements(db, "sensor_id = $1", sensorId)
+
+	// Transform and write every measurement into the sub-view
+	bar := newProgress(int64(len(measurements)))
+	for _, measurement := range measurements {
+		// Drop measurements with a NULL reading, if the NULL policy skips them
+		if measurement.skippedByNullPolicy() {
+			bar.Add(1)
+			continue
+		}
+		// Dead-letter measurements with a NaN or infinite reading, if the non-finite policy doesn't clamp them
+		if measurement.deadLetteredAsNonFinite() {
+			writeDeadLetter(measurement, "non-finite reading", db)
+			bar.Add(1)
+			continue
+		}
+		if err := writeTransformedMeasurement(transformMeasurement(measurement), table, db); err != nil {
+			handleRowError(measurement, err)
+		}
+		bar.Add(1)
+	}
+	bar.Finish()
+
+	// Print needed time for materializing
+	fmt.Printf("Materialized %d measurements of sensor %d into %s in %f seconds\n", len(measurements), sensorId, table, time.Since(start).Seconds())
+}
@@ -0,0 +1,44 @@
+package main
+
+/*
+@author 1Zero64
+Order of transformed measurements by danger severity, so the most urgent ones surface first for triage
+*/
+
+// Importing packages
+import (
+	// Package for formatted printing
+	"fmt"
+	// Package for sorting slices
+	"sort"
+	// Package for string manipulation
+	"strings"
+)
+
+/*
+Function to sort transformed measurements by danger severity from Critical to No, keeping the order by id within a level
+@param transformedMeasurements Transformed measurements to sort in place
+*/
+func sortBySeverity(transformedMeasurements []TransformedMeasurement) {
+	sort.SliceStable(transformedMeasurements, func(i, j int) bool {
+		if rankI, rankJ := dangerRank(transformedMeasurements[i].danger), dangerRank(transformedMeasurements[j].danger); rankI != rankJ {
+			return rankI > rankJ
+		}
+		return transformedMeasurements[i].id < transformedMeasurements[j].id
+	})
+}
+
+/*
+Function to build an SQL expression for the severity rank of a danger level column, to order rows by severity in the database
+@param column Name of the danger level column
+@return CASE expression from 0 for No to 4 for Critical (0 for unknown levels)
+*/
+func severityRankExpression(column string) string {
+	var expression strings.Builder
+	expression.WriteString("CASE " + column)
+	for rank, level := range dangerLevels {
+		fmt.Fprintf(&expression, " WHEN '%s' THEN %d", level, rank)
+	}
+	expression.WriteString(" ELSE 0 END")
+	return expression.String()
+}
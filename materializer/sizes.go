@@ -0,0 +1,169 @@
+package main
+
+/*
+@author 1Zero64
+Report of the table and index sizes of the tables involved in a run before and after it, to evaluate the storage growth
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for aligned text columns
+	"text/tabwriter"
+)
+
+// Object structure for the size of a table in bytes
+type RelationSize struct {
+	// Size including indexes and TOAST
+	Total int64 `json:"total_bytes"`
+	// Size of the main data
+	Table int64 `json:"table_bytes"`
+	// Size of the indexes
+	Indexes int64 `json:"index_bytes"`
+}
+
+// Object structure for the size of a table before and after a run
+type TableSizeChange struct {
+	// Name of the table
+	Table string `json:"table"`
+	// Size before the run (null, if the table didn't exist)
+	Before *RelationSize `json:"before"`
+	// Size after the run (null, if the table doesn't exist anymore)
+	After *RelationSize `json:"after"`
+	// Difference of the sizes, a missing table counts as 0 bytes
+	Delta RelationSize `json:"delta"`
+}
+
+/*
+Function to get the tables involved in a run with the current settings
+@return Names of the event store, the view and the staging, danger level or aggregated tables, if used
+*/
+func sizedTables() []string {
+	tables := []string{"event_store", "materialized_view"}
+	if stagingRebuild {
+		tables = append(tables, stagingTable)
+	}
+	if splitByDanger {
+		for _, level := range dangerLevels {
+			tables = append(tables, dangerTable(level))
+		}
+	}
+	if aggregateMode {
+		tables = append(tables, aggregateTable)
+	}
+	return tables
+}
+
+/*
+Function to read the sizes of the tables involved in a run. The sizes of a partitioned table are summed over its partitions
+@param db *sql.DB Database connection to Postgres database
+@return Sizes by table name, missing tables are left out
+*/
+func readTableSizes(db *sql.DB) map[string]RelationSize {
+	sizes := make(map[string]RelationSize)
+	for _, table := range sizedTables() {
+		// Sum the sizes of the table and its partitions, a missing table has no rows in its partition tree
+		var relations int
+		var size RelationSize
+		err := db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(pg_total_relation_size(relid)), 0)::bigint, COALESCE(SUM(pg_relation_size(relid)), 0)::bigint, COALESCE(SUM(pg_indexes_size(relid)), 0)::bigint
+			FROM pg_partition_tree(to_regclass($1))`, table).Scan(&relations, &size.Total, &size.Table, &size.Indexes)
+		checkError(err)
+		if relations > 0 {
+			sizes[table] = size
+		}
+	}
+	return sizes
+}
+
+/*
+Function to compare the sizes of the tables before and after a run
+@param before Sizes before the run
+@param after Sizes after the run
+@return Changes in the order of the involved tables, tables missing before and after are left out
+*/
+func tableSizeChanges(before map[string]RelationSize, after map[string]RelationSize) []TableSizeChange {
+	changes := make([]TableSizeChange, 0, len(after))
+	for _, table := range sizedTables() {
+		sizeBefore, existedBefore := before[table]
+		sizeAfter, existsAfter := after[table]
+		if !existedBefore && !existsAfter {
+			continue
+		}
+		change := TableSizeChange{Table: table, Delta: RelationSize{
+			Total:   sizeAfter.Total - sizeBefore.Total,
+			Table:   sizeAfter.Table - sizeBefore.Table,
+			Indexes: sizeAfter.Indexes - sizeBefore.Indexes,
+		}}
+		if existedBefore {
+			change.Before = &sizeBefore
+		}
+		if existsAfter {
+			change.After = &sizeAfter
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+/*
+Function to format a number of bytes in binary units
+@param bytes Number of bytes (negative for a shrunk size)
+@return Human-readable size, e.g. 1.50 GiB
+*/
+func formatBytes(bytes int64) string {
+	value := float64(bytes)
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	unit := 0
+	for (value >= 1024 || value <= -1024) && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	return fmt.Sprintf("%.2f %s", value, units[unit])
+}
+
+/*
+Function to format the size of a table, which may be missing
+@param size Size of the table (nil, if it doesn't exist)
+@return Human-readable size or - for a missing table
+*/
+func formatRelationSize(size *RelationSize) string {
+	if size == nil {
+		return "-"
+	}
+	return formatBytes(size.Total)
+}
+
+/*
+Function to format a signed difference of a size
+@param bytes Difference in bytes
+@return Human-readable difference with its sign
+*/
+func formatBytesDelta(bytes int64) string {
+	if bytes >= 0 {
+		return "+" + formatBytes(bytes)
+	}
+	return formatBytes(bytes)
+}
+
+/*
+Function to print the sizes of the tables before and after a run with their differences
+@param changes Changes of the table sizes
+*/
+func printTableSizes(changes []TableSizeChange) {
+	fmt.Println("Table sizes (total including indexes and TOAST):")
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "  Table\tBefore\tAfter\tDelta\tTable delta\tIndex delta")
+	for _, change := range changes {
+		fmt.Fprintf(writer, "  %s\t%s\t%s\t%s\t%s\t%s\n", change.Table, formatRelationSize(change.Before), formatRelationSize(change.After),
+			formatBytesDelta(change.Delta.Total), formatBytesDelta(change.Delta.Table), formatBytesDelta(change.Delta.Indexes))
+	}
+	writer.Flush()
+}
@@ -0,0 +1,121 @@
+package main
+
+/*
+@author 1Zero64
+Accounting of the measurements skipped by the policies of a run, and the threshold a non-interactive run fails above
+*/
+
+// Importing packages
+import (
+	// Package for formatted printing
+	"fmt"
+	// Package for sorting Slices
+	"sort"
+	// Package for converting strings to numbers
+	"strconv"
+	// Package for string manipulation
+	"strings"
+)
+
+// Object structure for the number of measurements skipped for a reason
+type SkipCount struct {
+	// Reason of the skip, e.g. null_reading
+	Reason string `json:"reason"`
+	// Number of skipped measurements
+	Count int `json:"count"`
+}
+
+/*
+Function to parse the threshold of skipped measurements, either absolute (e.g. 100) or a percentage of the input (e.g. 5%)
+@param value Threshold as text (empty to disable)
+@return Threshold and flag whether it's a percentage
+*/
+func parseSkipThreshold(value string) (float64, bool) {
+	if value == "" {
+		return 0, false
+	}
+	percentage := strings.HasSuffix(value, "%")
+	threshold, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || threshold < 0 || (percentage && threshold > 100) {
+		checkError(fmt.Errorf("invalid SKIP_THRESHOLD %q, expected a number >= 0 or a percentage like 5%%", value))
+	}
+	return threshold, percentage
+}
+
+/*
+Function to get the number of skipped measurements per reason, which weren't written into the output
+@return Reasons with at least one skipped measurement, ordered by count descending
+*/
+func (summary *RunSummary) skipCounts() []SkipCount {
+
+	// Collect the counts of the policies dropping measurements
+	counts := []SkipCount{
+		{Reason: "null_reading", Count: summary.nullSkipped},
+		{Reason: "non_finite_reading", Count: summary.nonFiniteDeadLettered},
+	}
+	if unknownSensorPolicy != UnknownSensorFlag {
+		counts = append(counts, SkipCount{Reason: "unknown_sensor", Count: summary.unknownSensorMeasurements})
+	}
+	if futureSkewPolicy == FutureSkewSkip {
+		counts = append(counts, SkipCount{Reason: "future_created_on", Count: summary.futureMeasurements})
+	}
+	if summary.duplicates > 0 {
+		counts = append(counts, SkipCount{Reason: "duplicate", Count: summary.duplicates})
+	}
+
+	// Keep the reasons with skipped measurements, the most frequent first
+	skipped := make([]SkipCount, 0, len(counts))
+	for _, count := range counts {
+		if count.Count > 0 {
+			skipped = append(skipped, count)
+		}
+	}
+	sort.SliceStable(skipped, func(i, j int) bool {
+		return skipped[i].Count > skipped[j].Count
+	})
+	return skipped
+}
+
+/*
+Function to get the total number of skipped measurements and the number of input measurements
+@return Skipped measurements and input measurements (transformed and skipped ones)
+*/
+func (summary *RunSummary) skipTotals() (int, int) {
+	skipped := 0
+	for _, count := range summary.skipCounts() {
+		skipped += count.Count
+	}
+	return skipped, summary.measurements + skipped
+}
+
+/*
+Function to check whether the skipped measurements exceed SKIP_THRESHOLD
+@return True, if a threshold is set and exceeded
+*/
+func (summary *RunSummary) skipThresholdExceeded() bool {
+	if skipThresholdValue == "" {
+		return false
+	}
+	skipped, input := summary.skipTotals()
+	if skipThresholdPercent {
+		return input > 0 && float64(skipped)*100/float64(input) > skipThreshold
+	}
+	return float64(skipped) > skipThreshold
+}
+
+/*
+Function to print the skipped measurements per reason, ordered by count descending
+*/
+func (summary *RunSummary) printSkipped() {
+	skipped, input := summary.skipTotals()
+	if skipped == 0 {
+		return
+	}
+	localePrintf("Skipped measurements: %d of %d (%.2f%%)\n", skipped, input, float64(skipped)*100/float64(input))
+	for _, count := range summary.skipCounts() {
+		localePrintf("  %-20s %d\n", count.Reason, count.Count)
+	}
+	if summary.skipThresholdExceeded() {
+		localePrintf("Warning: skipped measurements exceed SKIP_THRESHOLD %s\n", skipThresholdValue)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+/*
+@author 1Zero64
+Export of the measurements breaching the latency SLA
+*/
+
+// Importing packages
+import (
+	// Package for buffered I/O
+	"bufio"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+)
+
+/*
+Function to write the ids of the measurements breaching the latency SLA into a file, one id per line
+@param path Path of the file
+@param ids Ids of the breaching measurements
+*/
+func writeSlaBreaches(path string, ids []int64) {
+
+	// Create or truncate file
+	file, err := os.Create(path)
+	// Check on error with handler
+	checkError(err)
+	defer file.Close()
+
+	// Write one id per line
+	writer := bufio.NewWriter(file)
+	for _, id := range ids {
+		_, err = fmt.Fprintln(writer, id)
+		checkError(err)
+	}
+	err = writer.Flush()
+	checkError(err)
+
+	fmt.Printf("Wrote %d latency SLA breaching measurement ids to %s\n", len(ids), path)
+}
@@ -0,0 +1,73 @@
+package main
+
+/*
+@author 1Zero64
+Routing of the transformed measurements into an additional table per danger level for alerting consumers
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for string manipulation
+	"strings"
+)
+
+/*
+Function to get the table of a danger level
+@param level Danger level
+@return Name of the table, e.g. materialized_view_critical
+*/
+func dangerTable(level string) string {
+	return "materialized_view_" + strings.ToLower(level)
+}
+
+/*
+Function to create the tables of all danger levels like the materialized view, if missing, and clean them for the run
+@param db *sql.DB Database connection to Postgres database
+*/
+func prepareDangerTables(db *sql.DB) {
+
+	// Refuse to clean the tables of a protected environment
+	requireUnprotected("clean the danger level tables")
+
+	for _, level := range dangerLevels {
+		table := dangerTable(level)
+
+		// Create the table with the structure and indexes of the materialized view
+		_, err := db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (LIKE materialized_view INCLUDING ALL)", table))
+		checkError(err)
+
+		// Record the latency unit and clean the rows of the previous run
+		recordLatencyUnit(db, table)
+		_, err = db.Exec("TRUNCATE TABLE " + table)
+		checkError(err)
+	}
+}
+
+/*
+Function to write a transformed measurement additionally into the table of its danger level
+@param measurement Measurement of the transformed measurement for the row error handling
+@param transformedMeasurement Transformed measurement to write
+@param summary Summary of the run counting the retries
+@param db *sql.DB Database connection to Postgres database
+*/
+func writeDangerTable(measurement Measurement, transformedMeasurement TransformedMeasurement, summary *RunSummary, db *sql.DB) {
+	table := dangerTable(transformedMeasurement.danger)
+	if err := retryOnDeadlock(func() error { return writeTransformedMeasurement(transformedMeasurement, table, db) }, &summary.deadlockRetried); err != nil {
+		handleRowError(measurement, err)
+	}
+}
+
+/*
+Function to print the number of rows written into each danger level table
+@param summary Summary of the finished run
+*/
+func printDangerTables(summary *RunSummary) {
+	fmt.Println("Rows per danger level table (SPLIT_BY_DANGER):")
+	for _, level := range dangerLevels {
+		fmt.Printf("  %-28s %d\n", dangerTable(level), summary.dangerLevels[level])
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+/*
+@author 1Zero64
+Zero-downtime rebuild of the materialized view by a staging table swap
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for string manipulation
+	"strings"
+	// Package for measuring and displaying time values
+	"time"
+
+	// Package to use PostgreSQL database, for quoting identifiers
+	"github.com/lib/pq"
+)
+
+// Name of the staging table for zero-downtime rebuilds
+const stagingTable = "materialized_view_staging"
+
+// Object structure for a privilege granted on the materialized view
+type TableGrant struct {
+	// Role the privilege is granted to (PUBLIC for all roles)
+	grantee string
+	// Privilege, e.g. SELECT
+	privilege string
+	// Flag whether the grantee may grant the privilege to others
+	grantable bool
+}
+
+/*
+Function to check that no view depends on the materialized view, because the swap drops it
+@param queryer Database connection or transaction to check on
+*/
+func checkNoDependentViews(queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}) {
+
+	// Find the views, whose rewrite rules reference the materialized view
+	rows, err := queryer.Query(`SELECT DISTINCT dependent.oid::regclass::text FROM pg_depend
+		JOIN pg_rewrite ON pg_rewrite.oid = pg_depend.objid
+		JOIN pg_class dependent ON dependent.oid = pg_rewrite.ev_class
+		WHERE pg_depend.refobjid = to_regclass('materialized_view') AND dependent.oid <> pg_depend.refobjid
+		ORDER BY 1`)
+	checkError(err)
+	defer rows.Close()
+	views := make([]string, 0)
+	for rows.Next() {
+		var view string
+		checkError(rows.Scan(&view))
+		views = append(views, view)
+	}
+	checkError(rows.Err())
+
+	// Refuse the swap with the names of the views
+	if len(views) > 0 {
+		checkError(fmt.Errorf("STAGING_REBUILD can't replace materialized_view, because the views %s depend on it: drop and recreate them around the rebuild or disable STAGING_REBUILD", strings.Join(views, ", ")))
+	}
+}
+
+/*
+Function to read the privileges granted on the materialized view, so they can be granted again on the swapped-in table
+@param tx Transaction of the swap
+@return Granted privileges
+*/
+func readTableGrants(tx *sql.Tx) []TableGrant {
+	rows, err := tx.Query(`SELECT grantee, privilege_type, is_grantable = 'YES' FROM information_schema.role_table_grants
+		WHERE table_schema = current_schema() AND table_name = 'materialized_view' ORDER BY grantee, privilege_type`)
+	checkError(err)
+	defer rows.Close()
+	grants := make([]TableGrant, 0)
+	for rows.Next() {
+		var grant TableGrant
+		checkError(rows.Scan(&grant.grantee, &grant.privilege, &grant.grantable))
+		grants = append(grants, grant)
+	}
+	checkError(rows.Err())
+	return grants
+}
+
+/*
+Function to build the statement granting a privilege on the materialized view again
+@param grant Privilege granted on the old materialized view
+@return GRANT statement with the quoted grantee
+*/
+func grantStatement(grant TableGrant) string {
+	grantee := "PUBLIC"
+	if grant.grantee != "PUBLIC" {
+		grantee = pq.QuoteIdentifier(grant.grantee)
+	}
+	statement := fmt.Sprintf("GRANT %s ON materialized_view TO %s", grant.privilege, grantee)
+	if grant.grantable {
+		statement += " WITH GRANT OPTION"
+	}
+	return statement
+}
+
+/*
+Function to create an empty staging table with the structure and indexes of the materialized view.
+A leftover staging table of a crashed run is dropped before
+@param db *sql.DB Database connection to Postgres database
+@return Name of the staging table to write into
+*/
+func createStagingTable(db *sql.DB) string {
+
+	// Fall back to a regular clean for partitioned views, because LIKE doesn't copy the partitioning
+	if partitionedView {
+		fmt.Println("Warning: STAGING_REBUILD is not supported for partitioned views, cleaning the view instead")
+		cleanMaterializedView(db)
+		return "materialized_view"
+	}
+
+	// Fail before the rebuild, if the swap couldn't drop the materialized view
+	checkNoDependentViews(db)
+
+	// Check for a leftover staging table of a crashed run
+	var leftover bool
+	err := db.QueryRow("SELECT to_regclass($1) IS NOT NULL", stagingTable).Scan(&leftover)
+	checkError(err)
+	if leftover {
+		fmt.Printf("Dropping leftover %s of a previous run\n", stagingTable)
+		_, err = db.Exec("DROP TABLE " + stagingTable)
+		checkError(err)
+	}
+
+	// Create staging table like the materialized view including defaults, constraints and indexes
+	_, err = db.Exec(fmt.Sprintf("CREATE TABLE %s (LIKE materialized_view INCLUDING ALL)", stagingTable))
+	// Check on error with handler
+	checkError(err)
+
+	// Return name of the staging table
+	return stagingTable
+}
+
+/*
+Function to replace the materialized view with the staging table in one transaction,
+so readers see either the complete old or the complete new data. Owner and privileges of the old view are carried over
+@param db *sql.DB Database connection to Postgres database
+@return Duration of the swap
+*/
+func swapStagingTable(db *sql.DB) time.Duration {
+
+	// Refuse to replace the materialized view of a protected environment
+	requireUnprotected("replace the materialized view")
+
+	// Save starting time point
+	start := time.Now()
+
+	// Begin transaction and check on error with handler
+	tx, err := db.Begin()
+	checkError(err)
+
+	// Roll back, if the transaction wasn't committed when the function returns
+	defer tx.Rollback()
+
+	// Check again for views created during the rebuild
+	checkNoDependentViews(tx)
+
+	// Read owner and privileges of the old view, which the staging table doesn't have
+	var owner string
+	var ownedByCurrentUser bool
+	err = tx.QueryRow("SELECT tableowner, tableowner = current_user FROM pg_tables WHERE schemaname = current_schema() AND tablename = 'materialized_view'").Scan(&owner, &ownedByCurrentUser)
+	checkError(err)
+	grants := readTableGrants(tx)
+
+	// Drop the old view and rename the staging table into place
+	_, err = tx.Exec("DROP TABLE materialized_view")
+	checkError(err)
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s RENAME TO materialized_view", stagingTable))
+	checkError(err)
+
+	// Read indexes carried over from the staging table, their names are still derived from the staging table
+	rows, err := tx.Query("SELECT indexname FROM pg_indexes WHERE tablename = 'materialized_view' AND indexname LIKE $1", stagingTable+"%")
+	checkError(err)
+	indexes := make([]string, 0)
+	for rows.Next() {
+		var index string
+		err = rows.Scan(&index)
+		checkError(err)
+		indexes = append(indexes, index)
+	}
+	checkError(rows.Err())
+	rows.Close()
+
+	// Rename indexes to the names of the materialized view, so the next staging table gets the same names again
+	for _, index := range indexes {
+		_, err = tx.Exec(fmt.Sprintf("ALTER INDEX %s RENAME TO %s", index, strings.Replace(index, stagingTable, "materialized_view", 1)))
+		checkError(err)
+	}
+
+	// Restore the owner and grant the privileges again, so readers like dashboard roles keep their access
+	if !ownedByCurrentUser {
+		_, err = tx.Exec("ALTER TABLE materialized_view OWNER TO " + pq.QuoteIdentifier(owner))
+		if err != nil {
+			checkError(fmt.Errorf("can't restore the owner %s of materialized_view after the swap: %w", owner, err))
+		}
+	}
+	for _, grant := range grants {
+		_, err = tx.Exec(grantStatement(grant))
+		checkError(err)
+	}
+
+	// Commit the swap
+	err = tx.Commit()
+	checkError(err)
+
+	// Return duration of the swap
+	return time.Since(start)
+}
@@ -0,0 +1,33 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the staging table swap
+*/
+
+// Importing packages
+import (
+	// Package for automated tests
+	"testing"
+)
+
+/*
+Test that the privileges of the old view are granted again with quoted grantees
+@param t Test state
+*/
+func TestGrantStatement(t *testing.T) {
+	cases := []struct {
+		grant TableGrant
+		want  string
+	}{
+		{TableGrant{grantee: "dashboard", privilege: "SELECT"}, `GRANT SELECT ON materialized_view TO "dashboard"`},
+		{TableGrant{grantee: "PUBLIC", privilege: "SELECT"}, `GRANT SELECT ON materialized_view TO PUBLIC`},
+		{TableGrant{grantee: "Report Users", privilege: "SELECT", grantable: true}, `GRANT SELECT ON materialized_view TO "Report Users" WITH GRANT OPTION`},
+		{TableGrant{grantee: `odd"name`, privilege: "INSERT"}, `GRANT INSERT ON materialized_view TO "odd""name"`},
+	}
+	for _, testCase := range cases {
+		if got := grantStatement(testCase.grant); got != testCase.want {
+			t.Errorf("grantStatement(%+v) = %s, want %s", testCase.grant, got, testCase.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+/*
+@author 1Zero64
+Microbenchmark of the materialize process broken down per event stream
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for reading and writing CSV files
+	"encoding/csv"
+	// Package for formatted printing
+	"fmt"
+	// Package for math functions
+	"math"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for sorting Slices
+	"sort"
+	// Package for converting strings to numbers
+	"strconv"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Object structure for the benchmark result of a single event stream
+type StreamBenchmarkResult struct {
+	// Name of the event stream
+	stream string
+	// Number of measurements of the event stream
+	measurements int
+	// Mean duration of the iterations in seconds
+	meanDuration float64
+	// Standard deviation of the iteration durations in seconds
+	standardDeviation float64
+	// Throughput in measurements per second based on the mean duration
+	throughput float64
+}
+
+/*
+Function to execute the materialize process several times per distinct event stream of the event store with the stream filter,
+to compare whether the measurements of a streaming technology are systematically slower to materialize
+@param db *sql.DB Database connection to Postgres database
+@param iterations Number of iterations per event stream
+@param exportPath Path of a CSV file to export the comparison table to (empty to skip)
+*/
+func streamMicrobenchmark(db *sql.DB, iterations int, exportPath string) {
+
+	// Generate unique identifier of the benchmark run, shared by all iterations
+	runId := newRunId()
+
+	// Print information about starting the test
+	fmt.Printf("Starting microbenchmark per event stream (run %s)...\n", runId)
+
+	// Read distinct event streams of the event store
+	streams := make([]string, 0)
+	for stream := range countByStream(db, "event_store") {
+		// Measurements without an event stream can't be selected by the stream filter
+		if stream == nullStreamName {
+			continue
+		}
+		streams = append(streams, stream)
+	}
+	sort.Strings(streams)
+
+	// Restore the configured stream filter, when surrounding function returns
+	defer func(filter string) { streamFilter = filter }(streamFilter)
+
+	// Benchmark every event stream on its own
+	results := make([]StreamBenchmarkResult, 0, len(streams))
+	for _, stream := range streams {
+		// Set the stream filter to the current event stream
+		streamFilter = stream
+
+		// Run the iterations and collect their durations
+		result := StreamBenchmarkResult{stream: stream}
+		durations := make([]float64, 0, iterations)
+		for i := 0; i < iterations; i++ {
+			start := time.Now()
+			summary := materialize(context.Background(), db, runId, nil)
+			elapsed := time.Since(start)
+			if !benchmarkIncludeClean {
+				elapsed -= summary.cleanDuration
+			}
+			result.measurements = summary.measurements
+			durations = append(durations, elapsed.Seconds())
+			fmt.Printf("%s: iteration %d/%d finished\n", stream, i+1, iterations)
+		}
+
+		// Calculate mean, standard deviation and throughput
+		result.meanDuration, result.standardDeviation = meanAndStandardDeviation(durations)
+		if result.meanDuration > 0 {
+			result.throughput = float64(result.measurements) / result.meanDuration
+		}
+		results = append(results, result)
+	}
+
+	// Print comparison table
+	fmt.Print("Microbenchmark per event stream finished\n\n")
+	fmt.Printf("Run id: %s, %d iterations per event stream\n", runId, iterations)
+	fmt.Printf("%-20s %12s %18s %18s %22s\n", "Event stream", "Measurements", "Mean (seconds)", "Stddev (seconds)", "Throughput (rows/s)")
+	for _, result := range results {
+		fmt.Printf("%-20s %12d %18f %18f %22f\n", result.stream, result.measurements, result.meanDuration, result.standardDeviation, result.throughput)
+	}
+
+	// Export comparison table, if a file is given
+	if exportPath != "" {
+		exportStreamBenchmark(results, iterations, runId, exportPath)
+	}
+}
+
+/*
+Function to calculate the mean of values
+@param values Values to calculate the mean for
+@return Mean (0 for no values)
+*/
+func mean(values []float64) float64 {
+	average, _ := meanAndStandardDeviation(values)
+	return average
+}
+
+/*
+Function to calculate the median of values
+@param values Values to calculate the median for, NaN and infinite ones are ignored
+@return Median (0 for no finite values)
+*/
+func median(values []float64) float64 {
+
+	// Return zero for no finite values
+	values, _ = finiteValues(values)
+	if len(values) == 0 {
+		return 0
+	}
+
+	// Sort a copy of the values and take the middle one or the mean of both middle ones
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	if len(sorted)%2 == 0 {
+		return (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	}
+	return sorted[len(sorted)/2]
+}
+
+/*
+Function to calculate the mean and the (population) standard deviation of values
+@param values Values to calculate the statistics for, NaN and infinite ones are ignored
+@return Mean and standard deviation (0 for no finite values)
+*/
+func meanAndStandardDeviation(values []float64) (float64, float64) {
+
+	// Return zeros for no finite values
+	values, _ = finiteValues(values)
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	// Divide total by number of values
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+
+	// Take square root of the mean square distance to the mean
+	var squares float64
+	for _, value := range values {
+		squares += math.Pow(value-mean, 2)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
+
+/*
+Function to export the per event stream benchmark results as CSV file
+@param results Benchmark results per event stream
+@param iterations Number of iterations per event stream
+@param runId Unique identifier of the benchmark run
+@param path Path of the CSV file
+*/
+func exportStreamBenchmark(results []StreamBenchmarkResult, iterations int, runId string, path string) {
+
+	// Create export file and check on error with handler
+	file, err := os.Create(path)
+	checkError(err)
+
+	// Close file later, when surrounding function returns
+	defer file.Close()
+
+	// Write a row per event stream including its dataset size
+	writer := csv.NewWriter(file)
+	checkError(writer.Write([]string{"run_id", "stream", "measurements", "iterations", "mean_seconds", "stddev_seconds", "throughput_rows_per_second"}))
+	for _, result := range results {
+		checkError(writer.Write([]string{
+			runId,
+			result.stream,
+			strconv.Itoa(result.measurements),
+			strconv.Itoa(iterations),
+			strconv.FormatFloat(result.meanDuration, 'f', -1, 64),
+			strconv.FormatFloat(result.standardDeviation, 'f', -1, 64),
+			strconv.FormatFloat(result.throughput, 'f', -1, 64),
+		}))
+	}
+	writer.Flush()
+	checkError(writer.Error())
+}
@@ -0,0 +1,183 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the strict mode aborting a run on the first error of a measurement
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package for inspecting errors
+	"errors"
+	// Package for string manipulation
+	"strings"
+	// Package for automated tests
+	"testing"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Source with a NULL temperature in the second of three measurements, which the default NULL_POLICY rejects
+const strictSource = csvSourceHeader +
+	"1,7,4,45,room-1,2024-01-05T10:00:00Z,2024-01-05T10:00:01Z\n" +
+	"2,7,,45,room-1,2024-01-05T10:01:00Z,2024-01-05T10:01:01Z\n" +
+	"3,7,5,45,room-1,2024-01-05T10:02:00Z,2024-01-05T10:02:01Z\n"
+
+/*
+Function to enable the strict mode, which is restored when the test finished
+@param t Test state
+*/
+func useStrictMode(t *testing.T) {
+	t.Helper()
+	savedStrict, savedNullPolicy := strict, nullPolicy
+	t.Cleanup(func() { strict, nullPolicy = savedStrict, savedNullPolicy })
+	strict, nullPolicy = true, NullPolicyError
+}
+
+/*
+Test that the first bad measurement stops the run with a StrictAbortError naming it, after the deferred cleanup of the run
+flushed the measurements written before
+@param t Test state
+*/
+func TestStrictModeStopsAtFirstBadMeasurement(t *testing.T) {
+	output := useCsvSourceAndOutput(t, strictSource)
+	useStrictMode(t)
+
+	var recovered interface{}
+	printed := captureStdout(t, func() {
+		defer func() { recovered = recover() }()
+		materialize(context.Background(), nil, "test", nil)
+	})
+	var strictAbort *StrictAbortError
+	if failure, ok := recovered.(error); !ok || !errors.As(failure, &strictAbort) {
+		t.Fatalf("run failed with %v, want a StrictAbortError", recovered)
+	}
+	if strictAbort.Measurement.id != 2 || !strings.Contains(strictAbort.Error(), "measurement id=2 sensor_id=7") {
+		t.Errorf("abort %q, want it to name measurement 2", strictAbort)
+	}
+
+	// The run stopped at measurement 2, the deferred abort of the export flushed measurement 1 and printed where to resume
+	if records := readCsvOutput(t, output); len(records) != 1 || records[0][0] != "1" {
+		t.Errorf("records %v, want only measurement 1", records)
+	}
+	if !strings.Contains(printed, "continue it with -resume-from 1") {
+		t.Errorf("output %q lacks the resume hint of the aborted export", printed)
+	}
+}
+
+/*
+Test that a strict mode abort in the batch mode fails with the data error exit code and the details of the measurement
+@param t Test state
+*/
+func TestBatchStrictModeAbort(t *testing.T) {
+	useCsvSourceAndOutput(t, strictSource)
+	useStrictMode(t)
+
+	exitCode, result := runTestBatch(t, openStubDatabase(t))
+	if exitCode != BatchExitData || result.Outcome != "data_error" {
+		t.Errorf("exit code %d, outcome %s, want %d and data_error", exitCode, result.Outcome, BatchExitData)
+	}
+	if !strings.HasPrefix(result.Error, "strict mode: aborting on error for measurement id=2") {
+		t.Errorf("error %q, want the strict mode abort of measurement 2", result.Error)
+	}
+}
+
+/*
+Test that the strict mode also aborts on the measurements, which the policies would skip, dead-letter or alter instead of
+writing them as read
+@param t Test state
+*/
+func TestStrictModeAbortsOnToleratedMeasurements(t *testing.T) {
+	cases := []struct {
+		name string
+		// Second measurement of the source
+		line string
+		// Configuration of the tolerating policy
+		setup func(t *testing.T)
+		want  string
+	}{
+		{"NULL skipped", "2,7,,45,room-1,2024-01-05T10:01:00Z,2024-01-05T10:01:01Z\n",
+			func(t *testing.T) { nullPolicy = NullPolicySkip }, "NULL reading, tolerated by NULL_POLICY skip"},
+		{"NULL zeroed", "2,7,,45,room-1,2024-01-05T10:01:00Z,2024-01-05T10:01:01Z\n",
+			func(t *testing.T) { nullPolicy = NullPolicyZero }, "NULL reading, tolerated by NULL_POLICY zero"},
+		{"non-finite dead-lettered", "2,7,NaN,45,room-1,2024-01-05T10:01:00Z,2024-01-05T10:01:01Z\n",
+			func(t *testing.T) {}, "NaN or infinite reading, tolerated by NON_FINITE_POLICY dead-letter"},
+		{"future skipped", "2,7,5,45,room-1,2030-01-05T10:01:00Z,2030-01-05T10:01:01Z\n",
+			func(t *testing.T) { futureSkew, futureSkewPolicy = time.Hour, FutureSkewSkip }, "tolerated by FUTURE_SKEW_POLICY skip"},
+		{"future clamped", "2,7,5,45,room-1,2030-01-05T10:01:00Z,2030-01-05T10:01:01Z\n",
+			func(t *testing.T) { futureSkew, futureSkewPolicy = time.Hour, FutureSkewClamp }, "tolerated by FUTURE_SKEW_POLICY clamp"},
+		{"unknown sensor skipped", "2,8,5,45,room-1,2024-01-05T10:01:00Z,2024-01-05T10:01:01Z\n",
+			func(t *testing.T) { useStubSensorRegistry(t, 0, "7") }, "unknown sensor 8, tolerated by UNKNOWN_SENSOR_POLICY skip"},
+	}
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			useCsvSourceAndOutput(t, csvSourceHeader+
+				"1,7,4,45,room-1,2024-01-05T10:00:00Z,2024-01-05T10:00:01Z\n"+testCase.line+
+				"3,7,5,45,room-1,2024-01-05T10:02:00Z,2024-01-05T10:02:01Z\n")
+			useStrictMode(t)
+			savedNonFinite, savedSkew, savedSkewPolicy := nonFinitePolicy, futureSkew, futureSkewPolicy
+			t.Cleanup(func() { nonFinitePolicy, futureSkew, futureSkewPolicy = savedNonFinite, savedSkew, savedSkewPolicy })
+			nonFinitePolicy, futureSkew, futureSkewPolicy = NonFiniteDeadLetter, 0, FutureSkewFlag
+			testCase.setup(t)
+
+			message := expectFailure(t, func() {
+				captureStdout(t, func() { materialize(context.Background(), openStubDatabase(t), "test", nil) })
+			})
+			if !strings.Contains(message, "measurement id=2") || !strings.Contains(message, testCase.want) {
+				t.Errorf("abort %q, want measurement 2 %s", message, testCase.want)
+			}
+		})
+	}
+}
+
+/*
+Test that the strict mode is refused together with a SKIP_THRESHOLD or an explicitly configured tolerating policy, but not with
+the tolerant defaults, which it replaces by the abort
+@param t Test state
+*/
+func TestCheckStrictMode(t *testing.T) {
+	useStrictMode(t)
+	savedThreshold, savedSkewPolicy, savedNonFinite, savedUnknown := skipThresholdValue, futureSkewPolicy, nonFinitePolicy, unknownSensorPolicy
+	defer func() {
+		skipThresholdValue, futureSkewPolicy, nonFinitePolicy, unknownSensorPolicy = savedThreshold, savedSkewPolicy, savedNonFinite, savedUnknown
+	}()
+	defaults := func() {
+		skipThresholdValue, nullPolicy, futureSkewPolicy, nonFinitePolicy, unknownSensorPolicy = "", NullPolicyError, FutureSkewFlag, NonFiniteDeadLetter, UnknownSensorSkip
+		t.Setenv("NON_FINITE_POLICY", "")
+		t.Setenv("UNKNOWN_SENSOR_POLICY", "")
+	}
+
+	// The defaults are accepted
+	defaults()
+	checkStrictMode()
+
+	conflicts := map[string]func(){
+		"SKIP_THRESHOLD":                func() { skipThresholdValue = "5%" },
+		"NULL_POLICY skip":              func() { nullPolicy = NullPolicySkip },
+		"NULL_POLICY zero":              func() { nullPolicy = NullPolicyZero },
+		"FUTURE_SKEW_POLICY clamp":      func() { futureSkewPolicy = FutureSkewClamp },
+		"FUTURE_SKEW_POLICY skip":       func() { futureSkewPolicy = FutureSkewSkip },
+		"NON_FINITE_POLICY clamp":       func() { nonFinitePolicy = NonFiniteClamp },
+		"NON_FINITE_POLICY dead-letter": func() { t.Setenv("NON_FINITE_POLICY", NonFiniteDeadLetter) },
+		"UNKNOWN_SENSOR_POLICY skip":    func() { t.Setenv("UNKNOWN_SENSOR_POLICY", UnknownSensorSkip) },
+		"UNKNOWN_SENSOR_POLICY dead-letter": func() {
+			t.Setenv("UNKNOWN_SENSOR_POLICY", UnknownSensorDeadLetter)
+			unknownSensorPolicy = UnknownSensorDeadLetter
+		},
+	}
+	for name, conflict := range conflicts {
+		defaults()
+		conflict()
+		if message := expectFailure(t, checkStrictMode); !strings.Contains(message, "-strict can't be combined") {
+			t.Errorf("%s: failure %q, want the refused combination", name, message)
+		}
+	}
+
+	// Flagging unknown sensors passes them through and is accepted
+	defaults()
+	t.Setenv("UNKNOWN_SENSOR_POLICY", UnknownSensorFlag)
+	unknownSensorPolicy = UnknownSensorFlag
+	checkStrictMode()
+}
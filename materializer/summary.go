@@ -0,0 +1,451 @@
+package main
+
+/*
+@author 1Zero64
+Summary of a materialize run accumulated during the transformation
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package for inspecting errors
+	"errors"
+	// Package for formatted printing
+	"fmt"
+	// Package for sorting Slices
+	"sort"
+	// Package for converting numbers to strings
+	"strconv"
+	// Package for string manipulation
+	"strings"
+	// Package for atomic counters shared by the concurrent writes
+	"sync/atomic"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Cause of a run stopped by the deadline of MAX_RUNTIME, unlike a deadline of the caller like a gRPC client
+var errMaxRuntimeExceeded = fmt.Errorf("MAX_RUNTIME exceeded: %w", context.DeadlineExceeded)
+
+// Maximum number of distinct unknown sensor ids to remember for the summary
+const maxUnknownSensorIds = 20
+
+// Danger levels ordered by severity, used for consistent output
+var dangerLevels = []string{No, Low, Medium, High, Critical}
+
+// Object structure for the summary of a materialize run
+type RunSummary struct {
+	// Unique identifier of the run
+	runId string
+	// Time point on when the run was started
+	start time.Time
+	// Duration of the whole run
+	duration time.Duration
+	// Maximum number of concurrent writes used
+	writeConcurrency int
+	// Duration of the clean phase
+	cleanDuration time.Duration
+	// Duration of the read phase
+	readDuration time.Duration
+	// Duration of the transform and write phase
+	writeDuration time.Duration
+	// Time the pipeline reader waited for the writer (0 without pipeline mode)
+	readerIdle time.Duration
+	// Time the writer waited for the pipeline reader (0 without pipeline mode)
+	writerIdle time.Duration
+	// Duration of swapping the staging table into place (0 without staging rebuild)
+	swapDuration time.Duration
+	// Number of transformed measurements
+	measurements int
+	// Thresholds used for the classification
+	thresholds Thresholds
+	// Source of the thresholds (configuration or the danger_thresholds set)
+	thresholdSource string
+	// Throughput samples taken during the transform and write phase (nil without throughput logging)
+	throughputSamples []ThroughputSample
+	// Id of the last processed measurement, to resume a stopped run from
+	lastId int64
+	// Reason, why the run was stopped before all measurements were processed (nil for a complete run): the cause of the
+	// cancelled context like an InterruptError or errMaxRuntimeExceeded
+	stopped error
+	// Number of transformed measurements per danger level
+	dangerLevels map[string]int
+	// Newest processed_on of the transformed measurements
+	maxProcessedOn time.Time
+	// Statistics per event stream (streaming technology)
+	streams map[string]*StreamStatistics
+	// Histogram of the latencies (nil, if disabled)
+	latencyHistogram *LatencyHistogram
+	// Streaming statistics of the latencies of all event streams
+	latency *LatencyAccumulator
+	// Number of measurements exceeding the latency SLA
+	slaBreaches int
+	// Ids of the measurements exceeding the latency SLA (only collected, if LATENCY_SLA_FILE is set)
+	slaBreachIds []int64
+	// Number of measurements collapsed as duplicates of the same (sensor_id, created_on) (-1, if not counted)
+	duplicates int
+	// Measurements processed later than BACKFILL_THRESHOLD after their creation
+	backfill Backfill
+	// Difference of the pg_stat counters of the run (nil, if PG_STATS is disabled)
+	pgStats *PgStats
+	// Sizes of the involved tables before and after the run (nil, if TABLE_SIZES is disabled)
+	tableSizes []TableSizeChange
+	// Freshness and ingest lag of the event store (not measured for benchmark iterations and CSV sources)
+	freshness Freshness
+	// Timings per chunk of measurements, if VERBOSE_CHUNKS is enabled
+	chunks []ChunkTiming
+	// Path the measurements were read by, into a slice or streamed (empty, if not read from the event store)
+	readPath string
+	// Number of measurements, whose level change was suppressed by the hysteresis
+	hysteresisHeld int
+	// Number of measurements created in the future beyond the skew tolerance
+	futureMeasurements int
+	// Number of measurements with a NULL reading transformed with the value 0
+	nullZeroed int
+	// Number of measurements with a NULL reading dropped by the NULL policy
+	nullSkipped int
+	// Number of measurements with a NaN or infinite reading written into the dead letter table
+	nonFiniteDeadLettered int
+	// Number of measurements with a NaN or infinite reading clamped to finite ones
+	nonFiniteClamped int
+	// Number of writes retried after a deadlock, shared by the concurrent writes
+	deadlockRetried atomic.Int64
+	// Number of measurements of sensors missing in the sensor registry
+	unknownSensorMeasurements int
+	// Distinct ids of unknown sensors (capped at maxUnknownSensorIds)
+	unknownSensorIds []int64
+	// Flag whether more distinct unknown sensor ids were seen than remembered
+	unknownSensorIdsCapped bool
+}
+
+/*
+Function to create an empty run summary starting now
+@param runId Unique identifier of the run
+@return Pointer to the initialized run summary
+*/
+func newRunSummary(runId string) *RunSummary {
+	summary := &RunSummary{
+		runId:        runId,
+		start:        time.Now(),
+		dangerLevels: make(map[string]int),
+		streams:      make(map[string]*StreamStatistics),
+		latency:      newLatencyAccumulator(),
+	}
+	if latencyBuckets != nil {
+		summary.latencyHistogram = newLatencyHistogram(latencyBuckets)
+	}
+	return summary
+}
+
+// Object structure for the statistics of a single event stream
+type StreamStatistics struct {
+	// Name of the threshold profile of the stream
+	profile string
+	// Number of transformed measurements of the stream
+	measurements int
+	// Sum of the latencies in the latency unit of the stream
+	latencySum float64
+	// Streaming statistics of the latencies of the stream
+	latency *LatencyAccumulator
+}
+
+/*
+Function to add a transformed measurement to the summary
+@param transformedMeasurement Transformed measurement to account for
+*/
+func (summary *RunSummary) add(transformedMeasurement TransformedMeasurement) {
+
+	// Increment counter of measurements and of its danger level
+	summary.measurements++
+	summary.dangerLevels[transformedMeasurement.danger]++
+
+	// Count NULL readings, which passed the NULL policy as 0
+	if transformedMeasurement.hasNullReading() {
+		summary.nullZeroed++
+	}
+
+	// Count NaN and infinite readings, which were clamped
+	if transformedMeasurement.clamped {
+		summary.nonFiniteClamped++
+	}
+
+	// Remember newest processed_on
+	if transformedMeasurement.processed_on.After(summary.maxProcessedOn) {
+		summary.maxProcessedOn = transformedMeasurement.processed_on
+	}
+
+	// Count latency into its histogram bucket, if enabled
+	if summary.latencyHistogram != nil {
+		summary.latencyHistogram.add(float64(transformedMeasurement.latency))
+	}
+
+	// Accumulate latency statistics of the run
+	summary.latency.add(float64(transformedMeasurement.latency))
+
+	// Count measurements exceeding the latency SLA, if set
+	if latencySla > 0 && float64(transformedMeasurement.latency) > latencySla {
+		summary.slaBreaches++
+		if latencySlaFile != "" {
+			summary.slaBreachIds = append(summary.slaBreachIds, transformedMeasurement.id)
+		}
+	}
+
+	// Count late-arriving measurements of replays or backfills, if the detector is enabled
+	summary.backfill.add(transformedMeasurement.Measurement)
+
+	// Accumulate count and latency of the event stream
+	stream, found := summary.streams[transformedMeasurement.event_stream]
+	if !found {
+		stream = &StreamStatistics{profile: profileFor(transformedMeasurement.event_stream), latency: newLatencyAccumulator()}
+		summary.streams[transformedMeasurement.event_stream] = stream
+	}
+	stream.measurements++
+	stream.latencySum += float64(transformedMeasurement.latency)
+	stream.latency.add(float64(transformedMeasurement.latency))
+}
+
+/*
+Function to get the names of all event streams of the run in alphabetical order
+@return Sorted event stream names
+*/
+func (summary *RunSummary) streamNames() []string {
+
+	// Collect and sort names
+	names := make([]string, 0, len(summary.streams))
+	for name := range summary.streams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+Function to account a measurement of a sensor missing in the sensor registry
+@param sensorId Id of the unknown sensor
+*/
+func (summary *RunSummary) addUnknownSensor(sensorId int64) {
+
+	// Increment counter of affected measurements
+	summary.unknownSensorMeasurements++
+
+	// Remember the sensor id, if it wasn't seen before
+	for _, id := range summary.unknownSensorIds {
+		if id == sensorId {
+			return
+		}
+	}
+	if len(summary.unknownSensorIds) < maxUnknownSensorIds {
+		summary.unknownSensorIds = append(summary.unknownSensorIds, sensorId)
+	} else {
+		summary.unknownSensorIdsCapped = true
+	}
+}
+
+/*
+Function to print a histogram of danger level counts as ASCII bar chart
+@param counts Number of measurements per danger level
+@param total Total number of measurements
+*/
+func printDangerHistogram(counts map[string]int, total int) {
+
+	// Print a bar per danger level scaled to 50 characters for all measurements
+	for _, level := range dangerLevels {
+		var share float64
+		if total > 0 {
+			share = float64(counts[level]) / float64(total)
+		}
+		localePrintf("%-10s %10d %6.2f%% %s\n", level, counts[level], share*100, strings.Repeat("#", int(share*50+0.5)))
+	}
+}
+
+/*
+Function to describe why a stopped run stopped early
+@return Reason by the cause of the cancellation
+*/
+func (summary *RunSummary) stopReason() string {
+	var interrupt *InterruptError
+	switch {
+	case errors.Is(summary.stopped, errMaxRuntimeExceeded):
+		return fmt.Sprintf("after MAX_RUNTIME %s", maxRuntime)
+	case errors.As(summary.stopped, &interrupt):
+		return fmt.Sprintf("by an interrupt (%v)", interrupt)
+	case errors.Is(summary.stopped, context.DeadlineExceeded):
+		return "by the deadline of the caller"
+	case errors.Is(summary.stopped, context.Canceled):
+		return "by the cancelled caller"
+	}
+	return fmt.Sprintf("(%v)", summary.stopped)
+}
+
+/*
+Function to print the statistics of the run summary to the console
+*/
+func (summary *RunSummary) print() {
+
+	// Print effective write concurrency
+	localePrintf("Write concurrency: %d\n", summary.writeConcurrency)
+
+	// Print the thresholds used and their source
+	localePrintf("Thresholds (%s): temperature %s, humidity %s\n", summary.thresholdSource, formatThresholds(summary.thresholds.temperature), formatThresholds(summary.thresholds.humidity))
+
+	// Print data freshness and ingest lag of the event store
+	summary.freshness.print()
+
+	// Print how far a stopped run got
+	if summary.stopped != nil {
+		localePrintf("Run stopped early %s, last processed id %s\n", summary.stopReason(), strconv.FormatInt(summary.lastId, 10))
+	}
+
+	// Print new rows and watermark of an incremental run
+	if sinceLastRun {
+		localePrintf("Since last run: %d new measurements materialized", summary.measurements)
+		if summary.measurements > 0 {
+			localePrintf(", new max processed_on %s", summary.maxProcessedOn.Format(time.RFC3339Nano))
+		}
+		fmt.Println()
+	}
+
+	// Per-level and per-stream statistics are only collected by the transformation in Go
+	if pushdownMode {
+		localePrintf("SQL pushdown: %d measurements materialized by the database, danger levels and streams aren't collected\n", summary.measurements)
+	}
+
+	// Print the level changes suppressed by the hysteresis
+	if hysteresisMargin > 0 {
+		localePrintf("Hysteresis (margin %v): %d level changes suppressed\n", hysteresisMargin, summary.hysteresisHeld)
+	}
+
+	// Print the number of collapsed duplicates
+	if dedupPolicy != "" && summary.duplicates >= 0 {
+		localePrintf("Deduplication (keep %s): %d duplicate measurements collapsed\n", dedupPolicy, summary.duplicates)
+	}
+
+	// Print the number of filled gaps
+	if fillGaps {
+		localePrintf("Filled gaps: %d measurements were missing from the materialized view\n", summary.measurements)
+	}
+
+	// Print extrapolated counts for a sampled run
+	if sampleRate < 1 {
+		localePrintf("Sampled run (SAMPLE_RATE %v): %d measurements sampled, ~%.0f measurements extrapolated\n", sampleRate, summary.measurements, float64(summary.measurements)/sampleRate)
+		for _, level := range dangerLevels {
+			localePrintf("  %-10s ~%.0f\n", level, float64(summary.dangerLevels[level])/sampleRate)
+		}
+	}
+
+	// Print the rows routed into each danger level table
+	if splitByDanger && sampleRate >= 1 {
+		printDangerTables(summary)
+	}
+
+	// Print the read path chosen by MAX_IN_MEMORY
+	if maxInMemory > 0 && summary.readPath != "" {
+		localePrintf("Read path: %s\n", summary.readPath)
+	}
+
+	// Print idle times of both sides of the pipeline to show which side is the bottleneck
+	if summary.readPath == ReadPathStreaming && summary.readerIdle+summary.writerIdle > 0 {
+		localePrintf("Pipeline reader idle (waiting on writes): %f seconds\n", summary.readerIdle.Seconds())
+		localePrintf("Pipeline writer idle (waiting on reads): %f seconds\n", summary.writerIdle.Seconds())
+	}
+
+	// Print duration of the staging table swap
+	if summary.swapDuration > 0 {
+		localePrintf("Staging table swap: %f seconds\n", summary.swapDuration.Seconds())
+	}
+
+	// Print breaches and breach rate of the latency SLA
+	if latencySla > 0 {
+		var rate float64
+		if summary.measurements > 0 {
+			rate = float64(summary.slaBreaches) / float64(summary.measurements)
+		}
+		localePrintf("Latency SLA (%v %s): %d measurements breached (%.2f%%)\n", latencySla, latencyUnit.Name, summary.slaBreaches, rate*100)
+	}
+
+	// Print the backfilled measurements, if the detector is enabled
+	summary.backfill.print(summary.measurements)
+
+	// Print the database IO of the run, if the pg_stat counters were snapshotted
+	if summary.pgStats != nil {
+		summary.pgStats.print()
+	}
+
+	// Print the storage growth of the involved tables, if reported
+	if summary.tableSizes != nil {
+		printTableSizes(summary.tableSizes)
+	}
+
+	// Print measurements and average latency per event stream to compare the streaming technologies
+	if len(summary.streams) > 0 {
+		fmt.Println()
+		localePrintf("%-20s %-15s %12s %20s\n", "Event stream", "Profile", "Measurements", "Avg latency ("+latencyUnit.Name+")")
+		for _, name := range summary.streamNames() {
+			stream := summary.streams[name]
+			localePrintf("%-20s %-15s %12d %20f\n", name, stream.profile, stream.measurements, stream.latencySum/float64(stream.measurements))
+		}
+		fmt.Println()
+
+		// Print the latency distribution per event stream and overall, computed during the transformation
+		localePrintf("Latency statistics (%s, p95 estimated from a sample of up to %d latencies per stream):\n", latencyUnit.Name, latencyReservoirSize)
+		localePrintf("%-20s %12s %12s %12s %12s %12s %10s %10s\n", "Event stream", "Count", "Min", "Max", "Mean", "p95", "Negative", "NaN/Inf")
+		for _, name := range summary.streamNames() {
+			printLatencyAccumulator(name, summary.streams[name].latency)
+		}
+		printLatencyAccumulator("all", summary.latency)
+		fmt.Println()
+	}
+
+	// Print the shape of the latency distribution, if enabled
+	if summary.latencyHistogram != nil {
+		summary.latencyHistogram.print()
+		fmt.Println()
+	}
+
+	// Print future-dated measurements, if the check is enabled
+	if futureSkew > 0 {
+		localePrintf("Future-dated created_on (FUTURE_SKEW %s, %s): %d measurements\n", futureSkew, futureSkewPolicy, summary.futureMeasurements)
+	}
+
+	// Print the actions of the NULL policy, an error aborts the run before
+	if nullPolicy != NullPolicyError {
+		localePrintf("NULL readings (NULL_POLICY %s): %d zeroed, %d skipped\n", nullPolicy, summary.nullZeroed, summary.nullSkipped)
+	}
+
+	// Print retried writes, if deadlocks occured
+	if retried := summary.deadlockRetried.Load(); retried > 0 {
+		localePrintf("Deadlocks: %d writes retried (DEADLOCK_RETRIES %d)\n", retried, deadlockRetries)
+	}
+
+	// Print the actions of the non-finite policy, if NaN or infinite readings were found
+	if summary.nonFiniteDeadLettered > 0 || summary.nonFiniteClamped > 0 {
+		localePrintf("NaN or infinite readings (NON_FINITE_POLICY %s): %d dead-lettered, %d clamped\n", nonFinitePolicy, summary.nonFiniteDeadLettered, summary.nonFiniteClamped)
+	}
+
+	// Print unknown sensors, if the sensor registry is enabled
+	if sensorTable != "" {
+		localePrintf("Unknown sensors (%s): %d measurements", unknownSensorPolicy, summary.unknownSensorMeasurements)
+		if len(summary.unknownSensorIds) > 0 {
+			localePrintf(", sensor ids %s", fmt.Sprint(summary.unknownSensorIds))
+			if summary.unknownSensorIdsCapped {
+				fmt.Print(" and more")
+			}
+		}
+		fmt.Println()
+	}
+
+	// Print the skipped measurements per reason, the most frequent first
+	summary.printSkipped()
+}
+
+/*
+Function to print the latency statistics of an event stream as table row
+@param name Name of the event stream
+@param accumulator Latency statistics of the event stream
+*/
+func printLatencyAccumulator(name string, accumulator *LatencyAccumulator) {
+	minimum, maximum := accumulator.extremes()
+	localePrintf("%-20s %12d %12.3f %12.3f %12.3f %12.3f %10d %10d\n", name, accumulator.count, minimum, maximum, accumulator.mean(),
+		accumulator.quantiles(0.95)[0], accumulator.negative, accumulator.invalid)
+}
@@ -0,0 +1,76 @@
+package main
+
+/*
+@author 1Zero64
+Tests of the summary of a materialize run
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package for inspecting errors
+	"errors"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for system calls like the termination signal
+	"syscall"
+	// Package for automated tests
+	"testing"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+/*
+Test that the reason of a stopped run names MAX_RUNTIME only for its own deadline
+@param t Test state
+*/
+func TestStopReason(t *testing.T) {
+	saved := maxRuntime
+	defer func() { maxRuntime = saved }()
+	maxRuntime = 5 * time.Minute
+
+	cases := []struct {
+		stopped error
+		want    string
+	}{
+		{errMaxRuntimeExceeded, "after MAX_RUNTIME 5m0s"},
+		{&InterruptError{Signal: syscall.SIGTERM}, "by an interrupt (terminated signal received)"},
+		{&InterruptError{Signal: os.Interrupt}, "by an interrupt (interrupt signal received)"},
+		{context.DeadlineExceeded, "by the deadline of the caller"},
+		{context.Canceled, "by the cancelled caller"},
+	}
+	for _, testCase := range cases {
+		summary := RunSummary{stopped: testCase.stopped}
+		if got := summary.stopReason(); got != testCase.want {
+			t.Errorf("stopReason of %v = %q, want %q", testCase.stopped, got, testCase.want)
+		}
+	}
+}
+
+/*
+Test that a received signal cancels the context with an InterruptError, which is still a cancellation of the context
+@param t Test state
+*/
+func TestNotifyInterruptContext(t *testing.T) {
+	ctx, stop := notifyInterruptContext(os.Interrupt)
+	defer stop()
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sending an interrupt isn't implemented on Windows
+	if err = process.Signal(os.Interrupt); err != nil {
+		t.Skip(err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context not cancelled by the signal")
+	}
+
+	var interrupt *InterruptError
+	if cause := context.Cause(ctx); !errors.As(cause, &interrupt) || interrupt.Signal != os.Interrupt || !errors.Is(cause, context.Canceled) {
+		t.Errorf("cause %v, want an InterruptError of the interrupt wrapping context.Canceled", cause)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+/*
+@author 1Zero64
+Microbenchmark of the materialize process sweeping over write concurrency levels
+*/
+
+// Importing packages
+import (
+	// Package for deadlines and cancellation
+	"context"
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for reading and writing CSV files
+	"encoding/csv"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for converting strings to numbers
+	"strconv"
+	// Package for string manipulation
+	"strings"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Object structure for the benchmark result of a single write concurrency level
+type SweepResult struct {
+	// Maximum number of concurrent writes
+	workers int
+	// Number of materialized measurements
+	measurements int
+	// Mean duration of the iterations in seconds
+	meanDuration float64
+	// Standard deviation of the iteration durations in seconds
+	standardDeviation float64
+	// Throughput in measurements per second based on the mean duration
+	throughput float64
+	// Speedup of the mean duration relative to the first worker count
+	speedup float64
+}
+
+/*
+Function to parse a comma separated list of worker counts
+@param value Comma separated worker counts, e.g. 1,2,4,8,16
+@return Parsed worker counts
+*/
+func parseWorkerCounts(value string) []int {
+
+	// Parse every entry and check it is a positive number
+	workerCounts := make([]int, 0)
+	for _, entry := range strings.Split(value, ",") {
+		workers, err := strconv.Atoi(strings.TrimSpace(entry))
+		if err != nil || workers < 1 {
+			checkError(fmt.Errorf("invalid SWEEP_WORKERS entry %q, expected a number >= 1", entry))
+		}
+		workerCounts = append(workerCounts, workers)
+	}
+	return workerCounts
+}
+
+/*
+Function to execute the materialize process several times per write concurrency level on the same dataset,
+to find the number of concurrent writes with the best throughput
+@param db *sql.DB Database connection to Postgres database
+@param iterations Number of iterations per worker count
+@param exportPath Path of a CSV file to export the sweep table to (empty to skip)
+*/
+func sweepMicrobenchmark(db *sql.DB, iterations int, exportPath string) {
+
+	// Generate unique identifier of the benchmark run, shared by all iterations
+	runId := newRunId()
+
+	// Print information about starting the test
+	fmt.Printf("Starting write concurrency sweep (run %s)...\n", runId)
+
+	// Read the measurements once, so every worker count materializes the same dataset
+	var measurements []Measurement
+	if sourceMode == ModeCsv {
+		measurements = readCsvMeasurements(sourceCsvPath)
+	} else {
+		measurements = readMeasurements(db, "")
+	}
+
+	// Warn about worker counts the connection pool can't serve in parallel
+	maxOpenConnections := db.Stats().MaxOpenConnections
+	for _, workers := range sweepWorkers {
+		if maxOpenConnections > 0 && workers > maxOpenConnections {
+			fmt.Printf("Warning: %d workers exceed DB_MAX_OPEN_CONNS %d, the effective parallelism is capped\n", workers, maxOpenConnections)
+		}
+	}
+
+	// Restore the configured write concurrency, when surrounding function returns
+	defer func(concurrency int) { writeConcurrency = concurrency }(writeConcurrency)
+
+	// Benchmark every worker count on its own, every iteration resets the materialized view first
+	results := make([]SweepResult, 0, len(sweepWorkers))
+	for _, workers := range sweepWorkers {
+		// Set the write concurrency to the current worker count
+		writeConcurrency = workers
+
+		// Run the iterations and collect their durations
+		result := SweepResult{workers: workers}
+		durations := make([]float64, 0, iterations)
+		for i := 0; i < iterations; i++ {
+			start := time.Now()
+			summary := materialize(context.Background(), db, runId, measurements)
+			elapsed := time.Since(start)
+			if !benchmarkIncludeClean {
+				elapsed -= summary.cleanDuration
+			}
+			result.measurements = summary.measurements
+			durations = append(durations, elapsed.Seconds())
+			fmt.Printf("%d workers: iteration %d/%d finished\n", workers, i+1, iterations)
+		}
+
+		// Calculate mean, standard deviation, throughput and speedup
+		result.meanDuration, result.standardDeviation = meanAndStandardDeviation(durations)
+		if result.meanDuration > 0 {
+			result.throughput = float64(result.measurements) / result.meanDuration
+			if len(results) > 0 {
+				result.speedup = results[0].meanDuration / result.meanDuration
+			} else {
+				result.speedup = 1
+			}
+		}
+		results = append(results, result)
+	}
+
+	// Print sweep table with the connection pool settings capping the parallelism
+	fmt.Print("Write concurrency sweep finished\n\n")
+	fmt.Printf("Run id: %s, %d iterations per worker count, max open connections: %s\n", runId, iterations, formatMaxOpenConnections(maxOpenConnections))
+	fmt.Printf("%8s %12s %18s %18s %22s %10s\n", "Workers", "Measurements", "Mean (seconds)", "Stddev (seconds)", "Throughput (rows/s)", "Speedup")
+	for _, result := range results {
+		fmt.Printf("%8d %12d %18f %18f %22f %10.2f\n", result.workers, result.measurements, result.meanDuration, result.standardDeviation, result.throughput, result.speedup)
+	}
+
+	// Export sweep table, if a file is given
+	if exportPath != "" {
+		exportSweep(results, iterations, runId, maxOpenConnections, exportPath)
+	}
+}
+
+/*
+Function to format the maximum number of open connections of the connection pool
+@param maxOpenConnections Maximum number of open connections (0 for unlimited)
+@return Formatted number
+*/
+func formatMaxOpenConnections(maxOpenConnections int) string {
+	if maxOpenConnections <= 0 {
+		return "unlimited"
+	}
+	return strconv.Itoa(maxOpenConnections)
+}
+
+/*
+Function to export the write concurrency sweep results as CSV file for a speedup plot
+@param results Benchmark results per worker count
+@param iterations Number of iterations per worker count
+@param runId Unique identifier of the benchmark run
+@param maxOpenConnections Maximum number of open connections of the connection pool (0 for unlimited)
+@param path Path of the CSV file
+*/
+func exportSweep(results []SweepResult, iterations int, runId string, maxOpenConnections int, path string) {
+
+	// Create export file and check on error with handler
+	file, err := os.Create(path)
+	checkError(err)
+
+	// Close file later, when surrounding function returns
+	defer file.Close()
+
+	// Write a row per worker count including the connection pool settings
+	writer := csv.NewWriter(file)
+	checkError(writer.Write([]string{"run_id", "workers", "max_open_connections", "measurements", "iterations", "mean_seconds", "stddev_seconds", "throughput_rows_per_second", "speedup"}))
+	for _, result := range results {
+		checkError(writer.Write([]string{
+			runId,
+			strconv.Itoa(result.workers),
+			strconv.Itoa(maxOpenConnections),
+			strconv.Itoa(result.measurements),
+			strconv.Itoa(iterations),
+			strconv.FormatFloat(result.meanDuration, 'f', -1, 64),
+			strconv.FormatFloat(result.standardDeviation, 'f', -1, 64),
+			strconv.FormatFloat(result.throughput, 'f', -1, 64),
+			strconv.FormatFloat(result.speedup, 'f', -1, 64),
+		}))
+	}
+	writer.Flush()
+	checkError(writer.Error())
+}
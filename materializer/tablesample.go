@@ -0,0 +1,148 @@
+package main
+
+/*
+@author 1Zero64
+Random-sample materialization into a separate table for quick experiments on large event stores
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for math functions
+	"math"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Enumerations for the sampling methods
+const (
+	SampleBernoulli = "bernoulli"
+	SampleSystem    = "system"
+	SampleRandom    = "random"
+)
+
+// Name of the table for the sampled transformed measurements
+const sampleTable = "materialized_view_sample"
+
+/*
+Function to materialize a random sample of the event store into materialized_view_sample, leaving the materialized view untouched.
+The sample is drawn with TABLESAMPLE or, if not available, with WHERE random() < fraction and is repeatable with SAMPLE_SEED
+@param db *sql.DB Database connection to Postgres database
+*/
+func materializeTableSample(db *sql.DB) {
+
+	// Save starting time point
+	start := time.Now()
+
+	// Estimate the rows of the event store from the planner statistics, counting them only without statistics
+	var total int64
+	err := readHandle(db).QueryRow("SELECT reltuples::bigint FROM pg_class WHERE oid = 'event_store'::regclass").Scan(&total)
+	checkError(err)
+	if total <= 0 {
+		err = readHandle(db).QueryRow("SELECT COUNT(*) FROM event_store").Scan(&total)
+		checkError(err)
+	}
+
+	// Derive the fraction from the requested number of rows, if given
+	fraction := sampleFraction
+	requested := fmt.Sprintf("%.4g%% of ~%d rows", fraction*100, total)
+	if sampleRows > 0 {
+		fraction = 1
+		if total > 0 {
+			fraction = math.Min(1, float64(sampleRows)/float64(total))
+		}
+		requested = fmt.Sprintf("%d rows", sampleRows)
+	}
+
+	// Fall back to random() on servers without TABLESAMPLE (before PostgreSQL 9.5)
+	method := sampleMethod
+	var version int
+	err = readHandle(db).QueryRow("SELECT current_setting('server_version_num')::int").Scan(&version)
+	checkError(err)
+	if method != SampleRandom && version < 90500 {
+		fmt.Println("TABLESAMPLE is not available on this server, sampling with random() instead")
+		method = SampleRandom
+	}
+
+	// Read the sample in a transaction, so the seed of random() applies to the same connection
+	tx, err := readHandle(db).Begin()
+	checkError(err)
+	defer tx.Rollback()
+
+	// Build the sampling query with the stream filter
+	var query, condition string
+	var args []interface{}
+	if method == SampleRandom {
+		if sampleSeedSet {
+			_, err = tx.Exec("SELECT setseed($1)", math.Mod(float64(sampleSeed), 1e6)/1e6)
+			checkError(err)
+		}
+		args = append(args, fraction)
+		condition = "random() < $1"
+		query = "SELECT * FROM event_store"
+	} else {
+		args = append(args, fraction*100)
+		query = "SELECT * FROM event_store TABLESAMPLE BERNOULLI ($1)"
+		if method == SampleSystem {
+			query = "SELECT * FROM event_store TABLESAMPLE SYSTEM ($1)"
+		}
+		if sampleSeedSet {
+			args = append(args, sampleSeed)
+			query += fmt.Sprintf(" REPEATABLE ($%d)", len(args))
+		}
+	}
+	condition, args = eventStoreCondition(condition, args...)
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	query += " ORDER BY " + readOrder
+
+	// Read the sampled measurements
+	rows, err := tx.Query(query, args...)
+	checkError(err)
+	measurements := make([]Measurement, 0)
+	for rows.Next() {
+		measurements = append(measurements, scanMeasurement(rows))
+	}
+	checkError(rows.Err())
+	rows.Close()
+	checkError(tx.Commit())
+
+	// Create the sample table like the materialized view, if needed, and clean it before
+	_, err = db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (LIKE materialized_view INCLUDING ALL)", sampleTable))
+	checkError(err)
+	_, err = db.Exec("TRUNCATE TABLE " + sampleTable)
+	checkError(err)
+	recordLatencyUnit(db, sampleTable)
+
+	// Transform and write every sampled measurement into the sample table
+	bar := newProgress(int64(len(measurements)))
+	for _, measurement := range measurements {
+		// Drop measurements with a NULL reading, if the NULL policy skips them
+		if measurement.skippedByNullPolicy() {
+			bar.Add(1)
+			continue
+		}
+		// Dead-letter measurements with a NaN or infinite reading, if the non-finite policy doesn't clamp them
+		if measurement.deadLetteredAsNonFinite() {
+			writeDeadLetter(measurement, "non-finite reading", db)
+			bar.Add(1)
+			continue
+		}
+		if err := writeTransformedMeasurement(transformMeasurement(measurement), sampleTable, db); err != nil {
+			handleRowError(measurement, err)
+		}
+		bar.Add(1)
+	}
+	bar.Finish()
+
+	// Print requested and obtained sample size
+	seed := "random"
+	if sampleSeedSet {
+		seed = fmt.Sprintf("seed %d", sampleSeed)
+	}
+	fmt.Printf("Sample (%s, %s): requested %s, obtained %d rows, written into %s in %f seconds\n", method, seed, requested, len(measurements), sampleTable, time.Since(start).Seconds())
+}
@@ -0,0 +1,274 @@
+package main
+
+/*
+@author 1Zero64
+Danger thresholds and the interactive threshold tuner
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for converting strings to numbers
+	"strconv"
+	// Package for string manipulation
+	"strings"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Object structure for the thresholds of the danger levels
+type Thresholds struct {
+	// Temperatures in Grad Celsius, that have to be exceeded for the levels Low, Medium, High and Critical
+	temperature [4]float64
+	// Humidities in percentage, that have to be exceeded for the levels Low, Medium, High and Critical
+	humidity [4]float64
+}
+
+// Default thresholds of the danger levels
+var defaultThresholds = Thresholds{
+	temperature: [4]float64{3, 5, 7, 10},
+	humidity:    [4]float64{20, 40, 50, 60},
+}
+
+// Active thresholds used for the classification
+var thresholds = defaultThresholds
+
+// Thresholds of the configuration (or accepted by the tuner), used without a danger_thresholds table
+var configuredThresholds = defaultThresholds
+
+// Source of the active thresholds
+var thresholdSource = "configuration"
+
+/*
+Function to activate the newest valid threshold set of the danger_thresholds table (level, max_temperature, max_humidity, valid_from)
+or the configured thresholds, if the table doesn't exist or has no valid set. Called at the start of each run, so changes apply to the next run
+@param db *sql.DB Database connection to Postgres database
+*/
+func refreshThresholds(db *sql.DB) {
+
+	// Fall back to the configured thresholds
+	thresholds = configuredThresholds
+	thresholdSource = "configuration"
+
+	// Resolve the thresholds per event stream once for the run
+	defer resolveStreamThresholds()
+
+	// Check the table exists
+	var exists bool
+	err := db.QueryRow("SELECT to_regclass('danger_thresholds') IS NOT NULL").Scan(&exists)
+	checkError(err)
+	if !exists {
+		return
+	}
+
+	// Read the newest set, that is already valid
+	rows, err := db.Query("SELECT level, max_temperature, max_humidity, valid_from FROM danger_thresholds WHERE valid_from = (SELECT MAX(valid_from) FROM danger_thresholds WHERE valid_from <= NOW())")
+	checkError(err)
+	defer rows.Close()
+	temperatures := make(map[string]float64)
+	humidities := make(map[string]float64)
+	var validFrom time.Time
+	for rows.Next() {
+		var level string
+		var temperature, humidity float64
+		checkError(rows.Scan(&level, &temperature, &humidity, &validFrom))
+		temperatures[level] = temperature
+		humidities[level] = humidity
+	}
+	checkError(rows.Err())
+	if len(temperatures) == 0 {
+		return
+	}
+
+	// Check the set is complete and validate it like the configured thresholds
+	temperatureList := make([]string, 0, 4)
+	humidityList := make([]string, 0, 4)
+	for _, level := range dangerLevels[1:] {
+		temperature, found := temperatures[level]
+		if !found {
+			checkError(fmt.Errorf("invalid danger_thresholds set valid from %s: level %s is missing", validFrom.Format(time.RFC3339), level))
+		}
+		temperatureList = append(temperatureList, strconv.FormatFloat(temperature, 'g', -1, 64))
+		humidityList = append(humidityList, strconv.FormatFloat(humidities[level], 'g', -1, 64))
+	}
+	temperature, err := parseThresholds(strings.Join(temperatureList, ","))
+	if err != nil {
+		checkError(fmt.Errorf("invalid danger_thresholds set valid from %s: temperature: %w", validFrom.Format(time.RFC3339), err))
+	}
+	humidity, err := parseThresholds(strings.Join(humidityList, ","))
+	if err != nil {
+		checkError(fmt.Errorf("invalid danger_thresholds set valid from %s: humidity: %w", validFrom.Format(time.RFC3339), err))
+	}
+
+	// Activate the loaded set
+	thresholds = Thresholds{temperature: temperature, humidity: humidity}
+	thresholdSource = "danger_thresholds valid from " + validFrom.Format(time.RFC3339)
+}
+
+/*
+Function to parse a comma-separated list of four ascending thresholds (e.g. "3,5,7,10")
+@param value Comma-separated thresholds for the levels Low, Medium, High and Critical
+@return Parsed thresholds or an error for malformed or not ascending values
+*/
+func parseThresholds(value string) ([4]float64, error) {
+
+	// Split list and check number of thresholds
+	var parsed [4]float64
+	parts := strings.Split(value, ",")
+	if len(parts) != len(parsed) {
+		return parsed, fmt.Errorf("expected %d comma-separated thresholds, got %d", len(parsed), len(parts))
+	}
+
+	// Parse thresholds and check they are strictly ascending
+	for i, part := range parts {
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return parsed, err
+		}
+		if i > 0 && threshold <= parsed[i-1] {
+			return parsed, fmt.Errorf("thresholds must be ascending, but %v follows %v", threshold, parsed[i-1])
+		}
+		parsed[i] = threshold
+	}
+	return parsed, nil
+}
+
+/*
+Function to format thresholds as comma-separated list
+@param values Thresholds for the levels Low, Medium, High and Critical
+@return Comma-separated thresholds
+*/
+func formatThresholds(values [4]float64) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
+
+/*
+Function to read a .env variable as thresholds with a default value
+@param name Name of the variable
+@param defaultValue Thresholds to use, if the variable is not set or empty
+@return Parsed thresholds of the variable or the default value
+*/
+func getThresholdsEnv(name string, defaultValue [4]float64) [4]float64 {
+
+	// Return default value for missing variables
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	// Parse value and check on error with handler
+	parsed, err := parseThresholds(value)
+	if err != nil {
+		checkError(fmt.Errorf("invalid %s %q: %w", name, value, err))
+	}
+	return parsed
+}
+
+/*
+Function to tune the danger thresholds interactively by showing the resulting danger level histogram
+over the current event store for candidate thresholds. Nothing is written into the materialized view
+@param db *sql.DB Database connection to Postgres database
+*/
+func tuneThresholds(db *sql.DB) {
+
+	// Read measurements once for all candidates
+	measurements := readMeasurements(db, "")
+	fmt.Printf("Read %d measurements for tuning\n", len(measurements))
+
+	// Start with the active thresholds
+	candidate := thresholds
+
+	// Loop until the candidate is accepted or discarded
+	for {
+		// Classify all measurements with the candidate and count the danger levels
+		counts := make(map[string]int)
+		for _, measurement := range measurements {
+			counts[classifyWithThresholds(candidate, measurement.temperature, measurement.humidity)]++
+		}
+
+		// Print candidate and histogram
+		fmt.Println()
+		fmt.Printf("Temperature thresholds: %s\n", formatThresholds(candidate.temperature))
+		fmt.Printf("Humidity thresholds:    %s\n", formatThresholds(candidate.humidity))
+		printDangerHistogram(counts, len(measurements))
+
+		// Get user input for the next action
+		action := consolePrompt.text("t: new temperature thresholds, h: new humidity thresholds, a: accept, d: discard: ")
+
+		switch action {
+		case "t", "h":
+			// Get user input for the new thresholds and parse them
+			input := consolePrompt.text("Thresholds for Low,Medium,High,Critical (e.g. 3,5,7,10): ")
+			parsed, err := parseThresholds(input)
+			if err != nil {
+				fmt.Printf("Invalid thresholds: %v\n", err)
+				continue
+			}
+			if action == "t" {
+				candidate.temperature = parsed
+			} else {
+				candidate.humidity = parsed
+			}
+		case "a":
+			// Activate the candidate for the following runs
+			thresholds = candidate
+			configuredThresholds = candidate
+			fmt.Println("Thresholds accepted for this session")
+
+			// Get user input whether to save the thresholds to the .env file
+			save := consolePrompt.text("Save thresholds to .env? (y/n): ")
+			if save == "y" {
+				updateEnvFile(".env", "THRESHOLDS_TEMPERATURE", formatThresholds(candidate.temperature))
+				updateEnvFile(".env", "THRESHOLDS_HUMIDITY", formatThresholds(candidate.humidity))
+				fmt.Println("Thresholds saved to .env")
+			}
+			return
+		case "d":
+			// Keep the active thresholds
+			fmt.Println("Thresholds discarded")
+			return
+		}
+	}
+}
+
+/*
+Function to set a variable in a .env file, replacing an existing assignment or appending a new one
+@param path Path of the .env file
+@param name Name of the variable
+@param value Value of the variable
+*/
+func updateEnvFile(path string, name string, value string) {
+
+	// Read existing file and check on error with handler
+	content, err := os.ReadFile(path)
+	checkError(err)
+
+	// Replace the assignment of the variable in its line, if existing
+	assignment := fmt.Sprintf("%s = %q", name, value)
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	found := false
+	for i, line := range lines {
+		if key, _, ok := strings.Cut(line, "="); ok && strings.TrimSpace(key) == name {
+			lines[i] = assignment
+			found = true
+		}
+	}
+
+	// Append a new assignment otherwise
+	if !found {
+		lines = append(lines, "", assignment)
+	}
+
+	// Write file back
+	err = os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	checkError(err)
+}
@@ -0,0 +1,135 @@
+package main
+
+/*
+@author 1Zero64
+Periodic throughput logging during long materialize runs
+*/
+
+// Importing packages
+import (
+	// Package for formatted printing
+	"fmt"
+	// Package for atomic counters shared by concurrent writes
+	"sync/atomic"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+// Object structure for a throughput sample of a run
+type ThroughputSample struct {
+	// Seconds since the start of the transform and write phase
+	Elapsed float64 `json:"elapsed"`
+	// Number of measurements processed so far
+	Processed int64 `json:"processed"`
+	// Measurements per second over the last interval
+	Instantaneous float64 `json:"instantaneous"`
+	// Measurements per second since the start of the transform and write phase
+	Average float64 `json:"average"`
+	// Estimated remaining seconds (omitted, if the total is unknown)
+	Remaining *float64 `json:"remaining,omitempty"`
+}
+
+// Object structure for the monitor sampling the throughput of a run in the background
+type ThroughputMonitor struct {
+	// Number of processed measurements, incremented by all writes
+	processed atomic.Int64
+	// Total number of measurements (-1, if unknown)
+	total int64
+	// Time point on when the monitor was started
+	start time.Time
+	// Samples taken so far
+	samples []ThroughputSample
+	// Channel to stop the monitor
+	stopped chan struct{}
+	// Channel closed, when the monitor finished
+	done chan struct{}
+}
+
+/*
+Function to start sampling and logging the throughput every THROUGHPUT_INTERVAL
+@param total Total number of measurements (-1, if unknown)
+@return Pointer to the started monitor or nil, if throughput logging is disabled
+*/
+func startThroughputMonitor(total int64) *ThroughputMonitor {
+
+	// Throughput logging is disabled without an interval
+	if throughputInterval <= 0 {
+		return nil
+	}
+
+	// Initialize monitor
+	monitor := &ThroughputMonitor{
+		total:   total,
+		start:   time.Now(),
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	// Take a sample every interval in the background until stopped
+	go func() {
+		defer close(monitor.done)
+		ticker := time.NewTicker(throughputInterval)
+		defer ticker.Stop()
+		var lastProcessed int64
+		lastSample := monitor.start
+		for {
+			select {
+			case <-monitor.stopped:
+				return
+			case now := <-ticker.C:
+				processed := monitor.processed.Load()
+				sample := ThroughputSample{
+					Elapsed:       now.Sub(monitor.start).Seconds(),
+					Processed:     processed,
+					Instantaneous: float64(processed-lastProcessed) / now.Sub(lastSample).Seconds(),
+				}
+				sample.Average = float64(processed) / sample.Elapsed
+				if monitor.total >= 0 && sample.Average > 0 {
+					remaining := float64(monitor.total-processed) / sample.Average
+					sample.Remaining = &remaining
+				}
+				monitor.samples = append(monitor.samples, sample)
+				monitor.print(sample)
+				lastProcessed = processed
+				lastSample = now
+			}
+		}
+	}()
+
+	// Return started monitor
+	return monitor
+}
+
+/*
+Function to count a processed measurement, safe to call from concurrent writes
+*/
+func (monitor *ThroughputMonitor) add() {
+	if monitor != nil {
+		monitor.processed.Add(1)
+	}
+}
+
+/*
+Function to stop the monitor and get its samples
+@return Samples taken during the run (nil, if throughput logging is disabled)
+*/
+func (monitor *ThroughputMonitor) stop() []ThroughputSample {
+	if monitor == nil {
+		return nil
+	}
+	close(monitor.stopped)
+	<-monitor.done
+	return monitor.samples
+}
+
+/*
+Function to print a throughput sample as log line
+@param sample Throughput sample
+*/
+func (monitor *ThroughputMonitor) print(sample ThroughputSample) {
+	fmt.Printf("Throughput: %d processed, %.0f rows/s (last %s), %.0f rows/s average", sample.Processed, sample.Instantaneous, throughputInterval, sample.Average)
+	if sample.Remaining != nil {
+		fmt.Printf(", ~%s remaining", (time.Duration(*sample.Remaining) * time.Second).String())
+	}
+	fmt.Println()
+}
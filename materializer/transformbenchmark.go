@@ -0,0 +1,85 @@
+package main
+
+/*
+@author 1Zero64
+Microbenchmark of the pure in-memory transformation without database reads and writes
+*/
+
+// Importing packages
+import (
+	// Package to use SQL-like databases
+	"database/sql"
+	// Package for formatted printing
+	"fmt"
+	// Package for math functions
+	"math"
+	// Package for measuring and displaying time values
+	"time"
+)
+
+/*
+Function to read the measurements once and time only the repeated in-memory transformation of them,
+isolating the CPU cost of latency, classification and heat index from the database I/O
+@param db *sql.DB Database connection to Postgres database
+@param iterations Number of iterations
+*/
+func transformMicrobenchmark(db *sql.DB, iterations int) {
+
+	// Generate unique identifier of the benchmark run
+	runId := newRunId()
+
+	// Print information about starting the test
+	fmt.Printf("Starting pure transform microbenchmark (run %s)...\n", runId)
+
+	// Read the measurements once, the reading isn't timed
+	var measurements []Measurement
+	if sourceMode == ModeCsv {
+		measurements = readCsvMeasurements(sourceCsvPath)
+	} else {
+		measurements = readMeasurements(db, "")
+	}
+
+	// Transform all measurements in every iteration, counting the danger levels so the work can't be optimized away
+	durations := make([]float64, 0, iterations)
+	counts := make(map[string]int)
+	for i := 0; i < iterations; i++ {
+		start := time.Now()
+		for _, measurement := range measurements {
+			if measurement.skippedByNullPolicy() || measurement.deadLetteredAsNonFinite() {
+				continue
+			}
+			counts[transformMeasurement(measurement).danger]++
+		}
+		durations = append(durations, time.Since(start).Seconds())
+		fmt.Printf("Iteration %d/%d finished\n", (i + 1), iterations)
+	}
+
+	// Calculate statistics of the iteration durations
+	average, standardDeviation := meanAndStandardDeviation(durations)
+	fastest, slowest := math.Inf(1), 0.0
+	for _, duration := range durations {
+		fastest = math.Min(fastest, duration)
+		slowest = math.Max(slowest, duration)
+	}
+
+	// Display statistics separately from the end-to-end microbenchmark
+	fmt.Print("Pure transform microbenchmark finished\n\n")
+	fmt.Println("Go Materializer Pure Transform Microbenchmark (no database reads and writes)")
+	fmt.Printf("Run id:\t\t\t\t%s\n", runId)
+	fmt.Printf("Build:\t\t\t\t%s\n", buildInfo())
+	fmt.Printf("Number of Iterations:\t\t%d\n", iterations)
+	fmt.Printf("Datapoints transformed each:\t%d\n", len(measurements))
+	fmt.Printf("Fastest iteration (min):\t%f seconds\n", fastest)
+	fmt.Printf("Slowest iteration (max):\t%f seconds\n", slowest)
+	fmt.Printf("Average duration (avg/mean):\t%f seconds\n", average)
+	fmt.Printf("Median duration (median):\t%f seconds\n", median(durations))
+	fmt.Printf("Standard deviation:\t\t%f seconds\n", standardDeviation)
+	if len(measurements) > 0 && average > 0 {
+		fmt.Printf("Per measurement:\t\t%f nanoseconds\n", average*1e9/float64(len(measurements)))
+		fmt.Printf("Throughput:\t\t\t%.0f measurements/second\n", float64(len(measurements))/average)
+	}
+	fmt.Print("\n\n")
+	fmt.Println("All runs (unsorted):")
+	fmt.Println(durations)
+	fmt.Println()
+}
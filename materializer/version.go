@@ -0,0 +1,118 @@
+package main
+
+/*
+@author 1Zero64
+Version and build information of the binary to tell results of different builds apart
+*/
+
+// Importing packages
+import (
+	// Package for formatted printing
+	"fmt"
+	// Package with interface to operating system functionality
+	"os"
+	// Package for interaction with the Go runtime
+	"runtime"
+	// Package for the build information embedded by the Go toolchain
+	"runtime/debug"
+)
+
+// Version, git commit and build date set at build time with -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=..."
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// Object structure for the build information of the binary
+type BuildInfo struct {
+	// Version string
+	Version string `json:"version"`
+	// Git commit the binary was built from
+	Commit string `json:"commit"`
+	// Build date, or commit date without a build date
+	BuildDate string `json:"build_date"`
+	// Go version of the toolchain
+	GoVersion string `json:"go_version"`
+	// Flag whether the working tree had uncommitted changes
+	Modified bool `json:"modified,omitempty"`
+}
+
+/*
+Function to check whether the version subcommand is requested.
+The arguments are looked up directly, because the version is printed without loading the configuration
+@return True, if version is the first argument
+*/
+func versionRequested() bool {
+	return len(os.Args) > 1 && os.Args[1] == "version"
+}
+
+/*
+Function to get the build information from the -ldflags values, falling back to the information embedded by the Go toolchain
+@return Build information of the binary
+*/
+func buildInfo() BuildInfo {
+
+	// Take the values set at build time
+	info := BuildInfo{Version: version, Commit: commit, BuildDate: buildDate, GoVersion: runtime.Version()}
+
+	// Fill missing values from the module and version control information embedded by go build
+	embedded, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if info.Version == "dev" && embedded.Main.Version != "" && embedded.Main.Version != "(devel)" {
+		info.Version = embedded.Main.Version
+	}
+	for _, setting := range embedded.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildDate == "" {
+				info.BuildDate = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = commit == "" && setting.Value == "true"
+		}
+	}
+
+	// Return build information
+	return info
+}
+
+/*
+Function to describe the build information in a single line
+@return Version, commit, build date and Go version
+*/
+func (info BuildInfo) String() string {
+	commit := info.Commit
+	if commit == "" {
+		commit = "unknown"
+	}
+	if info.Modified {
+		commit += " (modified)"
+	}
+	buildDate := info.BuildDate
+	if buildDate == "" {
+		buildDate = "unknown"
+	}
+	return fmt.Sprintf("%s, commit %s, built %s, %s", info.Version, commit, buildDate, info.GoVersion)
+}
+
+/*
+Function to print the version and build information
+*/
+func printVersion() {
+	info := buildInfo()
+	commit := info.Commit
+	if info.Modified {
+		commit += " (modified)"
+	}
+	fmt.Printf("Version:\t%s\n", info.Version)
+	fmt.Printf("Commit:\t\t%s\n", commit)
+	fmt.Printf("Build date:\t%s\n", info.BuildDate)
+	fmt.Printf("Go version:\t%s\n", info.GoVersion)
+}